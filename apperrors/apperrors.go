@@ -0,0 +1,203 @@
+package apperrors
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// Code is the stable, machine-readable identifier sent to clients.
+type Code string
+
+const (
+	CodeNotFound   Code = "not_found"
+	CodeConflict   Code = "conflict"
+	CodeValidation Code = "validation_failed"
+	CodeInvalidID  Code = "invalid_id"
+	CodeBadRequest Code = "bad_request"
+	CodeInternal   Code = "internal_error"
+
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodePreconditionFailed   Code = "precondition_failed"
+	CodePreconditionRequired Code = "precondition_required"
+	CodeFailedDependency     Code = "failed_dependency"
+)
+
+// Sentinels for errors.Is. Any *Error with the same Code matches.
+var (
+	ErrNotFound   = &Error{Code: CodeNotFound, Message: "resource not found"}
+	ErrConflict   = &Error{Code: CodeConflict, Message: "conflict"}
+	ErrValidation = &Error{Code: CodeValidation, Message: "validation failed"}
+	ErrInvalidID  = &Error{Code: CodeInvalidID, Message: "invalid id"}
+	ErrBadRequest = &Error{Code: CodeBadRequest, Message: "bad request"}
+
+	ErrUnsupportedMediaType = &Error{Code: CodeUnsupportedMediaType, Message: "unsupported media type"}
+	ErrPreconditionFailed   = &Error{Code: CodePreconditionFailed, Message: "precondition failed"}
+	ErrPreconditionRequired = &Error{Code: CodePreconditionRequired, Message: "precondition required"}
+	ErrFailedDependency     = &Error{Code: CodeFailedDependency, Message: "failed dependency"}
+)
+
+// Error is a failure that is safe to describe to clients: Message and Fields
+// are sent in responses, Err is only logged.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	// Extensions are extra members sent with the message, such as the IDs
+	// of the records a conflict is about.
+	Extensions map[string]any
+	Err        error
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	for _, f := range e.Fields {
+		msg += fmt.Sprintf("; %s: %s", f.Field, f.Message)
+	}
+	if e.Err != nil {
+		msg += fmt.Sprintf(": %v", e.Err)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With returns a copy of e with one more extension member; e itself, which
+// may be a shared sentinel, is left unchanged.
+func (e *Error) With(key string, value any) *Error {
+	c := *e
+	c.Extensions = maps.Clone(e.Extensions)
+	if c.Extensions == nil {
+		c.Extensions = make(map[string]any)
+	}
+	c.Extensions[key] = value
+	return &c
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func NotFound(format string, args ...any) *Error {
+	return &Error{Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...any) *Error {
+	return &Error{Code: CodeConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func Validation(err error, fields ...FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: "validation failed", Fields: fields, Err: err}
+}
+
+func InvalidID(resource string, err error) *Error {
+	return &Error{Code: CodeInvalidID, Message: fmt.Sprintf("invalid %s id", resource), Err: err}
+}
+
+// BadRequest reports a request that could not be read. The message is sent
+// to the client; err, which may come from a decoder or the network, is only
+// logged.
+func BadRequest(err error, format string, args ...any) *Error {
+	return &Error{Code: CodeBadRequest, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// InvalidJSON reports a body the JSON decoder rejected. Decoder messages name
+// Go types, so the client is only told which field has the wrong type, when
+// the decoder knows.
+func InvalidJSON(err error) *Error {
+	if field, ok := DecodeFieldError(err); ok {
+		return &Error{Code: CodeBadRequest, Message: "the request body has a field of the wrong type", Fields: []FieldError{field}, Err: err}
+	}
+	return &Error{Code: CodeBadRequest, Message: "the request body is not valid JSON", Err: err}
+}
+
+// DecodeFieldError describes the field of a JSON document that a decoding
+// error is about, by its name in the document and the JSON type it must
+// have.
+func DecodeFieldError(err error) (FieldError, bool) {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return FieldError{}, false
+	}
+	return FieldError{
+		Field:   typeErr.Field,
+		Message: fmt.Sprintf("%s must be %s", typeErr.Field, jsonType(typeErr.Type)),
+	}, true
+}
+
+var textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// jsonType names the JSON type a value of Go type t is decoded from.
+func jsonType(t reflect.Type) string {
+	if t == nil {
+		return "of another type"
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshaler) {
+		return "a string"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "a whole number"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("a whole number between 0 and %d", uint64(1)<<t.Bits()-1)
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "of another type"
+}
+
+func UnsupportedMediaType(mediaType string, supported ...string) *Error {
+	return &Error{
+		Code:    CodeUnsupportedMediaType,
+		Message: fmt.Sprintf("unsupported media type %q, expected one of %s", mediaType, strings.Join(supported, ", ")),
+	}
+}
+
+// PreconditionFailed reports a write based on an outdated version of a
+// resource.
+func PreconditionFailed(format string, args ...any) *Error {
+	return &Error{Code: CodePreconditionFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+func PreconditionRequired(format string, args ...any) *Error {
+	return &Error{Code: CodePreconditionRequired, Message: fmt.Sprintf(format, args...)}
+}
+
+// FailedDependency reports a part of a request that was not carried out
+// because another part failed.
+func FailedDependency(format string, args ...any) *Error {
+	return &Error{Code: CodeFailedDependency, Message: fmt.Sprintf(format, args...)}
+}
+
+// CodeOf returns the Code of the first *Error in err's chain, or
+// CodeInternal when there is none.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
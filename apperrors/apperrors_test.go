@@ -0,0 +1,50 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestInvalidJSON(t *testing.T) {
+	type engine struct {
+		ID       uuid.UUID `json:"id"`
+		CarRange uint16    `json:"carRange"`
+	}
+	type request struct {
+		Name   string  `json:"name"`
+		Engine *engine `json:"engine"`
+	}
+
+	tests := []struct {
+		body       string
+		wantFields []FieldError
+	}{
+		{`{"name":`, nil},
+		{`{"name":12}`, []FieldError{{Field: "name", Message: "name must be a string"}}},
+		{`{"engine":{"carRange":70000}}`, []FieldError{{Field: "engine.carRange", Message: "engine.carRange must be a whole number between 0 and 65535"}}},
+		{`{"engine":{"id":1}}`, []FieldError{{Field: "engine.id", Message: "engine.id must be a string"}}},
+		{`{"engine":[]}`, []FieldError{{Field: "engine", Message: "engine must be an object"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			decodeErr := json.Unmarshal([]byte(tt.body), &request{})
+			err := InvalidJSON(decodeErr)
+
+			if err.Code != CodeBadRequest || !errors.Is(err, decodeErr) {
+				t.Fatalf("InvalidJSON = %#v, want a bad request wrapping the decoder error", err)
+			}
+			// The decoder names Go types, such as uint16 and request.
+			if strings.Contains(err.Message, "Go") || strings.Contains(err.Message, "uint16") {
+				t.Fatalf("Message = %q, want no decoder text", err.Message)
+			}
+			if len(err.Fields) != len(tt.wantFields) || (len(tt.wantFields) > 0 && err.Fields[0] != tt.wantFields[0]) {
+				t.Fatalf("Fields = %+v, want %+v", err.Fields, tt.wantFields)
+			}
+		})
+	}
+}
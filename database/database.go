@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -34,17 +33,3 @@ func ConnectPostgres(connStr string) *sql.DB {
 func GetDB() *sql.DB {
 	return db
 }
-
-func ExecuteSQLSchema(db *sql.DB, fileName string) error {
-	sqlFile, err := os.ReadFile(fileName)
-	if err != nil {
-		return fmt.Errorf("error reading schema file: %v", err)
-	}
-
-	_, err = db.Exec(string(sqlFile))
-	if err != nil {
-		return fmt.Errorf("error executing schema file: %v", err)
-	}
-
-	return nil
-}
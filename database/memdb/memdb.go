@@ -0,0 +1,250 @@
+package memdb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CarRow struct {
+	CarID     uuid.UUID
+	Name      string
+	Year      uint16
+	Brand     string
+	FuelType  string
+	EngineID  uuid.NullUUID
+	Price     float64
+	VIN       string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Version   int64
+	DeletedAt *time.Time
+}
+
+type EngineRow struct {
+	EngineID      uuid.UUID
+	Displacement  uint16
+	NoOfCylinders uint16
+	CarRange      uint16
+	Version       int64
+	DeletedAt     *time.Time
+}
+
+// AuditRow stores its changes as JSON, like the audit_log.changes column.
+type AuditRow struct {
+	AuditID    uuid.UUID
+	EntityType string
+	EntityID   uuid.UUID
+	Action     string
+	Actor      string
+	RequestID  string
+	Changes    []byte
+	Version    int64
+	CreatedAt  time.Time
+}
+
+// JobRow stores its params, result, error and artifact as JSON, like the
+// jsonb columns of jobs.
+type JobRow struct {
+	JobID           uuid.UUID
+	Kind            string
+	Status          string
+	Params          []byte
+	Processed       int64
+	Result          []byte
+	Error           []byte
+	Artifact        []byte
+	CancelRequested bool
+	Attempt         int
+	LeaseUntil      *time.Time
+	Actor           string
+	RequestID       string
+	CreatedAt       time.Time
+	StartedAt       *time.Time
+	FinishedAt      *time.Time
+	UpdatedAt       time.Time
+}
+
+// JobFileKey identifies one chunk of a job file.
+type JobFileKey struct {
+	JobID uuid.UUID
+	Name  string
+	Seq   int
+}
+
+type JobFileRow struct {
+	Data      []byte
+	CreatedAt time.Time
+}
+
+// DB is an in-memory stand-in for the Postgres database. Reads run under a
+// shared lock; writes are buffered in a Tx and only applied if the callback
+// succeeds, so a failed write never leaves partial changes behind.
+type DB struct {
+	mu      sync.RWMutex
+	cars    map[uuid.UUID]CarRow
+	engines map[uuid.UUID]EngineRow
+	audit   map[uuid.UUID]AuditRow
+	jobs    map[uuid.UUID]JobRow
+	files   map[JobFileKey]JobFileRow
+}
+
+func New() *DB {
+	return &DB{
+		cars:    make(map[uuid.UUID]CarRow),
+		engines: make(map[uuid.UUID]EngineRow),
+		audit:   make(map[uuid.UUID]AuditRow),
+		jobs:    make(map[uuid.UUID]JobRow),
+		files:   make(map[JobFileKey]JobFileRow),
+	}
+}
+
+type Tx struct {
+	Cars     *Table[uuid.UUID, CarRow]
+	Engines  *Table[uuid.UUID, EngineRow]
+	Audit    *Table[uuid.UUID, AuditRow]
+	Jobs     *Table[uuid.UUID, JobRow]
+	JobFiles *Table[JobFileKey, JobFileRow]
+}
+
+func (db *DB) newTx(readOnly bool) *Tx {
+	return &Tx{
+		Cars:     newTable(db.cars, readOnly),
+		Engines:  newTable(db.engines, readOnly),
+		Audit:    newTable(db.audit, readOnly),
+		Jobs:     newTable(db.jobs, readOnly),
+		JobFiles: newTable(db.files, readOnly),
+	}
+}
+
+// View runs fn in a read-only transaction.
+func (db *DB) View(fn func(tx *Tx) error) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return fn(db.newTx(true))
+}
+
+// Update runs fn in a read-write transaction and commits its writes when fn
+// returns nil.
+func (db *DB) Update(fn func(tx *Tx) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx := db.newTx(false)
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	tx.Cars.commit()
+	tx.Engines.commit()
+	tx.Audit.commit()
+	tx.Jobs.commit()
+	tx.JobFiles.commit()
+	return nil
+}
+
+type txKey struct{}
+
+// WithinTx is the in-memory database.Transactor: fn runs in one read-write
+// transaction carried by its ctx, and the repositories called with that ctx
+// join it. Calls inside fn join the outer transaction. Writes are applied
+// only when fn returns nil.
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return fn(ctx)
+	}
+
+	return db.Update(func(tx *Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// ViewContext is View, joining the transaction carried by ctx if any.
+func (db *DB) ViewContext(ctx context.Context, fn func(tx *Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return fn(tx)
+	}
+	return db.View(fn)
+}
+
+// UpdateContext is Update, joining the transaction carried by ctx if any.
+func (db *DB) UpdateContext(ctx context.Context, fn func(tx *Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*Tx); ok {
+		return fn(tx)
+	}
+	return db.Update(fn)
+}
+
+// Table is a transactional view over one map: writes go to an overlay that is
+// merged into the base map on commit.
+type Table[K comparable, V any] struct {
+	base     map[K]V
+	writes   map[K]*V
+	readOnly bool
+}
+
+func newTable[K comparable, V any](base map[K]V, readOnly bool) *Table[K, V] {
+	return &Table[K, V]{base: base, writes: make(map[K]*V), readOnly: readOnly}
+}
+
+func (t *Table[K, V]) Get(key K) (V, bool) {
+	if row, ok := t.writes[key]; ok {
+		if row == nil {
+			var zero V
+			return zero, false
+		}
+		return *row, true
+	}
+
+	row, ok := t.base[key]
+	return row, ok
+}
+
+func (t *Table[K, V]) Put(key K, row V) {
+	if t.readOnly {
+		panic("memdb: write in read-only transaction")
+	}
+	t.writes[key] = &row
+}
+
+func (t *Table[K, V]) Delete(key K) {
+	if t.readOnly {
+		panic("memdb: write in read-only transaction")
+	}
+	t.writes[key] = nil
+}
+
+// Scan calls fn for every row visible in the transaction until fn returns
+// false. Iteration order is unspecified.
+func (t *Table[K, V]) Scan(fn func(key K, row V) bool) {
+	for key, row := range t.base {
+		if _, ok := t.writes[key]; ok {
+			continue
+		}
+		if !fn(key, row) {
+			return
+		}
+	}
+
+	for key, row := range t.writes {
+		if row == nil {
+			continue
+		}
+		if !fn(key, *row) {
+			return
+		}
+	}
+}
+
+func (t *Table[K, V]) commit() {
+	for key, row := range t.writes {
+		if row == nil {
+			delete(t.base, key)
+			continue
+		}
+		t.base[key] = *row
+	}
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS engines (
+    engine_id UUID PRIMARY KEY,
+    displacement INTEGER NOT NULL,
+    no_of_cylinders INTEGER NOT NULL,
+    car_range INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS cars (
+    car_id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    year INTEGER NOT NULL,
+    brand TEXT NOT NULL,
+    fuel_type TEXT NOT NULL,
+    engine_id UUID REFERENCES engines (engine_id),
+    price NUMERIC(12, 2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS cars_brand_idx ON cars (brand);
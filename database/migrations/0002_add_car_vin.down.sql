@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS cars_vin_key;
+
+ALTER TABLE cars DROP COLUMN IF EXISTS vin;
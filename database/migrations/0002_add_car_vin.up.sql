@@ -0,0 +1,3 @@
+ALTER TABLE cars ADD COLUMN vin TEXT;
+
+CREATE UNIQUE INDEX cars_vin_key ON cars (vin) WHERE vin IS NOT NULL;
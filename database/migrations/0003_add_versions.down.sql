@@ -0,0 +1,3 @@
+ALTER TABLE cars DROP COLUMN IF EXISTS version;
+
+ALTER TABLE engines DROP COLUMN IF EXISTS version;
@@ -0,0 +1,3 @@
+ALTER TABLE engines ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE cars ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS engines_deleted_at_idx;
+DROP INDEX IF EXISTS cars_deleted_at_idx;
+
+DELETE FROM cars WHERE deleted_at IS NOT NULL;
+DELETE FROM engines WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS cars_vin_key;
+CREATE UNIQUE INDEX cars_vin_key ON cars (vin) WHERE vin IS NOT NULL;
+
+ALTER TABLE cars DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE engines DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+ALTER TABLE engines ADD COLUMN deleted_at TIMESTAMPTZ;
+
+ALTER TABLE cars ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- A trashed car no longer holds on to its VIN.
+DROP INDEX IF EXISTS cars_vin_key;
+CREATE UNIQUE INDEX cars_vin_key ON cars (vin) WHERE vin IS NOT NULL AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS cars_deleted_at_idx ON cars (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS engines_deleted_at_idx ON engines (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1 @@
+DELETE FROM audit_log WHERE action = 'snapshot';
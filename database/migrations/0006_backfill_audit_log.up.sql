@@ -0,0 +1,40 @@
+-- Gives every engine and car written before the audit log a snapshot of its
+-- current state, so their history can be replayed.
+--
+-- Engines have no timestamps, so an engine snapshot is stamped at the
+-- creation of the earliest car that uses it, and otherwise when it was
+-- deleted or now. A car snapshot is stamped at its last update, and as of
+-- any earlier time the car reads as not existing yet. Snapshots hold the
+-- state at the time of the migration, so earlier states are not recorded.
+INSERT INTO audit_log (audit_id, entity_type, entity_id, action, actor, changes, version, created_at)
+SELECT gen_random_uuid(), 'engine', e.engine_id, 'snapshot', 'system',
+    (SELECT COALESCE(jsonb_agg(jsonb_build_object('field', f.field, 'before', NULL, 'after', f.value) ORDER BY f.field), '[]')
+    FROM (VALUES
+        ('carRange', to_jsonb(e.car_range)),
+        ('deletedAt', to_jsonb(e.deleted_at)),
+        ('displacement', to_jsonb(e.displacement)),
+        ('noOfCylinders', to_jsonb(e.no_of_cylinders))
+    ) AS f (field, value)
+    WHERE f.value IS NOT NULL),
+    e.version,
+    LEAST((SELECT MIN(c.created_at) FROM cars c WHERE c.engine_id = e.engine_id), COALESCE(e.deleted_at, now()))
+FROM engines e
+WHERE NOT EXISTS (SELECT 1 FROM audit_log a WHERE a.entity_type = 'engine' AND a.entity_id = e.engine_id);
+
+INSERT INTO audit_log (audit_id, entity_type, entity_id, action, actor, changes, version, created_at)
+SELECT gen_random_uuid(), 'car', c.car_id, 'snapshot', 'system',
+    (SELECT COALESCE(jsonb_agg(jsonb_build_object('field', f.field, 'before', NULL, 'after', f.value) ORDER BY f.field), '[]')
+    FROM (VALUES
+        ('brand', to_jsonb(c.brand)),
+        ('deletedAt', to_jsonb(c.deleted_at)),
+        ('engineId', to_jsonb(c.engine_id)),
+        ('fuelType', to_jsonb(c.fuel_type)),
+        ('name', to_jsonb(c.name)),
+        ('price', to_jsonb(c.price)),
+        ('vin', to_jsonb(c.vin)),
+        ('year', to_jsonb(c.year))
+    ) AS f (field, value)
+    WHERE f.value IS NOT NULL),
+    c.version, c.updated_at
+FROM cars c
+WHERE NOT EXISTS (SELECT 1 FROM audit_log a WHERE a.entity_type = 'car' AND a.entity_id = c.car_id);
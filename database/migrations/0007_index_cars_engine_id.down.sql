@@ -0,0 +1 @@
+DROP INDEX IF EXISTS cars_engine_idx;
@@ -0,0 +1,4 @@
+-- Serves listing the cars of an engine in the default createdAt order, the
+-- in-use check before an engine is deleted and the foreign key check when
+-- one is purged.
+CREATE INDEX IF NOT EXISTS cars_engine_idx ON cars (engine_id, created_at, car_id);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    job_id UUID PRIMARY KEY,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL,
+    params JSONB NOT NULL,
+    processed BIGINT NOT NULL DEFAULT 0,
+    result JSONB,
+    error JSONB,
+    artifact JSONB,
+    cancel_requested BOOLEAN NOT NULL DEFAULT false,
+    attempt INTEGER NOT NULL DEFAULT 0,
+    lease_until TIMESTAMPTZ,
+    actor TEXT NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Workers take the oldest job that is queued or whose lease expired.
+CREATE INDEX IF NOT EXISTS jobs_pending_idx ON jobs (created_at) WHERE status IN ('queued', 'running');
+
+-- The input of a job is stored before the job itself, so files have no
+-- foreign key; files without a job are purged with expired jobs.
+CREATE TABLE IF NOT EXISTS job_files (
+    job_id UUID NOT NULL,
+    name TEXT NOT NULL,
+    seq INTEGER NOT NULL,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (job_id, name, seq)
+);
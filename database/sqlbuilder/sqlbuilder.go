@@ -0,0 +1,121 @@
+// Package sqlbuilder assembles the dynamic parts of Postgres queries. Values
+// are always bound as numbered placeholders; only SQL fragments written in
+// the code (column names picked from a whitelist, operators) are ever
+// inlined.
+package sqlbuilder
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Builder struct {
+	sets    []string
+	conds   []string
+	orderBy []string
+	args    []any
+}
+
+func New() *Builder {
+	return &Builder{}
+}
+
+// Arg binds v and returns its placeholder.
+func (b *Builder) Arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Row binds one row of a VALUES list and returns it as "($1, $2, ...)".
+func (b *Builder) Row(values ...any) string {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.Arg(v)
+	}
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// Set adds a "column = value" assignment for an UPDATE. column must come
+// from the code, never from user input.
+func (b *Builder) Set(column string, v any) *Builder {
+	b.sets = append(b.sets, column+" = "+b.Arg(v))
+	return b
+}
+
+// SetExpr adds an assignment written in the code, such as
+// "version = version + 1".
+func (b *Builder) SetExpr(assignment string) *Builder {
+	b.sets = append(b.sets, assignment)
+	return b
+}
+
+// SetClause returns "SET ..." or an empty string when nothing is assigned.
+func (b *Builder) SetClause() string {
+	if len(b.sets) == 0 {
+		return ""
+	}
+	return "SET " + strings.Join(b.sets, ", ")
+}
+
+// Where adds a condition joined with AND. Each ? in cond is replaced by the
+// placeholder of the matching argument.
+func (b *Builder) Where(cond string, args ...any) *Builder {
+	var sb strings.Builder
+
+	i := 0
+	for _, r := range cond {
+		if r == '?' && i < len(args) {
+			sb.WriteString(b.Arg(args[i]))
+			i++
+			continue
+		}
+		sb.WriteRune(r)
+	}
+
+	b.conds = append(b.conds, sb.String())
+	return b
+}
+
+// OrderBy appends a sort key. column must come from a fixed whitelist, never
+// from user input.
+func (b *Builder) OrderBy(column string, desc bool) *Builder {
+	if desc {
+		column += " DESC"
+	} else {
+		column += " ASC"
+	}
+	b.orderBy = append(b.orderBy, column)
+	return b
+}
+
+// WhereClause returns "WHERE ..." or an empty string when there are no
+// conditions.
+func (b *Builder) WhereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conds, " AND ")
+}
+
+func (b *Builder) OrderByClause() string {
+	if len(b.orderBy) == 0 {
+		return ""
+	}
+	return "ORDER BY " + strings.Join(b.orderBy, ", ")
+}
+
+// LimitOffset binds limit and offset and returns the clause.
+func (b *Builder) LimitOffset(limit, offset int) string {
+	return "LIMIT " + b.Arg(limit) + " OFFSET " + b.Arg(offset)
+}
+
+func (b *Builder) Args() []any {
+	return b.args
+}
+
+// Contains returns an ILIKE pattern matching s anywhere, with LIKE
+// wildcards in s escaped.
+func Contains(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(s) + "%"
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// maxTxAttempts bounds how often a transaction is run when it keeps failing
+// with a serialization failure or a deadlock.
+const maxTxAttempts = 3
+
+// Transactor runs several repository calls as one unit of work. fn receives
+// a context carrying the transaction; repositories called with that context
+// join it instead of starting their own. The transaction commits when fn
+// returns nil and rolls back when it returns an error or panics. Calls made
+// inside fn join the outer transaction.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Querier is what *sql.DB and *sql.Tx have in common.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// TxManager is the Postgres Transactor. A transaction failing with a
+// serialization failure or a deadlock is rolled back and fn is run again,
+// so fn must not have side effects outside the database.
+type TxManager struct {
+	db *sql.DB
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	return retry(ctx, maxTxAttempts, func() error {
+		return m.run(ctx, fn)
+	})
+}
+
+// InTx is WithinTx for repositories: fn also receives the transaction.
+func (m *TxManager) InTx(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return m.WithinTx(ctx, func(ctx context.Context) error {
+		return fn(ctx, ctx.Value(txKey{}).(*sql.Tx))
+	})
+}
+
+// Querier returns the transaction carried by ctx, or the pool when there is
+// none, so reads see the writes of the unit of work they are part of.
+func (m *TxManager) Querier(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return m.db
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// retry runs fn up to attempts times while it fails with a retryable error,
+// backing off a little longer each time.
+func retry(ctx context.Context, attempts int, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt == attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * 20 * time.Millisecond):
+		}
+	}
+}
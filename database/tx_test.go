@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestRetry(t *testing.T) {
+	serialization := &pq.Error{Code: pgSerializationFailure}
+	deadlock := &pq.Error{Code: pgDeadlockDetected}
+	other := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		errs     []error
+		wantErr  error
+		wantRuns int
+	}{
+		{"success", []error{nil}, nil, 1},
+		{"serialization failure then success", []error{serialization, nil}, nil, 2},
+		{"deadlock then success", []error{deadlock, deadlock, nil}, nil, 3},
+		{"gives up", []error{serialization, deadlock, serialization, nil}, serialization, 3},
+		{"other errors are not retried", []error{other, nil}, other, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := 0
+			err := retry(context.Background(), 3, func() error {
+				runs++
+				return tt.errs[runs-1]
+			})
+
+			if err != tt.wantErr || runs != tt.wantRuns {
+				t.Fatalf("retry = %v after %d runs, want %v after %d", err, runs, tt.wantErr, tt.wantRuns)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package etag maps resource versions to ETag and If-Match headers for
+// optimistic concurrency control.
+package etag
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+)
+
+// Any is the version If-Match: * asks for. Repositories skip the version
+// check for it.
+const Any int64 = 0
+
+// Format returns the strong entity tag of a version.
+func Format(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch returns the version the If-Match header of r requires. The header
+// is mandatory; a tag that cannot match any version fails the precondition.
+func IfMatch(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, apperrors.PreconditionRequired("the If-Match header is required, send the ETag of the resource")
+	}
+	if header == "*" {
+		return Any, nil
+	}
+
+	// Weak tags never match with the strong comparison If-Match uses.
+	if unquoted, ok := strings.CutPrefix(header, `"`); ok {
+		if unquoted, ok = strings.CutSuffix(unquoted, `"`); ok {
+			if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil && version > 0 {
+				return version, nil
+			}
+		}
+	}
+	return 0, apperrors.PreconditionFailed("If-Match %s does not match the current version", header)
+}
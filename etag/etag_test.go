@@ -0,0 +1,44 @@
+package etag
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/codepnw/go-car-management/apperrors"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int64
+		wantErr error
+	}{
+		{Format(3), 3, nil},
+		{`  "12" `, 12, nil},
+		{"*", Any, nil},
+		{"", 0, apperrors.ErrPreconditionRequired},
+		{`W/"3"`, 0, apperrors.ErrPreconditionFailed},
+		{`3`, 0, apperrors.ErrPreconditionFailed},
+		{`"0"`, 0, apperrors.ErrPreconditionFailed},
+		{`"abc"`, 0, apperrors.ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodPut, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("If-Match", tt.header)
+		}
+
+		got, err := IfMatch(r)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IfMatch(%q) error = %v, want %v", tt.header, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Fatalf("IfMatch(%q) = %d, %v, want %d", tt.header, got, err, tt.want)
+		}
+	}
+}
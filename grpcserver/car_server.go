@@ -0,0 +1,135 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/codepnw/go-car-management/modules/cars"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	"github.com/codepnw/go-car-management/pagination"
+	inventoryv1 "github.com/codepnw/go-car-management/proto/inventory/v1"
+)
+
+type carServer struct {
+	inventoryv1.UnimplementedCarServiceServer
+	service carservices.ICarService
+}
+
+func (s *carServer) GetCar(ctx context.Context, req *inventoryv1.GetCarRequest) (*inventoryv1.Car, error) {
+	var f fields
+	asOf := f.time("asOf", req.AsOf)
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	var car *cars.Car
+	var err error
+	if asOf != nil {
+		car, err = s.service.GetCarAsOf(ctx, req.Id, *asOf)
+	} else {
+		car, err = s.service.GetCarById(ctx, req.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newCar(car), nil
+}
+
+// ListCars sends the listing a page at a time, following its cursor.
+func (s *carServer) ListCars(req *inventoryv1.ListCarsRequest, stream inventoryv1.CarService_ListCarsServer) error {
+	var f fields
+	filter := &cars.CarFilter{
+		Brands:                req.Brands,
+		FuelTypes:             req.FuelTypes,
+		Name:                  req.Name,
+		YearMin:               f.optionalUint16("yearMin", req.YearMin),
+		YearMax:               f.optionalUint16("yearMax", req.YearMax),
+		PriceMin:              req.PriceMin,
+		PriceMax:              req.PriceMax,
+		EngineDisplacementMin: f.optionalUint16("engineDisplacementMin", req.EngineDisplacementMin),
+		EngineDisplacementMax: f.optionalUint16("engineDisplacementMax", req.EngineDisplacementMax),
+		EngineCylinders:       f.optionalUint16("engineCylinders", req.EngineCylinders),
+		EngineCarRangeMin:     f.optionalUint16("engineCarRangeMin", req.EngineCarRangeMin),
+		EngineCarRangeMax:     f.optionalUint16("engineCarRangeMax", req.EngineCarRangeMax),
+		Sort:                  req.Sort,
+		Desc:                  req.Desc,
+		Limit:                 cars.MaxListLimit,
+		Trashed:               req.Trashed,
+	}
+	if err := f.err(); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	for {
+		var page *pagination.Page[*cars.Car]
+		var err error
+		if req.EngineId != "" {
+			page, err = s.service.ListCarsByEngine(ctx, req.EngineId, filter)
+		} else {
+			page, err = s.service.ListCars(ctx, filter)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, car := range page.Items {
+			if err := stream.Send(newCar(car)); err != nil {
+				return err
+			}
+		}
+
+		if page.Next == nil {
+			return nil
+		}
+		filter.Cursor = page.Next
+	}
+}
+
+func (s *carServer) CreateCar(ctx context.Context, req *inventoryv1.CreateCarRequest) (*inventoryv1.Car, error) {
+	carReq, err := carRequest(req.Car)
+	if err != nil {
+		return nil, err
+	}
+
+	car, err := s.service.CreateCar(ctx, carReq)
+	if err != nil {
+		return nil, err
+	}
+	return newCar(car), nil
+}
+
+func (s *carServer) UpdateCar(ctx context.Context, req *inventoryv1.UpdateCarRequest) (*inventoryv1.Car, error) {
+	if err := requireVersion("car", req.Version); err != nil {
+		return nil, err
+	}
+	carReq, err := carRequest(req.Car)
+	if err != nil {
+		return nil, err
+	}
+
+	car, err := s.service.UpdateCar(ctx, req.Id, carReq, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	return newCar(car), nil
+}
+
+func (s *carServer) DeleteCar(ctx context.Context, req *inventoryv1.DeleteCarRequest) (*inventoryv1.Car, error) {
+	if err := requireVersion("car", req.Version); err != nil {
+		return nil, err
+	}
+
+	car, err := s.service.DeleteCar(ctx, req.Id, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	return newCar(car), nil
+}
+
+func (s *carServer) RestoreCar(ctx context.Context, req *inventoryv1.RestoreCarRequest) (*inventoryv1.Car, error) {
+	car, err := s.service.RestoreCar(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return newCar(car), nil
+}
@@ -0,0 +1,173 @@
+package grpcserver
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carv2"
+	"github.com/codepnw/go-car-management/modules/engines"
+	inventoryv1 "github.com/codepnw/go-car-management/proto/inventory/v1"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func newCar(c *cars.Car) *inventoryv1.Car {
+	price := carv2.NewMoney(c.Price)
+
+	return &inventoryv1.Car{
+		Id:        c.CarID.String(),
+		Name:      c.Name,
+		Year:      uint32(c.Year),
+		Brand:     c.Brand,
+		FuelType:  c.FuelType,
+		Engine:    newEngine(c.Engine),
+		Price:     &inventoryv1.Money{Amount: price.Amount, Currency: price.Currency},
+		Vin:       c.VIN,
+		Version:   c.Version,
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+		DeletedAt: timestamp(c.DeletedAt),
+	}
+}
+
+// newEngine returns the message of e, nil for a nil engine.
+func newEngine(e *engines.Engine) *inventoryv1.Engine {
+	if e == nil {
+		return nil
+	}
+
+	return &inventoryv1.Engine{
+		Id:            e.EngineID.String(),
+		Displacement:  uint32(e.Displacement),
+		NoOfCylinders: uint32(e.NoOfCylinders),
+		CarRange:      uint32(e.CarRange),
+		Version:       e.Version,
+		DeletedAt:     timestamp(e.DeletedAt),
+	}
+}
+
+func newEngineUsage(e *engines.EngineUsage) *inventoryv1.Engine {
+	engine := newEngine(&e.Engine)
+	engine.UsageCount = int64(e.UsageCount)
+	return engine
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// fields collects every field of a request that does not fit the services,
+// like httpquery.Parser does for query parameters. Fields are named as in
+// the JSON form of the messages, as the services name theirs.
+type fields []apperrors.FieldError
+
+func (f *fields) fail(field, message string) {
+	*f = append(*f, apperrors.FieldError{Field: field, Message: message})
+}
+
+func (f *fields) uint16(field string, v uint32) uint16 {
+	if v > math.MaxUint16 {
+		f.fail(field, field+" must be at most "+strconv.Itoa(math.MaxUint16))
+	}
+	return uint16(v)
+}
+
+// optionalUint16 is uint16 for an optional field, nil when it is not set.
+func (f *fields) optionalUint16(field string, v *uint32) *uint16 {
+	if v == nil {
+		return nil
+	}
+	n := f.uint16(field, *v)
+	return &n
+}
+
+func (f *fields) uuid(field, s string) uuid.UUID {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		f.fail(field, field+" must be a UUID")
+	}
+	return id
+}
+
+func (f *fields) time(field string, t *timestamppb.Timestamp) *time.Time {
+	if t == nil {
+		return nil
+	}
+	if err := t.CheckValid(); err != nil {
+		f.fail(field, field+" must be a valid timestamp")
+		return nil
+	}
+	at := t.AsTime()
+	return &at
+}
+
+func (f *fields) err() error {
+	if len(*f) > 0 {
+		return apperrors.Validation(nil, *f...)
+	}
+	return nil
+}
+
+// carRequest converts in, leaving missing fields zero for the service to
+// report.
+func carRequest(in *inventoryv1.CarInput) (*cars.CarRequest, error) {
+	if in == nil {
+		return nil, apperrors.Validation(nil, apperrors.FieldError{Field: "car", Message: "car is required"})
+	}
+
+	var f fields
+	req := &cars.CarRequest{
+		Name:     in.Name,
+		Year:     f.uint16("year", in.Year),
+		Brand:    in.Brand,
+		FuelType: in.FuelType,
+		VIN:      in.Vin,
+	}
+
+	switch engine := in.Engine.(type) {
+	case *inventoryv1.CarInput_EngineId:
+		req.Engine = &engines.Engine{EngineID: f.uuid("engine.engineId", engine.EngineId)}
+	case *inventoryv1.CarInput_EngineSpec:
+		spec := engineRequest(&f, "engine.", engine.EngineSpec)
+		req.Engine = &engines.Engine{Displacement: spec.Displacement, NoOfCylinders: spec.NoOfCylinders, CarRange: spec.CarRange}
+	}
+
+	if in.Price != nil {
+		var invalid []apperrors.FieldError
+		req.Price, invalid = (&carv2.Money{Amount: in.Price.Amount, Currency: in.Price.Currency}).Value("price")
+		f = append(f, invalid...)
+	}
+
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// engineRequest converts spec, reporting its fields under prefix.
+func engineRequest(f *fields, prefix string, spec *inventoryv1.EngineSpec) *engines.EngineRequest {
+	if spec == nil {
+		return &engines.EngineRequest{}
+	}
+
+	return &engines.EngineRequest{
+		Displacement:  f.uint16(prefix+"displacement", spec.Displacement),
+		NoOfCylinders: f.uint16(prefix+"noOfCylinders", spec.NoOfCylinders),
+		CarRange:      f.uint16(prefix+"carRange", spec.CarRange),
+	}
+}
+
+// requireVersion rejects writes without the version the resource was read
+// at: there is no counterpart of If-Match: * over gRPC.
+func requireVersion(resource string, version int64) error {
+	if version == 0 {
+		return apperrors.PreconditionRequired("version is required, send the version the %s was read at", resource)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/codepnw/go-car-management/modules/engines"
+	engservices "github.com/codepnw/go-car-management/modules/engines/services"
+	inventoryv1 "github.com/codepnw/go-car-management/proto/inventory/v1"
+)
+
+type engineServer struct {
+	inventoryv1.UnimplementedEngineServiceServer
+	service engservices.IEngineService
+}
+
+func (s *engineServer) GetEngine(ctx context.Context, req *inventoryv1.GetEngineRequest) (*inventoryv1.Engine, error) {
+	engine, err := s.service.GetEngineByID(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(engine), nil
+}
+
+// ListEngines sends the listing a page at a time, following its cursor.
+func (s *engineServer) ListEngines(req *inventoryv1.ListEnginesRequest, stream inventoryv1.EngineService_ListEnginesServer) error {
+	var f fields
+	filter := &engines.EngineFilter{
+		DisplacementMin: f.optionalUint16("displacementMin", req.DisplacementMin),
+		DisplacementMax: f.optionalUint16("displacementMax", req.DisplacementMax),
+		NoOfCylinders:   f.optionalUint16("noOfCylinders", req.NoOfCylinders),
+		CarRangeMin:     f.optionalUint16("carRangeMin", req.CarRangeMin),
+		CarRangeMax:     f.optionalUint16("carRangeMax", req.CarRangeMax),
+		Sort:            req.Sort,
+		Desc:            req.Desc,
+		Limit:           engines.MaxListLimit,
+		Trashed:         req.Trashed,
+	}
+	if err := f.err(); err != nil {
+		return err
+	}
+
+	for {
+		page, err := s.service.ListEngines(stream.Context(), filter)
+		if err != nil {
+			return err
+		}
+
+		for _, engine := range page.Items {
+			if err := stream.Send(newEngineUsage(engine)); err != nil {
+				return err
+			}
+		}
+
+		if page.Next == nil {
+			return nil
+		}
+		filter.Cursor = page.Next
+	}
+}
+
+func (s *engineServer) CreateEngine(ctx context.Context, req *inventoryv1.CreateEngineRequest) (*inventoryv1.Engine, error) {
+	var f fields
+	engineReq := engineRequest(&f, "", req.Engine)
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	engine, err := s.service.CreateEngine(ctx, engineReq)
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(engine), nil
+}
+
+func (s *engineServer) UpdateEngine(ctx context.Context, req *inventoryv1.UpdateEngineRequest) (*inventoryv1.Engine, error) {
+	if err := requireVersion("engine", req.Version); err != nil {
+		return nil, err
+	}
+	var f fields
+	engineReq := engineRequest(&f, "", req.Engine)
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	engine, err := s.service.UpdateEngine(ctx, req.Id, engineReq, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(engine), nil
+}
+
+func (s *engineServer) DeleteEngine(ctx context.Context, req *inventoryv1.DeleteEngineRequest) (*inventoryv1.Engine, error) {
+	if err := requireVersion("engine", req.Version); err != nil {
+		return nil, err
+	}
+
+	var f fields
+	opts := &engines.DeleteOptions{}
+	switch cars := req.Cars.(type) {
+	case *inventoryv1.DeleteEngineRequest_DetachCars:
+		if cars.DetachCars {
+			opts.Cascade = engines.CascadeDetach
+		}
+	case *inventoryv1.DeleteEngineRequest_ReassignTo:
+		id := f.uuid("reassignTo", cars.ReassignTo)
+		opts.ReassignTo = &id
+	}
+	if err := f.err(); err != nil {
+		return nil, err
+	}
+
+	engine, err := s.service.DeleteEngine(ctx, req.Id, req.Version, opts)
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(engine), nil
+}
+
+func (s *engineServer) RestoreEngine(ctx context.Context, req *inventoryv1.RestoreEngineRequest) (*inventoryv1.Engine, error) {
+	engine, err := s.service.RestoreEngine(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return newEngine(engine), nil
+}
@@ -0,0 +1,97 @@
+// Package grpcserver serves the car and engine services over gRPC, for
+// internal services that would rather not call the HTTP API.
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/codepnw/go-car-management/middlewares"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	engservices "github.com/codepnw/go-car-management/modules/engines/services"
+	inventoryv1 "github.com/codepnw/go-car-management/proto/inventory/v1"
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+)
+
+// The metadata keys of the correlation ID and the actor, the counterparts of
+// the X-Request-ID and X-Actor headers.
+const (
+	RequestIDKey = "x-request-id"
+	ActorKey     = "x-actor"
+)
+
+// New returns a server with the car and engine services registered. Like the
+// HTTP API, every call is tagged with a correlation ID, echoed back in the
+// response header, and an actor for the audit log.
+func New(carService carservices.ICarService, engineService engservices.IEngineService) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptor),
+		grpc.ChainStreamInterceptor(streamInterceptor),
+	)
+
+	inventoryv1.RegisterCarServiceServer(s, &carServer{service: carService})
+	inventoryv1.RegisterEngineServiceServer(s, &engineServer{service: engineService})
+	reflection.Register(s)
+
+	return s
+}
+
+func unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = withRequest(ctx)
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, statusError(ctx, info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+func streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := withRequest(ss.Context())
+
+	if err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx}); err != nil {
+		return statusError(ctx, info.FullMethod, err)
+	}
+	return nil
+}
+
+// serverStream is a stream with the context of withRequest.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// withRequest reads the correlation ID and the actor from the metadata of a
+// call, the way middlewares.RequestID and middlewares.Actor read headers.
+func withRequest(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := firstValue(md, RequestIDKey)
+	if !middlewares.ValidRequestID(id) {
+		id = uuid.NewString()
+	}
+	actor := strings.TrimSpace(firstValue(md, ActorKey))
+	if !middlewares.ValidActor(actor) {
+		actor = middlewares.AnonymousActor
+	}
+
+	// Only fails when the header was already sent, which it cannot be yet.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+
+	return requestctx.WithActor(requestctx.WithRequestID(ctx, id), actor)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
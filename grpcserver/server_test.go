@@ -0,0 +1,259 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/codepnw/go-car-management/database/memdb"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	engservices "github.com/codepnw/go-car-management/modules/engines/services"
+	inventoryv1 "github.com/codepnw/go-car-management/proto/inventory/v1"
+	"github.com/codepnw/go-car-management/routes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClients(t *testing.T) (inventoryv1.CarServiceClient, inventoryv1.EngineServiceClient) {
+	t.Helper()
+
+	repos := routes.NewMemoryRepositories(memdb.New())
+	server := New(
+		carservices.NewCarService(repos.Car, repos.Engine, repos.Audit, repos.Tx),
+		engservices.NewEngineService(repos.Engine, repos.Car, repos.Tx),
+	)
+
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return inventoryv1.NewCarServiceClient(conn), inventoryv1.NewEngineServiceClient(conn)
+}
+
+func electricCar(name, engineID string) *inventoryv1.CarInput {
+	return &inventoryv1.CarInput{
+		Name:     name,
+		Year:     2024,
+		Brand:    "Tesla",
+		FuelType: "Electric",
+		Engine:   &inventoryv1.CarInput_EngineId{EngineId: engineID},
+		Price:    &inventoryv1.Money{Amount: "39999.5", Currency: "USD"},
+	}
+}
+
+// wantStatus fails unless err is a status with code, and returns its details.
+func wantStatus(t *testing.T, err error, code codes.Code) []any {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != code {
+		t.Fatalf("got error %v, want code %s", err, code)
+	}
+	return st.Details()
+}
+
+func TestCarLifecycle(t *testing.T) {
+	carClient, engineClient := newTestClients(t)
+	ctx := context.Background()
+
+	engine, err := engineClient.CreateEngine(ctx, &inventoryv1.CreateEngineRequest{Engine: &inventoryv1.EngineSpec{CarRange: 500}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(ctx, RequestIDKey, "req-1", ActorKey, "inventory-sync")
+	car, err := carClient.CreateCar(ctx, &inventoryv1.CreateCarRequest{Car: electricCar("Model 3", engine.Id)}, grpc.Header(&header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(RequestIDKey); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("got request ID %v, want req-1", got)
+	}
+	if car.Engine.GetId() != engine.Id || car.Price.GetAmount() != "39999.5" || car.Version != 1 {
+		t.Errorf("got car %v", car)
+	}
+
+	updated, err := carClient.UpdateCar(ctx, &inventoryv1.UpdateCarRequest{Id: car.Id, Car: electricCar("Model 3 LR", engine.Id), Version: car.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Model 3 LR" || updated.Version != 2 {
+		t.Errorf("got updated car %v", updated)
+	}
+
+	_, err = carClient.DeleteCar(ctx, &inventoryv1.DeleteCarRequest{Id: car.Id, Version: car.Version})
+	wantStatus(t, err, codes.Aborted)
+
+	deleted, err := carClient.DeleteCar(ctx, &inventoryv1.DeleteCarRequest{Id: car.Id, Version: updated.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.DeletedAt == nil {
+		t.Error("deleted car has no deletedAt")
+	}
+
+	_, err = carClient.GetCar(ctx, &inventoryv1.GetCarRequest{Id: car.Id})
+	wantStatus(t, err, codes.NotFound)
+
+	if _, err := carClient.RestoreCar(ctx, &inventoryv1.RestoreCarRequest{Id: car.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := carClient.GetCar(ctx, &inventoryv1.GetCarRequest{Id: car.Id}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestListCarsStreamsEveryPage lists more cars than fit in one page.
+func TestListCarsStreamsEveryPage(t *testing.T) {
+	carClient, engineClient := newTestClients(t)
+	ctx := context.Background()
+
+	engine, err := engineClient.CreateEngine(ctx, &inventoryv1.CreateEngineRequest{Engine: &inventoryv1.EngineSpec{CarRange: 500}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 105
+	for i := range n {
+		if _, err := carClient.CreateCar(ctx, &inventoryv1.CreateCarRequest{Car: electricCar(fmt.Sprintf("Car %03d", i), engine.Id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stream, err := carClient.ListCars(ctx, &inventoryv1.ListCarsRequest{Sort: "name", EngineId: engine.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		car, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, car.Name)
+	}
+	if len(names) != n {
+		t.Fatalf("got %d cars, want %d", len(names), n)
+	}
+	for i, name := range names {
+		if want := fmt.Sprintf("Car %03d", i); name != want {
+			t.Fatalf("got car %d %q, want %q", i, name, want)
+		}
+	}
+
+	engines, err := engineClient.ListEngines(ctx, &inventoryv1.ListEnginesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err := engines.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Id != engine.Id || usage.UsageCount != n {
+		t.Errorf("got engine %v, want %s used by %d cars", usage, engine.Id, n)
+	}
+	if _, err := engines.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v after the only engine, want EOF", err)
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	carClient, engineClient := newTestClients(t)
+	ctx := context.Background()
+
+	engine, err := engineClient.CreateEngine(ctx, &inventoryv1.CreateEngineRequest{Engine: &inventoryv1.EngineSpec{CarRange: 500}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	car, err := carClient.CreateCar(ctx, &inventoryv1.CreateCarRequest{Car: electricCar("Model Y", engine.Id)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("invalid id", func(t *testing.T) {
+		_, err := carClient.GetCar(ctx, &inventoryv1.GetCarRequest{Id: "not-a-uuid"})
+		wantStatus(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		in := electricCar("", engine.Id)
+		in.Year = 70000
+		in.Price.Currency = "EUR"
+		_, err := carClient.CreateCar(ctx, &inventoryv1.CreateCarRequest{Car: in})
+
+		var violations map[string]bool
+		for _, detail := range wantStatus(t, err, codes.InvalidArgument) {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				violations = make(map[string]bool)
+				for _, v := range badRequest.FieldViolations {
+					violations[v.Field] = true
+				}
+			}
+		}
+		if !violations["year"] || !violations["price.currency"] {
+			t.Errorf("got field violations %v, want year and price.currency", violations)
+		}
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		_, err := carClient.DeleteCar(ctx, &inventoryv1.DeleteCarRequest{Id: car.Id})
+		wantStatus(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("engine in use", func(t *testing.T) {
+		_, err := engineClient.DeleteEngine(ctx, &inventoryv1.DeleteEngineRequest{Id: engine.Id, Version: engine.Version})
+
+		var info *errdetails.ErrorInfo
+		for _, detail := range wantStatus(t, err, codes.FailedPrecondition) {
+			if i, ok := detail.(*errdetails.ErrorInfo); ok {
+				info = i
+			}
+		}
+		if info.GetReason() != "CONFLICT" || info.GetDomain() != ErrorDomain || info.GetMetadata()["correlationId"] == "" {
+			t.Errorf("got error info %v", info)
+		}
+		if want := `["` + car.Id + `"]`; info.GetMetadata()["carIds"] != want || info.GetMetadata()["carCount"] != "1" {
+			t.Errorf("got error info %v", info)
+		}
+	})
+
+	t.Run("detach cars", func(t *testing.T) {
+		_, err := engineClient.DeleteEngine(ctx, &inventoryv1.DeleteEngineRequest{
+			Id:      engine.Id,
+			Version: engine.Version,
+			Cars:    &inventoryv1.DeleteEngineRequest_DetachCars{DetachCars: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := carClient.GetCar(ctx, &inventoryv1.GetCarRequest{Id: car.Id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Engine != nil {
+			t.Errorf("got engine %v on a detached car", got.Engine)
+		}
+	})
+}
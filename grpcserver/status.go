@@ -0,0 +1,103 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/requestctx"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo of every error.
+const ErrorDomain = "car-management"
+
+// statusCodes are the gRPC counterparts of the HTTP statuses of
+// middlewares.NewProblem. A conflict is a state the client has to change
+// first, and an outdated version a read-modify-write to start over.
+var statusCodes = map[apperrors.Code]codes.Code{
+	apperrors.CodeNotFound:   codes.NotFound,
+	apperrors.CodeConflict:   codes.FailedPrecondition,
+	apperrors.CodeValidation: codes.InvalidArgument,
+	apperrors.CodeInvalidID:  codes.InvalidArgument,
+	apperrors.CodeBadRequest: codes.InvalidArgument,
+	apperrors.CodeInternal:   codes.Internal,
+
+	apperrors.CodeUnsupportedMediaType: codes.InvalidArgument,
+	apperrors.CodePreconditionFailed:   codes.Aborted,
+	apperrors.CodePreconditionRequired: codes.InvalidArgument,
+	apperrors.CodeFailedDependency:     codes.Aborted,
+}
+
+// statusError converts err to a status error and logs it with the
+// correlation ID. Only apperrors messages reach the client.
+func statusError(ctx context.Context, method string, err error) error {
+	st := statusOf(err, requestctx.RequestID(ctx))
+
+	log.Printf("[%s] %s: %s %v", requestctx.RequestID(ctx), method, st.Code(), err)
+
+	return st.Err()
+}
+
+// statusOf returns the status of err. It carries a google.rpc.ErrorInfo with
+// the error code, the correlation ID and the extensions of the error, and a
+// google.rpc.BadRequest when there are invalid fields.
+func statusOf(err error, correlationID string) *status.Status {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+	// Such as a failed send to a client that went away.
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	code := apperrors.CodeOf(err)
+	statusCode, ok := statusCodes[code]
+	if !ok {
+		code, statusCode = apperrors.CodeInternal, codes.Internal
+	}
+
+	info := &errdetails.ErrorInfo{
+		Reason:   strings.ToUpper(string(code)),
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{"correlationId": correlationID},
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		st, _ := status.New(statusCode, "An unexpected error occurred. Quote the correlation ID when reporting it.").WithDetails(info)
+		return st
+	}
+
+	for key, value := range appErr.Extensions {
+		info.Metadata[key] = metadataValue(value)
+	}
+	st, _ := status.New(statusCode, appErr.Message).WithDetails(info)
+	if len(appErr.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(appErr.Fields))
+		for i, f := range appErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		st, _ = st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	}
+	return st
+}
+
+// metadataValue renders an extension member as a string, the only type
+// ErrorInfo metadata holds: strings as they are, anything else as JSON.
+func metadataValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(raw)
+}
@@ -0,0 +1,169 @@
+// Package httpquery parses listing query parameters, collecting every
+// malformed value as a field error instead of stopping at the first one.
+package httpquery
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type Parser struct {
+	c      *gin.Context
+	fields []apperrors.FieldError
+}
+
+func New(c *gin.Context) *Parser {
+	return &Parser{c: c}
+}
+
+// Strings returns every value of a repeated or comma-separated parameter:
+// ?brand=Honda&brand=Toyota and ?brand=Honda,Toyota are equivalent.
+func (p *Parser) Strings(name string) []string {
+	var values []string
+	for _, raw := range p.c.QueryArray(name) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func (p *Parser) String(name string) string {
+	return strings.TrimSpace(p.c.Query(name))
+}
+
+func (p *Parser) Uint16(name string) *uint16 {
+	raw, ok := p.c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil
+	}
+
+	v, err := strconv.ParseUint(raw, 10, 16)
+	if err != nil {
+		p.fail(name, name+" must be a whole number between 0 and 65535")
+		return nil
+	}
+
+	n := uint16(v)
+	return &n
+}
+
+func (p *Parser) Float(name string) *float64 {
+	raw, ok := p.c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		p.fail(name, name+" must be a number")
+		return nil
+	}
+
+	return &v
+}
+
+// Int returns 0 when the parameter is absent.
+func (p *Parser) Int(name string) int {
+	raw, ok := p.c.GetQuery(name)
+	if !ok || raw == "" {
+		return 0
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		p.fail(name, name+" must be a whole number")
+		return 0
+	}
+
+	return v
+}
+
+// Bool reads true or false; a parameter given without a value, as in
+// ?dryRun, is true.
+func (p *Parser) Bool(name string) bool {
+	raw, ok := p.c.GetQuery(name)
+	if !ok {
+		return false
+	}
+	if raw == "" {
+		return true
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		p.fail(name, name+" must be true or false")
+		return false
+	}
+
+	return v
+}
+
+func (p *Parser) UUID(name string) *uuid.UUID {
+	raw, ok := p.c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil
+	}
+
+	v, err := uuid.Parse(raw)
+	if err != nil {
+		p.fail(name, name+" must be a UUID")
+		return nil
+	}
+
+	return &v
+}
+
+// Time reads an RFC 3339 timestamp such as 2024-05-01T09:30:00Z.
+func (p *Parser) Time(name string) *time.Time {
+	raw, ok := p.c.GetQuery(name)
+	if !ok || raw == "" {
+		return nil
+	}
+
+	v, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		p.fail(name, name+" must be an RFC 3339 timestamp")
+		return nil
+	}
+
+	return &v
+}
+
+// Require reports each of the named parameters that is absent or empty.
+func (p *Parser) Require(names ...string) {
+	for _, name := range names {
+		if p.c.Query(name) == "" {
+			p.fail(name, name+" is required")
+		}
+	}
+}
+
+// Sort reads a sort parameter where a leading "-" means descending, as in
+// ?sort=-price.
+func (p *Parser) Sort(name string) (field string, desc bool) {
+	field = p.String(name)
+	if strings.HasPrefix(field, "-") {
+		return field[1:], true
+	}
+	return strings.TrimPrefix(field, "+"), false
+}
+
+// Err returns a validation error listing every malformed parameter, or nil.
+func (p *Parser) Err() error {
+	if len(p.fields) == 0 {
+		return nil
+	}
+	return apperrors.Validation(nil, p.fields...)
+}
+
+func (p *Parser) fail(field, message string) {
+	p.fields = append(p.fields, apperrors.FieldError{Field: field, Message: message})
+}
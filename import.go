@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codepnw/go-car-management/modules/cars/carimport"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/codepnw/go-car-management/routes"
+)
+
+const importUsage = "usage: import [-dry-run] [-mapping field=Header,...] [-delimiter c] file.csv | -"
+
+// importActor is recorded in the audit log for cars imported from the
+// command line.
+const importActor = "cli"
+
+func runImport(ctx context.Context, repos *routes.Repositories, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file without importing anything")
+	mapping := flags.String("mapping", "", "columns of the fields whose header is not their name, as field=Header,...")
+	delimiter := flags.String("delimiter", "", `column delimiter, "tab" for tab-separated files`)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(importUsage)
+	}
+
+	opts := &carimport.Options{DryRun: *dryRun}
+	var err error
+	if opts.Mapping, err = carimport.ParseMapping(*mapping); err != nil {
+		return err
+	}
+	if opts.Comma, err = carimport.ParseDelimiter(*delimiter); err != nil {
+		return err
+	}
+
+	var file io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		file = f
+	}
+
+	service := carservices.NewCarService(repos.Car, repos.Engine, repos.Audit, repos.Tx)
+	importer := carimport.NewImporter(service, repos.Engine)
+
+	report, err := importer.Import(requestctx.WithActor(ctx, importActor), file, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range report.Errors {
+		fmt.Printf("line %d: %s\n", rowErr.Line, rowErr.Message)
+		for _, field := range rowErr.Fields {
+			fmt.Printf("  %s: %s\n", field.Field, field.Message)
+		}
+	}
+	if report.ErrorsTruncated {
+		fmt.Printf("only the first %d errors are listed\n", len(report.Errors))
+	}
+
+	verb := "imported"
+	if report.DryRun {
+		verb = "would import"
+	}
+	fmt.Printf("%d rows: %s %d cars, %d failed, %d new engines\n",
+		report.Rows, verb, report.Imported, report.Failed, report.EnginesCreated)
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d rows failed", report.Failed)
+	}
+	return nil
+}
@@ -1,39 +1,93 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"time"
 
 	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/grpcserver"
+	"github.com/codepnw/go-car-management/middlewares"
+	"github.com/codepnw/go-car-management/modules/cars/carimport"
+	"github.com/codepnw/go-car-management/modules/cars/carjobs"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	engservices "github.com/codepnw/go-car-management/modules/engines/services"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	jobservices "github.com/codepnw/go-car-management/modules/jobs/services"
+	"github.com/codepnw/go-car-management/pagination"
 	"github.com/codepnw/go-car-management/routes"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
-const (
-	envFile = "dev.env"
-	version = "/v1"
-	schemaFile = "database/schema.sql"
-)
+const envFile = "dev.env"
 
 func main() {
 	if err := godotenv.Load(envFile); err != nil {
-		log.Fatalf("error loading .env file: %v", err)
+		log.Printf("%s not loaded, using process environment: %v", envFile, err)
 	}
 
-	// Database
-	db := database.ConnectPostgres(os.Getenv("DB_CONN_STR"))
-	defer db.Close()
+	var repos *routes.Repositories
 
-	if err := database.ExecuteSQLSchema(db, schemaFile); err != nil {
-		log.Fatal(err)
+	// STORAGE=memory runs the API without Postgres; data lives only as long
+	// as the process.
+	if os.Getenv("STORAGE") == "memory" {
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			log.Fatal("migrate is not available with STORAGE=memory")
+		}
+		// The imported cars would be gone when the command exits.
+		if len(os.Args) > 1 && os.Args[1] == "import" {
+			log.Fatal("import is not available with STORAGE=memory")
+		}
+
+		fmt.Println("using in-memory storage...")
+		repos = routes.NewMemoryRepositories(memdb.New())
+	} else {
+		// Database
+		db := database.ConnectPostgres(os.Getenv("DB_CONN_STR"))
+		defer db.Close()
+
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err := runMigrate(context.Background(), migrator, os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+
+		repos = routes.NewPostgresRepositories(db)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(context.Background(), repos, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
 	r := gin.Default()
+	r.Use(middlewares.RequestID(), middlewares.Actor(), middlewares.ErrorHandler())
+
+	cfg := &routes.Config{Cursors: cursorSigner(), TrashRetention: trashRetention()}
+
+	go purgeTrash(repos, cfg.TrashRetention)
+	go runJobs(repos, jobRetention())
+	go serveGRPC(repos)
 
 	// Routes
-	routes.NewRoutes(db, r, version)
+	routes.NewRoutes(repos, cfg, r, routes.V1, routes.V2)
 
 	port := os.Getenv("APP_PORT")
 	if port == "" {
@@ -43,3 +97,117 @@ func main() {
 	fmt.Println("server starting on port:", port)
 	r.Run(":" + port)
 }
+
+// serveGRPC serves the car and engine services over gRPC on GRPC_PORT,
+// alongside the HTTP API.
+func serveGRPC(repos *routes.Repositories) {
+	port := os.Getenv("GRPC_PORT")
+	if port == "" {
+		port = "9090"
+	}
+
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := grpcserver.New(
+		carservices.NewCarService(repos.Car, repos.Engine, repos.Audit, repos.Tx),
+		engservices.NewEngineService(repos.Engine, repos.Car, repos.Tx),
+	)
+
+	fmt.Println("grpc server starting on port:", port)
+	if err := server.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// cursorSigner signs pagination cursors with CURSOR_SECRET. Without it a
+// random key is used and cursors stop working when the process restarts.
+func cursorSigner() *pagination.Signer {
+	secret := os.Getenv("CURSOR_SECRET")
+	if secret == "" {
+		log.Print("CURSOR_SECRET not set, using a random key for pagination cursors")
+		return pagination.NewRandomSigner()
+	}
+	return pagination.NewSigner([]byte(secret))
+}
+
+const (
+	// defaultTrashRetention keeps deleted records restorable for 30 days.
+	defaultTrashRetention = 30 * 24 * time.Hour
+	// defaultJobRetention keeps finished jobs and their files for 7 days.
+	defaultJobRetention = 7 * 24 * time.Hour
+)
+
+// trashRetention reads TRASH_RETENTION as a Go duration, such as "720h".
+func trashRetention() time.Duration {
+	return retentionEnv("TRASH_RETENTION", defaultTrashRetention)
+}
+
+// jobRetention reads JOB_RETENTION as a Go duration, such as "168h".
+func jobRetention() time.Duration {
+	return retentionEnv("JOB_RETENTION", defaultJobRetention)
+}
+
+func retentionEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention < 0 {
+		log.Fatalf("invalid %s %q: use a duration such as 720h", name, value)
+	}
+	return retention
+}
+
+// purgeTrash permanently deletes expired trash once an hour. Cars go first so
+// the engines they held on to can be purged in the same run.
+func purgeTrash(repos *routes.Repositories, retention time.Duration) {
+	carService := carservices.NewCarService(repos.Car, repos.Engine, repos.Audit, repos.Tx)
+	engineService := engservices.NewEngineService(repos.Engine, repos.Car, repos.Tx)
+
+	for ; ; time.Sleep(time.Hour) {
+		ctx := context.Background()
+
+		cars, err := carService.PurgeCars(ctx, retention)
+		if err != nil {
+			log.Printf("purge trashed cars: %v", err)
+			continue
+		}
+		engines, err := engineService.PurgeEngines(ctx, retention)
+		if err != nil {
+			log.Printf("purge trashed engines: %v", err)
+			continue
+		}
+
+		if cars > 0 || engines > 0 {
+			log.Printf("purged %d cars and %d engines from the trash", cars, engines)
+		}
+	}
+}
+
+// runJobs runs the background jobs, and deletes the finished ones once an
+// hour when they have outlived retention.
+func runJobs(repos *routes.Repositories, retention time.Duration) {
+	carService := carservices.NewCarService(repos.Car, repos.Engine, repos.Audit, repos.Tx)
+	worker := jobservices.NewWorker(repos.Job, map[jobs.Kind]jobs.Runner{
+		jobs.KindExport: carjobs.NewExportRunner(carService, routes.CarRepresentation),
+		jobs.KindImport: carjobs.NewImportRunner(carimport.NewImporter(carService, repos.Engine)),
+	})
+	go worker.Run(context.Background())
+
+	jobService := jobservices.NewJobService(repos.Job)
+	for ; ; time.Sleep(time.Hour) {
+		purged, err := jobService.PurgeJobs(context.Background(), retention)
+		if err != nil {
+			log.Printf("purge finished jobs: %v", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("purged %d finished jobs", purged)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ActorHeader    = "X-Actor"
+	AnonymousActor = "anonymous"
+	maxActorLen    = 128
+)
+
+// Actor records who is making the request, for the audit log. There is no
+// authentication yet, so X-Actor is trusted as sent; requests without a
+// usable one are attributed to AnonymousActor.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := strings.TrimSpace(c.GetHeader(ActorHeader))
+		if !ValidActor(actor) {
+			actor = AnonymousActor
+		}
+
+		c.Request = c.Request.WithContext(requestctx.WithActor(c.Request.Context(), actor))
+
+		c.Next()
+	}
+}
+
+// ValidActor reports whether actor can be recorded as sent.
+func ValidActor(actor string) bool {
+	if actor == "" || len(actor) > maxActorLen {
+		return false
+	}
+	for _, r := range actor {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
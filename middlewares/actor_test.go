@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/gin-gonic/gin"
+)
+
+func TestActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"header", "alice@example.com", "alice@example.com"},
+		{"trimmed", "  Alice Smith ", "Alice Smith"},
+		{"missing", "", AnonymousActor},
+		{"control character", "alice\tbob", AnonymousActor},
+		{"too long", strings.Repeat("a", maxActorLen+1), AnonymousActor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			r := gin.New()
+			r.Use(Actor())
+			r.GET("/", func(c *gin.Context) { got = requestctx.Actor(c.Request.Context()) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(ActorHeader, tt.header)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Fatalf("actor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	since := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	r := gin.New()
+	r.Use(ErrorHandler(), Deprecation(since, sunset, "/v2/openapi.json"))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/fail", func(c *gin.Context) { c.Error(apperrors.NotFound("car not found")) })
+
+	for _, path := range []string{"/ok", "/fail"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		h := w.Header()
+		if got := h.Get("Deprecation"); got != "@1792195200" {
+			t.Errorf("%s: Deprecation = %q", path, got)
+		}
+		if got := h.Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+			t.Errorf("%s: Sunset = %q", path, got)
+		}
+		if got := h.Get("Link"); got != `</v2/openapi.json>; rel="successor-version"` {
+			t.Errorf("%s: Link = %q", path, got)
+		}
+	}
+}
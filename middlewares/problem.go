@@ -0,0 +1,144 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document. Code and CorrelationID
+// are extension members, as are the members in Extensions, which are
+// rendered inline next to them.
+type Problem struct {
+	Type          string                 `json:"type"`
+	Title         string                 `json:"title"`
+	Status        int                    `json:"status"`
+	Detail        string                 `json:"detail,omitempty"`
+	Instance      string                 `json:"instance,omitempty"`
+	Code          apperrors.Code         `json:"code"`
+	CorrelationID string                 `json:"correlationId,omitempty"`
+	Errors        []apperrors.FieldError `json:"errors,omitempty"`
+	Extensions    map[string]any         `json:"-"`
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	raw, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return raw, err
+	}
+
+	// Extensions follow the standard members, which win over extensions of
+	// the same name.
+	var standard map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &standard); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(raw[:len(raw)-1])
+	for _, k := range slices.Sorted(maps.Keys(p.Extensions)) {
+		if _, ok := standard[k]; ok {
+			continue
+		}
+		key, _ := json.Marshal(k)
+		value, err := json.Marshal(p.Extensions[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type problemKind struct {
+	status int
+	title  string
+}
+
+var problemKinds = map[apperrors.Code]problemKind{
+	apperrors.CodeNotFound:   {http.StatusNotFound, "Resource not found"},
+	apperrors.CodeConflict:   {http.StatusConflict, "Conflict"},
+	apperrors.CodeValidation: {http.StatusUnprocessableEntity, "Validation failed"},
+	apperrors.CodeInvalidID:  {http.StatusBadRequest, "Invalid identifier"},
+	apperrors.CodeBadRequest: {http.StatusBadRequest, "Bad request"},
+	apperrors.CodeInternal:   {http.StatusInternalServerError, "Internal server error"},
+
+	apperrors.CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, "Unsupported media type"},
+	apperrors.CodePreconditionFailed:   {http.StatusPreconditionFailed, "Precondition failed"},
+	apperrors.CodePreconditionRequired: {http.StatusPreconditionRequired, "Precondition required"},
+	apperrors.CodeFailedDependency:     {http.StatusFailedDependency, "Failed dependency"},
+}
+
+// ErrorHandler renders the last error a handler attached with c.Error as
+// application/problem+json. Only apperrors messages reach the client; the
+// full error, including driver messages, is logged with the correlation ID.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		// A streamed response can fail after its status was sent, too late
+		// for a problem document.
+		if c.Writer.Written() {
+			log.Printf("[%s] %s %s: failed after the response started: %v", c.GetString(requestIDKey), c.Request.Method, c.Request.URL.Path, err)
+			return
+		}
+
+		problem := NewProblem(err, c.Request.URL.Path, c.GetString(requestIDKey))
+
+		log.Printf("[%s] %s %s: %d %v", problem.CorrelationID, c.Request.Method, c.Request.URL.Path, problem.Status, err)
+
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+func NewProblem(err error, instance, correlationID string) *Problem {
+	code := apperrors.CodeOf(err)
+
+	kind, ok := problemKinds[code]
+	if !ok {
+		code = apperrors.CodeInternal
+		kind = problemKinds[code]
+	}
+
+	problem := &Problem{
+		Type:          "/problems/" + strings.ReplaceAll(string(code), "_", "-"),
+		Title:         kind.title,
+		Status:        kind.status,
+		Instance:      instance,
+		Code:          code,
+		CorrelationID: correlationID,
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		problem.Detail = "An unexpected error occurred. Quote the correlation ID when reporting it."
+		return problem
+	}
+
+	problem.Detail = appErr.Message
+	problem.Errors = appErr.Fields
+	problem.Extensions = appErr.Extensions
+
+	return problem
+}
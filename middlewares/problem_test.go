@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   apperrors.Code
+		wantDetail string
+	}{
+		{"not found", apperrors.NotFound("car not found"), http.StatusNotFound, apperrors.CodeNotFound, "car not found"},
+		{"conflict", apperrors.Conflict("engine is still referenced by cars"), http.StatusConflict, apperrors.CodeConflict, "engine is still referenced by cars"},
+		{"validation", apperrors.Validation(nil, apperrors.FieldError{Field: "year", Message: "is required"}), http.StatusUnprocessableEntity, apperrors.CodeValidation, "validation failed"},
+		{"invalid id", apperrors.InvalidID("car", errors.New("invalid UUID length: 3")), http.StatusBadRequest, apperrors.CodeInvalidID, "invalid car id"},
+		{"unsupported media type", apperrors.UnsupportedMediaType("text/plain", "application/json"), http.StatusUnsupportedMediaType, apperrors.CodeUnsupportedMediaType, `unsupported media type "text/plain", expected one of application/json`},
+		{"precondition failed", apperrors.PreconditionFailed("car has been modified"), http.StatusPreconditionFailed, apperrors.CodePreconditionFailed, "car has been modified"},
+		{"precondition required", apperrors.PreconditionRequired("If-Match header is required"), http.StatusPreconditionRequired, apperrors.CodePreconditionRequired, "If-Match header is required"},
+		{"internal", errors.New(`pq: relation "cars" does not exist`), http.StatusInternalServerError, apperrors.CodeInternal, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(RequestID(), ErrorHandler())
+			r.GET("/cars/:id", func(c *gin.Context) { c.Error(tt.err) })
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/cars/42", nil)
+			req.Header.Set(RequestIDHeader, "req-123")
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, ProblemContentType) {
+				t.Fatalf("Content-Type = %q, want %q", ct, ProblemContentType)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if problem.Code != tt.wantCode || problem.Status != tt.wantStatus || problem.Instance != "/cars/42" || problem.CorrelationID != "req-123" {
+				t.Fatalf("problem = %+v", problem)
+			}
+			if tt.wantDetail != "" && problem.Detail != tt.wantDetail {
+				t.Fatalf("detail = %q, want %q", problem.Detail, tt.wantDetail)
+			}
+			if strings.Contains(w.Body.String(), "pq:") {
+				t.Fatalf("driver error leaked to the client: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProblemExtensions(t *testing.T) {
+	err := apperrors.Conflict("engine is still used by 2 cars").With("carIds", []string{"a", "b"}).With("code", "ignored")
+	problem := NewProblem(err, "/engines/42", "req-123")
+
+	raw, jsonErr := json.Marshal(problem)
+	if jsonErr != nil {
+		t.Fatalf("Marshal: %v", jsonErr)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if ids, _ := got["carIds"].([]any); len(ids) != 2 || ids[0] != "a" {
+		t.Fatalf("carIds = %v, want [a b]", got["carIds"])
+	}
+	if got["code"] != string(apperrors.CodeConflict) || got["status"] != float64(http.StatusConflict) {
+		t.Fatalf("standard members = %v", got)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/codepnw/go-car-management/database"
+)
+
+const migrateUsage = "usage: migrate status | up | down [steps] | redo"
+
+func runMigrate(ctx context.Context, migrator *database.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, appliedAt)
+		}
+
+	case "up":
+		ran, err := migrator.Up(ctx)
+		printMigrations("applied", ran)
+		if err != nil {
+			return err
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps: %s", args[1])
+			}
+			steps = n
+		}
+		ran, err := migrator.Down(ctx, steps)
+		printMigrations("rolled back", ran)
+		if err != nil {
+			return err
+		}
+
+	case "redo":
+		redone, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrations("redone", []*database.Migration{redone})
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
+
+func printMigrations(action string, migrations []*database.Migration) {
+	if len(migrations) == 0 {
+		fmt.Printf("no migrations %s\n", action)
+		return
+	}
+	for _, m := range migrations {
+		fmt.Printf("%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
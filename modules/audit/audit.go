@@ -0,0 +1,116 @@
+// Package audit describes the history of changes to cars and engines. The
+// repositories write an Entry in the same transaction as the change itself,
+// so the log never disagrees with the data.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/google/uuid"
+)
+
+const (
+	EntityCar    = "car"
+	EntityEngine = "engine"
+)
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionPurge   Action = "purge"
+	// ActionSnapshot records the full state of a record that predates the
+	// audit log, as of its last change.
+	ActionSnapshot Action = "snapshot"
+)
+
+// SystemActor is recorded for changes made outside of a request, such as the
+// trash purge.
+const SystemActor = "system"
+
+type Change struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+type Entry struct {
+	AuditID    uuid.UUID `json:"auditId"`
+	EntityType string    `json:"entityType"`
+	EntityID   uuid.UUID `json:"entityId"`
+	Action     Action    `json:"action"`
+	Actor      string    `json:"actor"`
+	RequestID  string    `json:"requestId,omitempty"`
+	Changes    []Change  `json:"changes"`
+	// Version is the version of the entity after the change.
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewEntry builds the entry for one change, taking the actor and request ID
+// from ctx. before and after are the audited fields of the entity, nil when
+// it did not exist on that side of the change.
+func NewEntry(ctx context.Context, entityType string, entityID uuid.UUID, action Action, version int64, before, after map[string]any) *Entry {
+	actor := requestctx.Actor(ctx)
+	if actor == "" {
+		actor = SystemActor
+	}
+
+	return &Entry{
+		AuditID:    uuid.New(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      actor,
+		RequestID:  requestctx.RequestID(ctx),
+		Changes:    Diff(before, after),
+		Version:    version,
+		CreatedAt:  time.Now().Local(),
+	}
+}
+
+// Diff lists the fields whose value differs between before and after, in
+// field name order. Values are compared and reported in their JSON form.
+func Diff(before, after map[string]any) []Change {
+	before, after = normalize(before), normalize(after)
+
+	fields := maps.Clone(before)
+	if fields == nil {
+		fields = make(map[string]any)
+	}
+	maps.Copy(fields, after)
+
+	changes := []Change{}
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if !reflect.DeepEqual(before[key], after[key]) {
+			changes = append(changes, Change{Field: key, Before: before[key], After: after[key]})
+		}
+	}
+	return changes
+}
+
+func normalize(fields map[string]any) map[string]any {
+	if fields == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		panic("audit: fields are not JSON encodable: " + err.Error())
+	}
+
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		panic("audit: " + err.Error())
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/google/uuid"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]any{"name": "Civic", "year": uint16(2020), "vin": nil}
+	after := map[string]any{"name": "Civic", "year": uint16(2021), "price": 100.5, "vin": nil}
+
+	want := []Change{
+		{Field: "price", Before: nil, After: 100.5},
+		{Field: "year", Before: 2020.0, After: 2021.0},
+	}
+	if got := Diff(before, after); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff = %+v, want %+v", got, want)
+	}
+
+	if got := Diff(before, before); len(got) != 0 {
+		t.Fatalf("Diff of equal fields = %+v, want none", got)
+	}
+
+	created := Diff(nil, map[string]any{"name": "Civic"})
+	if len(created) != 1 || created[0].Before != nil || created[0].After != "Civic" {
+		t.Fatalf("Diff(nil, after) = %+v", created)
+	}
+}
+
+func TestNewEntryActor(t *testing.T) {
+	id := uuid.New()
+
+	e := NewEntry(context.Background(), EntityCar, id, ActionDelete, 3, nil, nil)
+	if e.Actor != SystemActor || e.RequestID != "" || e.EntityID != id || e.Version != 3 {
+		t.Fatalf("entry outside a request = %+v", e)
+	}
+
+	ctx := requestctx.WithActor(requestctx.WithRequestID(context.Background(), "req-1"), "alice")
+	e = NewEntry(ctx, EntityCar, id, ActionDelete, 3, nil, nil)
+	if e.Actor != "alice" || e.RequestID != "req-1" {
+		t.Fatalf("entry in a request = %+v, want actor alice and request req-1", e)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return start.Add(time.Duration(hours) * time.Hour) }
+
+	entries := []*Entry{
+		{Action: ActionPurge, Version: 3, CreatedAt: at(4)},
+		{Action: ActionUpdate, Version: 2, CreatedAt: at(2), Changes: []Change{{Field: "price", Before: 100.0, After: 90.0}}},
+		{Action: ActionCreate, Version: 1, CreatedAt: at(0), Changes: []Change{
+			{Field: "name", After: "Civic"},
+			{Field: "price", After: 100.0},
+		}},
+	}
+
+	if snap := Replay(entries, at(-1)); snap != nil {
+		t.Fatalf("Replay before create = %+v, want nil", snap)
+	}
+
+	snap := Replay(entries, at(1))
+	if snap == nil || snap.Fields["price"] != 100.0 || snap.Fields["name"] != "Civic" || snap.Version != 1 || !snap.UpdatedAt.Equal(at(0)) {
+		t.Fatalf("Replay after create = %+v", snap)
+	}
+
+	snap = Replay(entries, at(3))
+	if snap == nil || snap.Fields["price"] != 90.0 || snap.Version != 2 || !snap.CreatedAt.Equal(at(0)) || !snap.UpdatedAt.Equal(at(2)) {
+		t.Fatalf("Replay after update = %+v", snap)
+	}
+
+	if snap := Replay(entries, at(5)); snap != nil {
+		t.Fatalf("Replay after purge = %+v, want nil", snap)
+	}
+}
@@ -0,0 +1,83 @@
+package auditrepositories
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/google/uuid"
+)
+
+type auditMemoryRepository struct {
+	db *memdb.DB
+}
+
+func NewAuditMemoryRepository(db *memdb.DB) IAuditRepository {
+	return &auditMemoryRepository{db: db}
+}
+
+// Put is the in-memory equivalent of Insert.
+func Put(tx *memdb.Tx, e *audit.Entry) error {
+	changes, err := json.Marshal(e.Changes)
+	if err != nil {
+		return err
+	}
+
+	tx.Audit.Put(e.AuditID, memdb.AuditRow{
+		AuditID:    e.AuditID,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     string(e.Action),
+		Actor:      e.Actor,
+		RequestID:  e.RequestID,
+		Changes:    changes,
+		Version:    e.Version,
+		CreatedAt:  e.CreatedAt,
+	})
+	return nil
+}
+
+func (r *auditMemoryRepository) ListHistory(ctx context.Context, entityType string, entityID uuid.UUID) ([]*audit.Entry, error) {
+	response := []*audit.Entry{}
+
+	err := r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		var err error
+		tx.Audit.Scan(func(_ uuid.UUID, row memdb.AuditRow) bool {
+			if row.EntityType != entityType || row.EntityID != entityID {
+				return true
+			}
+
+			e := &audit.Entry{
+				AuditID:    row.AuditID,
+				EntityType: row.EntityType,
+				EntityID:   row.EntityID,
+				Action:     audit.Action(row.Action),
+				Actor:      row.Actor,
+				RequestID:  row.RequestID,
+				Version:    row.Version,
+				CreatedAt:  row.CreatedAt,
+			}
+			if err = json.Unmarshal(row.Changes, &e.Changes); err != nil {
+				return false
+			}
+
+			response = append(response, e)
+			return true
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(response, func(i, j int) bool {
+		a, b := response[i], response[j]
+		if a.Version != b.Version {
+			return a.Version > b.Version
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+	return response, nil
+}
@@ -0,0 +1,106 @@
+package auditrepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/database/sqlbuilder"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/google/uuid"
+)
+
+type IAuditRepository interface {
+	// ListHistory returns the entries of one entity, newest first.
+	ListHistory(ctx context.Context, entityType string, entityID uuid.UUID) ([]*audit.Entry, error)
+}
+
+type auditRepository struct {
+	db *database.TxManager
+}
+
+func NewAuditRepository(db *sql.DB) IAuditRepository {
+	return &auditRepository{db: database.NewTxManager(db)}
+}
+
+// Insert writes e in tx, the transaction of the change it describes.
+func Insert(ctx context.Context, tx *sql.Tx, e *audit.Entry) error {
+	return InsertMany(ctx, tx, []*audit.Entry{e})
+}
+
+// InsertMany writes the entries of a batch change with one statement.
+func InsertMany(ctx context.Context, tx *sql.Tx, entries []*audit.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	b := sqlbuilder.New()
+	rows := make([]string, len(entries))
+	for i, e := range entries {
+		changes, err := json.Marshal(e.Changes)
+		if err != nil {
+			return err
+		}
+
+		rows[i] = b.Row(
+			e.AuditID,
+			e.EntityType,
+			e.EntityID,
+			e.Action,
+			e.Actor,
+			sql.NullString{String: e.RequestID, Valid: e.RequestID != ""},
+			changes,
+			e.Version,
+			e.CreatedAt,
+		)
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO audit_log (audit_id, entity_type, entity_id, action, actor, request_id, changes, version, created_at)
+		VALUES `+strings.Join(rows, ", ")+";",
+		b.Args()...,
+	)
+	return err
+}
+
+func (r *auditRepository) ListHistory(ctx context.Context, entityType string, entityID uuid.UUID) ([]*audit.Entry, error) {
+	rows, err := r.db.Querier(ctx).QueryContext(
+		ctx,
+		`SELECT audit_id, entity_type, entity_id, action, actor, request_id, changes, version, created_at
+		FROM audit_log
+		WHERE entity_type = $1 AND entity_id = $2
+		ORDER BY version DESC, created_at DESC;`,
+		entityType,
+		entityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	response := []*audit.Entry{}
+	for rows.Next() {
+		var e audit.Entry
+		var requestID sql.NullString
+		var changes []byte
+
+		err := rows.Scan(&e.AuditID, &e.EntityType, &e.EntityID, &e.Action, &e.Actor, &requestID, &changes, &e.Version, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &e.Changes); err != nil {
+			return nil, err
+		}
+		e.RequestID = requestID.String
+
+		response = append(response, &e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
@@ -0,0 +1,49 @@
+package audithandlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/codepnw/go-car-management/modules/audit"
+	auditservices "github.com/codepnw/go-car-management/modules/audit/services"
+	"github.com/gin-gonic/gin"
+)
+
+type auditHandler struct {
+	service    auditservices.IAuditService
+	entityType string
+	changes    func([]audit.Change) []audit.Change
+}
+
+// NewAuditHandler serves the history of one entity type, such as
+// audit.EntityCar. changes renders the changes of each entry in the form of
+// the API version; nil renders them as they are recorded.
+func NewAuditHandler(service auditservices.IAuditService, entityType string, changes func([]audit.Change) []audit.Change) *auditHandler {
+	return &auditHandler{service: service, entityType: entityType, changes: changes}
+}
+
+func (h *auditHandler) ListHistory(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+
+	entries, err := h.service.ListHistory(ctx, h.entityType, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if h.changes != nil {
+		rendered := make([]*audit.Entry, len(entries))
+		for i, entry := range entries {
+			e := *entry
+			e.Changes = h.changes(entry.Changes)
+			rendered[i] = &e
+		}
+		entries = rendered
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
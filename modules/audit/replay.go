@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Snapshot is the state of an entity rebuilt from its history.
+type Snapshot struct {
+	// Fields are the audited fields in their JSON form.
+	Fields    map[string]any
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Replay rebuilds the state of an entity at the given time by applying its
+// entries in order. It returns nil when nothing is recorded for the entity at
+// that time: it did not exist yet, it had been purged, or it predates its
+// snapshot. Entities written before the audit log only have the snapshot
+// taken when the log was backfilled, which holds their state at that time.
+// It is stamped at the last update of a car, and no later than the creation
+// of the earliest car using an engine.
+func Replay(entries []*Entry, at time.Time) *Snapshot {
+	entries = slices.Clone(entries)
+	slices.SortStableFunc(entries, func(a, b *Entry) int {
+		if c := cmp.Compare(a.Version, b.Version); c != 0 {
+			return c
+		}
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	var snap *Snapshot
+	for _, e := range entries {
+		if e.CreatedAt.After(at) {
+			break
+		}
+
+		switch {
+		case e.Action == ActionPurge:
+			snap = nil
+			continue
+		case snap == nil:
+			snap = &Snapshot{Fields: make(map[string]any), CreatedAt: e.CreatedAt}
+		}
+
+		for _, c := range e.Changes {
+			snap.Fields[c.Field] = c.After
+		}
+		snap.Version = e.Version
+		snap.UpdatedAt = e.CreatedAt
+	}
+	return snap
+}
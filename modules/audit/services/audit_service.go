@@ -0,0 +1,35 @@
+package auditservices
+
+import (
+	"context"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/google/uuid"
+)
+
+type IAuditService interface {
+	ListHistory(ctx context.Context, entityType, id string) ([]*audit.Entry, error)
+}
+
+type auditService struct {
+	repo auditrepositories.IAuditRepository
+}
+
+func NewAuditService(repo auditrepositories.IAuditRepository) IAuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) ListHistory(ctx context.Context, entityType, id string) ([]*audit.Entry, error) {
+	entityID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperrors.InvalidID(entityType, err)
+	}
+
+	entries, err := s.repo.ListHistory(ctx, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,94 @@
+package cars
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/google/uuid"
+)
+
+// MaxBulkItems bounds the number of cars one bulk request may carry.
+const MaxBulkItems = 500
+
+// BulkMode says what happens to a bulk request some items of which fail.
+type BulkMode string
+
+const (
+	// BulkAtomic applies every item or none of them.
+	BulkAtomic BulkMode = "atomic"
+	// BulkPartial applies the items that can be applied and reports the
+	// others.
+	BulkPartial BulkMode = "partial"
+)
+
+// ParseBulkMode reads the mode query parameter, atomic when it is empty.
+func ParseBulkMode(s string) (BulkMode, error) {
+	switch mode := BulkMode(s); mode {
+	case "":
+		return BulkAtomic, nil
+	case BulkAtomic, BulkPartial:
+		return mode, nil
+	}
+	return "", apperrors.Validation(nil, apperrors.FieldError{
+		Field:   "mode",
+		Message: fmt.Sprintf("mode must be one of %s, %s", BulkAtomic, BulkPartial),
+	})
+}
+
+type BulkCreateRequest struct {
+	Items []*CarRequest `json:"items"`
+}
+
+// CarUpdate is one item of a bulk update: the full car, like the body of
+// PUT /cars/:id, with the car ID and the version it was read at.
+type CarUpdate struct {
+	CarID   uuid.UUID `json:"carId"`
+	Version int64     `json:"version"`
+	CarRequest
+}
+
+type BulkUpdateRequest struct {
+	Items []*CarUpdate `json:"items"`
+}
+
+// CarRef is one item of a bulk delete.
+type CarRef struct {
+	CarID   uuid.UUID `json:"carId"`
+	Version int64     `json:"version"`
+}
+
+type BulkDeleteRequest struct {
+	Items []*CarRef `json:"items"`
+}
+
+// BulkResult is the outcome of one item of a bulk request: the written car,
+// or the reason it was not written.
+type BulkResult struct {
+	Car *Car
+	Err error
+}
+
+// ErrNotApplied is the result of the items of an atomic bulk request that
+// were not written because other items failed.
+var ErrNotApplied = apperrors.FailedDependency("not applied because other items of the request failed")
+
+// ItemErrors is the error of a batch write some items of which cannot be
+// applied, keyed by their index in the batch. Nothing of the batch is
+// written.
+type ItemErrors map[int]error
+
+func (e ItemErrors) Error() string {
+	return strconv.Itoa(len(e)) + " items of the batch cannot be applied"
+}
+
+// CheckBulkSize rejects an empty batch or one larger than MaxBulkItems.
+func CheckBulkSize(n int) error {
+	if n == 0 || n > MaxBulkItems {
+		return apperrors.Validation(nil, apperrors.FieldError{
+			Field:   "items",
+			Message: fmt.Sprintf("items must hold between 1 and %d cars", MaxBulkItems),
+		})
+	}
+	return nil
+}
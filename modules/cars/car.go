@@ -1,8 +1,10 @@
 package cars
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/codepnw/go-car-management/apperrors"
 	"github.com/codepnw/go-car-management/modules/engines"
 	"github.com/google/uuid"
 )
@@ -15,15 +17,111 @@ type Car struct {
 	FuelType  string          `json:"fuelType" db:"fuel_type"`
 	Engine    *engines.Engine `json:"engineId" db:"engine_id"`
 	Price     float64         `json:"price" db:"price"`
+	VIN       string          `json:"vin,omitempty" db:"vin"`
 	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time       `json:"updatedAt" db:"updated_at"`
+	Version   int64           `json:"version,omitempty" db:"version"`
+	DeletedAt *time.Time      `json:"deletedAt,omitempty" db:"deleted_at"`
 }
 
 type CarRequest struct {
 	Name     string          `json:"name" validate:"required"`
-	Year     uint16          `json:"year" validate:"required,gte=1886"`
+	Year     uint16          `json:"year" validate:"required,gte=1886,modelyear"`
 	Brand    string          `json:"brand" validate:"required"`
-	FuelType string          `json:"fuelType" validate:"oneof=Petrol Diesel Electric Hybrid"`
+	FuelType string          `json:"fuelType" validate:"oneof=Petrol Diesel Electric Hybrid,fuelengine=Engine"`
 	Engine   *engines.Engine `json:"engine" validate:"required"`
 	Price    float64         `json:"price" validate:"required,gte=1"`
+	VIN      string          `json:"vin,omitempty" validate:"omitempty,vin"`
+}
+
+// NewEngine returns the spec of an engine to create together with the car,
+// or nil when the request references an existing engine by engineId.
+func (r *CarRequest) NewEngine() *engines.EngineRequest {
+	if r.Engine == nil || r.Engine.EngineID != uuid.Nil {
+		return nil
+	}
+
+	return &engines.EngineRequest{
+		Displacement:  r.Engine.Displacement,
+		NoOfCylinders: r.Engine.NoOfCylinders,
+		CarRange:      r.Engine.CarRange,
+	}
+}
+
+// NewEngineRequest validates the spec of a new engine, reporting its fields
+// under "engine." where the client sent them.
+type NewEngineRequest struct {
+	Engine *engines.EngineRequest `json:"engine"`
+}
+
+var ErrEngineNotExists = apperrors.Validation(nil, apperrors.FieldError{
+	Field:   "engine.engineId",
+	Message: "engine does not exist",
+})
+
+var ErrCarNotInTrash = apperrors.NotFound("car not found in trash")
+
+var ErrCarModified = apperrors.PreconditionFailed("car has been modified since it was read, fetch it again and retry")
+
+// AuditFields returns the fields the audit log tracks, nil for a nil car.
+// The engine is tracked by ID; its specs are audited on the engine itself.
+func (c *Car) AuditFields() map[string]any {
+	if c == nil {
+		return nil
+	}
+
+	var engineID *uuid.UUID
+	if c.Engine != nil {
+		engineID = &c.Engine.EngineID
+	}
+	// An empty VIN is stored as NULL.
+	var vin *string
+	if c.VIN != "" {
+		vin = &c.VIN
+	}
+
+	return map[string]any{
+		"name":      c.Name,
+		"year":      c.Year,
+		"brand":     c.Brand,
+		"fuelType":  c.FuelType,
+		"engineId":  engineID,
+		"price":     c.Price,
+		"vin":       vin,
+		"deletedAt": c.DeletedAt,
+	}
+}
+
+// SetAuditFields is the inverse of AuditFields: it sets the fields of c from
+// their JSON form, as rebuilt from the audit log. The engine is only set by
+// ID.
+func (c *Car) SetAuditFields(fields map[string]any) error {
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	var v struct {
+		Name      string     `json:"name"`
+		Year      uint16     `json:"year"`
+		Brand     string     `json:"brand"`
+		FuelType  string     `json:"fuelType"`
+		EngineID  *uuid.UUID `json:"engineId"`
+		Price     float64    `json:"price"`
+		VIN       *string    `json:"vin"`
+		DeletedAt *time.Time `json:"deletedAt"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+
+	c.Name, c.Year, c.Brand, c.FuelType, c.Price, c.DeletedAt = v.Name, v.Year, v.Brand, v.FuelType, v.Price, v.DeletedAt
+	c.Engine, c.VIN = nil, ""
+	if v.EngineID != nil {
+		c.Engine = &engines.Engine{EngineID: *v.EngineID}
+	}
+	if v.VIN != nil {
+		c.VIN = *v.VIN
+	}
+	return nil
 }
@@ -0,0 +1,186 @@
+package carexport
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carimport"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/google/uuid"
+)
+
+func testCars() []*cars.Car {
+	created := time.Date(2026, 10, 17, 7, 1, 6, 0, time.UTC)
+	return []*cars.Car{
+		{
+			CarID:     uuid.New(),
+			Name:      `Civic "Type R", <limited> & co`,
+			Year:      2020,
+			Brand:     "Honda",
+			FuelType:  "Petrol",
+			Engine:    &engines.Engine{EngineID: uuid.New(), Displacement: 1998, NoOfCylinders: 4, CarRange: 600},
+			Price:     25999.99,
+			VIN:       "1HGCM82633A004352",
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+		{
+			CarID:     uuid.New(),
+			Name:      "Model 3",
+			Year:      2023,
+			Brand:     "Tesla",
+			FuelType:  "Electric",
+			Price:     42000,
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+	}
+}
+
+func export(t *testing.T, format Format, list []*cars.Car) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, cars.V1)
+	if err != nil {
+		t.Fatalf("NewWriter(%s): %v", format, err)
+	}
+	for _, car := range list {
+		if err := w.Write(car); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != CSV {
+		t.Fatalf("ParseFormat(\"\") = %q, %v, want %q", f, err, CSV)
+	}
+	if _, err := ParseFormat("pdf"); !errors.Is(err, apperrors.ErrValidation) {
+		t.Fatalf("ParseFormat(pdf) error = %v, want %v", err, apperrors.ErrValidation)
+	}
+}
+
+// The CSV export reads back as an import with the default mapping.
+func TestCSVRoundTrip(t *testing.T) {
+	list := testCars()
+
+	r, err := carimport.NewReader(bytes.NewReader(export(t, CSV, list)), carimport.DefaultMapping(), ',')
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	for i, car := range list {
+		row, err := r.Read()
+		if err != nil || row.Err != nil {
+			t.Fatalf("Read row %d = %+v, %v", i, row, err)
+		}
+		got := row.Car
+		if got.Name != car.Name || got.Year != car.Year || got.Price != car.Price || got.VIN != car.VIN {
+			t.Fatalf("row %d = %+v, want %+v", i, got, car)
+		}
+		if (got.Engine == nil) != (car.Engine == nil) || (car.Engine != nil && got.Engine.EngineID != car.Engine.EngineID) {
+			t.Fatalf("row %d engine = %+v, want %+v", i, got.Engine, car.Engine)
+		}
+	}
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Read at the end error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	list := testCars()
+
+	lines := bufio.NewScanner(bytes.NewReader(export(t, NDJSON, list)))
+	for i, car := range list {
+		if !lines.Scan() {
+			t.Fatalf("line %d missing", i)
+		}
+		var got cars.Car
+		if err := json.Unmarshal(lines.Bytes(), &got); err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if got.CarID != car.CarID || got.Name != car.Name {
+			t.Fatalf("line %d = %+v, want %+v", i, got, car)
+		}
+	}
+	if lines.Scan() {
+		t.Fatalf("unexpected line %q", lines.Text())
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	list := testCars()
+	data := export(t, XLSX, list)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	parts := map[string]*zip.File{}
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if parts[name] == nil {
+			t.Fatalf("part %s missing", name)
+		}
+	}
+
+	f, err := parts["xl/worksheets/sheet1.xml"].Open()
+	if err != nil {
+		t.Fatalf("open worksheet: %v", err)
+	}
+	defer f.Close()
+
+	var sheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R      string `xml:"r,attr"`
+				T      string `xml:"t,attr"`
+				V      string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.NewDecoder(f).Decode(&sheet); err != nil {
+		t.Fatalf("decode worksheet: %v", err)
+	}
+
+	if len(sheet.Rows) != len(list)+1 {
+		t.Fatalf("worksheet has %d rows, want %d", len(sheet.Rows), len(list)+1)
+	}
+	if header := sheet.Rows[0].Cells; len(header) != len(Columns) || header[0].Inline != "carId" || header[12].R != "M1" {
+		t.Fatalf("header row = %+v", header)
+	}
+
+	civic := sheet.Rows[1].Cells
+	if civic[1].Inline != list[0].Name || civic[2].T != "" || civic[2].V != "2020" || civic[5].V != "25999.99" {
+		t.Fatalf("first car row = %+v", civic)
+	}
+	// The car without an engine has no engine cells.
+	if tesla := sheet.Rows[2].Cells; len(tesla) != len(Columns)-5 {
+		t.Fatalf("second car row has %d cells, want %d", len(tesla), len(Columns)-5)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Fatalf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Package carexport writes cars, with the specs of their engine, as CSV,
+// NDJSON or XLSX. Writers take one car at a time and hold no more than a
+// row in memory, so an export of any size streams.
+package carexport
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/cars"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	XLSX   Format = "xlsx"
+)
+
+// ParseFormat reads the format query parameter, CSV when it is empty.
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case "":
+		return CSV, nil
+	case CSV, NDJSON, XLSX:
+		return format, nil
+	}
+	return "", apperrors.Validation(nil, apperrors.FieldError{
+		Field:   "format",
+		Message: fmt.Sprintf("format must be one of %s, %s, %s", CSV, NDJSON, XLSX),
+	})
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case NDJSON:
+		return "application/x-ndjson"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filename names an export made at t, such as cars-20261017-070106.csv.
+func (f Format) Filename(t time.Time) string {
+	return "cars-" + t.UTC().Format("20060102-150405") + "." + string(f)
+}
+
+// Writer writes cars one at a time. Close completes the file; it must be
+// called for the output to be valid, and does not close the underlying
+// writer.
+type Writer interface {
+	Write(car *cars.Car) error
+	Close() error
+}
+
+// NewWriter returns a Writer of f to w. Tabular formats start with their
+// header row and have the same columns in every version of the API; NDJSON
+// lines are cars in the form of view.
+func NewWriter(f Format, w io.Writer, view cars.Representation) (Writer, error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w)
+	case NDJSON:
+		return newNDJSONWriter(w, view), nil
+	case XLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// Columns head the tabular formats. They are the JSON names of the fields,
+// so carimport reads an export back with its default mapping.
+var Columns = []string{
+	"carId", "name", "year", "brand", "fuelType", "price", "vin",
+	"engineId", "displacement", "noOfCylinders", "carRange",
+	"createdAt", "updatedAt",
+}
+
+// numericColumns are the Columns whose values are numbers.
+var numericColumns = map[int]bool{2: true, 5: true, 8: true, 9: true, 10: true}
+
+// record returns the values of car under Columns. The engine columns are
+// empty for a car without an engine.
+func record(car *cars.Car) []string {
+	values := []string{
+		car.CarID.String(),
+		car.Name,
+		strconv.FormatUint(uint64(car.Year), 10),
+		car.Brand,
+		car.FuelType,
+		strconv.FormatFloat(car.Price, 'f', -1, 64),
+		car.VIN,
+		"", "", "", "",
+		car.CreatedAt.UTC().Format(time.RFC3339),
+		car.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if e := car.Engine; e != nil {
+		values[7] = e.EngineID.String()
+		values[8] = strconv.FormatUint(uint64(e.Displacement), 10)
+		values[9] = strconv.FormatUint(uint64(e.NoOfCylinders), 10)
+		values[10] = strconv.FormatUint(uint64(e.CarRange), 10)
+	}
+	return values
+}
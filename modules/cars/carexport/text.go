@@ -0,0 +1,52 @@
+package carexport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/codepnw/go-car-management/modules/cars"
+)
+
+type csvWriter struct {
+	csv *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{csv: csv.NewWriter(w)}
+	if err := cw.csv.Write(Columns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (w *csvWriter) Write(car *cars.Car) error {
+	return w.csv.Write(record(car))
+}
+
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// ndjsonWriter writes each car as its API representation on a line of its
+// own.
+type ndjsonWriter struct {
+	buf  *bufio.Writer
+	enc  *json.Encoder
+	view cars.Representation
+}
+
+func newNDJSONWriter(w io.Writer, view cars.Representation) *ndjsonWriter {
+	buf := bufio.NewWriter(w)
+	return &ndjsonWriter{buf: buf, enc: json.NewEncoder(buf), view: view}
+}
+
+func (w *ndjsonWriter) Write(car *cars.Car) error {
+	return w.enc.Encode(w.view.Car(car))
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.buf.Flush()
+}
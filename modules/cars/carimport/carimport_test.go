@@ -0,0 +1,224 @@
+package carimport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	"github.com/codepnw/go-car-management/modules/engines"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+)
+
+func TestParseMapping(t *testing.T) {
+	m, err := ParseMapping(" name = Model ,price=Price (EUR)")
+	if err != nil {
+		t.Fatalf("ParseMapping: %v", err)
+	}
+	if m["name"] != "Model" || m["price"] != "Price (EUR)" || m["brand"] != "brand" {
+		t.Fatalf("ParseMapping = %v", m)
+	}
+
+	for _, s := range []string{"name", "name=", "colour=Colour"} {
+		if _, err := ParseMapping(s); !errors.Is(err, apperrors.ErrValidation) {
+			t.Fatalf("ParseMapping(%q) error = %v, want %v", s, err, apperrors.ErrValidation)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	file := "\ufeffModel;Year;Make;Fuel;Price;VIN;Range;Displacement;Cylinders\n" +
+		"Civic;2020;Honda;Petrol;25999.99;;600;1998;4\n" +
+		";;;;;;;;\n" +
+		"Model 3;twenty;Tesla;Electric;lots;;500;;\n"
+	m, err := ParseMapping("name=Model,year=Year,brand=Make,fuelType=Fuel,price=Price,carRange=Range,noOfCylinders=Cylinders")
+	if err != nil {
+		t.Fatalf("ParseMapping: %v", err)
+	}
+
+	r, err := NewReader(strings.NewReader(file), m, ';')
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+
+	row, err := r.Read()
+	if err != nil || row.Err != nil {
+		t.Fatalf("Read = %+v, %v", row, err)
+	}
+	want := &engines.Engine{Displacement: 1998, NoOfCylinders: 4, CarRange: 600}
+	if row.Line != 2 || row.Car.Name != "Civic" || row.Car.Price != 25999.99 || *row.Car.Engine != *want {
+		t.Fatalf("Read line %d = %+v, engine %+v", row.Line, row.Car, row.Car.Engine)
+	}
+
+	// The blank row is skipped.
+	row, err = r.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var appErr *apperrors.Error
+	if row.Line != 4 || !errors.As(row.Err, &appErr) || len(appErr.Fields) != 2 || appErr.Fields[0].Field != "year" || appErr.Fields[1].Field != "price" {
+		t.Fatalf("Read line %d error = %v, want year and price errors on line 4", row.Line, row.Err)
+	}
+
+	if _, err := r.Read(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Read at the end error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestReaderColumns(t *testing.T) {
+	tests := map[string]string{
+		"empty":       "",
+		"no engine":   "name,year,brand,fuelType,price\n",
+		"no required": "name,year,brand,engineId\n",
+	}
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(file), DefaultMapping(), ',')
+			if !errors.Is(err, apperrors.ErrValidation) {
+				t.Fatalf("NewReader error = %v, want %v", err, apperrors.ErrValidation)
+			}
+		})
+	}
+}
+
+const importFile = `name,year,brand,fuelType,price,vin,engineId,displacement,noOfCylinders,carRange
+Civic,2020,Honda,Petrol,25999.99,1HGCM82633A004352,,1998,4,600
+Accord,2021,Honda,Petrol,29999,,,1998,4,600
+Model 3,2023,Tesla,Electric,42000,,,0,0,500
+Broken,2023,Tesla,Petrol,42000,,,0,0,500
+Copy,2020,Honda,Petrol,25999.99,1HGCM82633A004352,,1998,4,600
+Unknown,2020,Honda,Petrol,25999.99,,6f1c9b5e-5d4e-4bb6-9d7a-3f9a3c2b1a00,,,
+`
+
+func newTestImporter(t *testing.T) (*Importer, engrepositories.IEngineRepository, carrepositories.ICarRepository) {
+	t.Helper()
+
+	db := memdb.New()
+	engineRepo := engrepositories.NewEngineMemoryRepository(db)
+	carRepo := carrepositories.NewCarMemoryRepository(db)
+	service := carservices.NewCarService(carRepo, engineRepo, auditrepositories.NewAuditMemoryRepository(db), db)
+	return NewImporter(service, engineRepo), engineRepo, carRepo
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	importer, engineRepo, carRepo := newTestImporter(t)
+
+	existing, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{CarRange: 500})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+
+	wantLines := []int{5, 6, 7}
+
+	dry, err := importer.Import(ctx, strings.NewReader(importFile), &Options{Mapping: DefaultMapping(), Comma: ',', DryRun: true})
+	if err != nil {
+		t.Fatalf("Import dry run: %v", err)
+	}
+	assertReport(t, dry, 6, 3, 1, wantLines)
+	if got := listCars(t, carRepo); len(got) != 0 {
+		t.Fatalf("ListCars after dry run = %d cars, want none", len(got))
+	}
+
+	report, err := importer.Import(ctx, strings.NewReader(importFile), &Options{Mapping: DefaultMapping(), Comma: ',', DryRun: false})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	assertReport(t, report, 6, 3, 1, wantLines)
+
+	imported := listCars(t, carRepo)
+	if len(imported) != 3 {
+		t.Fatalf("ListCars = %d cars, want 3", len(imported))
+	}
+	// The two Hondas share the engine created for the first one, and the
+	// Tesla uses the stored electric engine.
+	engineIDs := map[string]string{}
+	for _, car := range imported {
+		engineIDs[car.Name] = car.Engine.EngineID.String()
+	}
+	if engineIDs["Civic"] != engineIDs["Accord"] || engineIDs["Model 3"] != existing.EngineID.String() {
+		t.Fatalf("engines of the imported cars = %v, want the Hondas to share one and Model 3 on %s", engineIDs, existing.EngineID)
+	}
+}
+
+func TestImportResume(t *testing.T) {
+	ctx := context.Background()
+	importer, engineRepo, carRepo := newTestImporter(t)
+
+	if _, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{CarRange: 500}); err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+
+	// The Accord waits for the engine the Civic creates, so the Civic is
+	// written on its own; the import stops right after.
+	stopCtx, stop := context.WithCancel(ctx)
+	var checkpoint *Checkpoint
+	_, err := importer.Import(stopCtx, strings.NewReader(importFile), &Options{
+		Mapping: DefaultMapping(),
+		Comma:   ',',
+		Progress: func(ctx context.Context, c *Checkpoint) error {
+			if checkpoint == nil {
+				checkpoint = c
+				stop()
+			}
+			return nil
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Import: %v, want it stopped", err)
+	}
+	if checkpoint == nil || checkpoint.Rows != 1 || checkpoint.Report.Imported != 1 || checkpoint.Report.EnginesCreated != 1 {
+		t.Fatalf("checkpoint = %+v, want the Civic done", checkpoint)
+	}
+
+	report, err := importer.Import(ctx, strings.NewReader(importFile), &Options{Mapping: DefaultMapping(), Comma: ',', Resume: checkpoint})
+	if err != nil {
+		t.Fatalf("Import resumed: %v", err)
+	}
+	assertReport(t, report, 6, 3, 1, []int{5, 6, 7})
+
+	if got := listCars(t, carRepo); len(got) != 3 {
+		t.Fatalf("ListCars = %d cars, want 3", len(got))
+	}
+}
+
+func listCars(t *testing.T, carRepo carrepositories.ICarRepository) []*cars.Car {
+	t.Helper()
+
+	filter := &cars.CarFilter{}
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	got, _, err := carRepo.ListCars(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("ListCars: %v", err)
+	}
+	return got
+}
+
+func assertReport(t *testing.T, report *Report, rows, imported, enginesCreated int, failedLines []int) {
+	t.Helper()
+
+	if report.Rows != rows || report.Imported != imported || report.EnginesCreated != enginesCreated || report.Failed != len(failedLines) {
+		t.Fatalf("report = %+v, want %d rows, %d imported, %d engines created, %d failed", report, rows, imported, enginesCreated, len(failedLines))
+	}
+	var lines []int
+	for _, rowErr := range report.Errors {
+		lines = append(lines, rowErr.Line)
+	}
+	if len(lines) != len(failedLines) {
+		t.Fatalf("report error lines = %v, want %v", lines, failedLines)
+	}
+	for i := range lines {
+		if lines[i] != failedLines[i] {
+			t.Fatalf("report error lines = %v, want %v", lines, failedLines)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package carimport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/cars"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	"github.com/codepnw/go-car-management/modules/engines"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	// batchSize is how many rows are written together.
+	batchSize = 100
+	// maxReportedErrors caps the row errors a report lists.
+	maxReportedErrors = 1000
+)
+
+type Options struct {
+	Mapping Mapping
+	// Comma is the column delimiter.
+	Comma rune
+	// DryRun validates every row and resolves engines without writing
+	// anything.
+	DryRun bool
+	// Resume continues an import from a checkpoint reported by an earlier
+	// run over the same input.
+	Resume *Checkpoint
+	// Progress, when set, is called with a checkpoint right after each batch
+	// is written, and an error it returns stops the import. It should save
+	// the checkpoint before it returns.
+	Progress func(ctx context.Context, checkpoint *Checkpoint) error
+}
+
+// Checkpoint is how far an import got: its first Rows data rows are done and
+// Report sums them up. Each batch is committed on its own and Progress is
+// called right after, so resuming from the last checkpoint saved imports
+// again at most the batch committed just before the import stopped, and only
+// when it stopped before Progress returned.
+type Checkpoint struct {
+	Rows   int     `json:"rows"`
+	Report *Report `json:"report"`
+}
+
+// Report sums up an import. In a dry run Imported counts the rows that would
+// be imported and EnginesCreated the engines that would be created.
+type Report struct {
+	DryRun         bool        `json:"dryRun"`
+	Rows           int         `json:"rows"`
+	Imported       int         `json:"imported"`
+	Failed         int         `json:"failed"`
+	EnginesCreated int         `json:"enginesCreated"`
+	Errors         []*RowError `json:"errors"`
+	// ErrorsTruncated is set when more rows failed than Errors lists.
+	ErrorsTruncated bool `json:"errorsTruncated,omitempty"`
+}
+
+// RowError is why one row was not imported, as the API would have reported
+// it for the same car.
+type RowError struct {
+	Line    int                    `json:"line"`
+	Code    apperrors.Code         `json:"code"`
+	Message string                 `json:"message"`
+	Fields  []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+type Importer struct {
+	service    carservices.ICarService
+	engineRepo engrepositories.IEngineRepository
+}
+
+func NewImporter(service carservices.ICarService, engineRepo engrepositories.IEngineRepository) *Importer {
+	return &Importer{service: service, engineRepo: engineRepo}
+}
+
+// Import reads the cars of src and creates the valid ones, validated like
+// CreateCar. Every row stands on its own: a failed row is reported and the
+// import goes on. A row giving engine specs instead of an engineId uses a
+// stored engine with those specs, or creates one with the car.
+func (im *Importer) Import(ctx context.Context, src io.Reader, opts *Options) (*Report, error) {
+	reader, err := NewReader(src, opts.Mapping, opts.Comma)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &importRun{
+		Importer: im,
+		dryRun:   opts.DryRun,
+		progress: opts.Progress,
+		report:   &Report{DryRun: opts.DryRun, Errors: []*RowError{}},
+		engines:  make(map[engines.EngineRequest]uuid.UUID),
+		claimed:  make(map[engines.EngineRequest]bool),
+		vins:     make(map[string]int),
+	}
+
+	if opts.Resume != nil {
+		if opts.Resume.Report != nil {
+			*run.report = *opts.Resume.Report
+			run.report.Errors = slices.Clone(run.report.Errors)
+		}
+		run.report.Rows = 0
+		for range opts.Resume.Rows {
+			_, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			run.report.Rows++
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := run.add(ctx, row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := run.flush(ctx, run.report.Rows); err != nil {
+		return nil, err
+	}
+	return run.report, nil
+}
+
+type importRun struct {
+	*Importer
+	dryRun   bool
+	progress func(context.Context, *Checkpoint) error
+	report   *Report
+
+	// batch holds the rows read but not written yet.
+	batch []*Row
+	// engines maps the specs resolved so far to their engine. In a dry run
+	// uuid.Nil marks an engine that would be created.
+	engines map[engines.EngineRequest]uuid.UUID
+	// claimed holds the specs of the engines the batch creates. A second
+	// row with the same specs waits for the next batch to use that engine
+	// rather than create another one.
+	claimed map[engines.EngineRequest]bool
+	// vins maps each VIN of a dry run to its first line, to find the
+	// repeats the import would reject.
+	vins map[string]int
+}
+
+func (run *importRun) add(ctx context.Context, row *Row) error {
+	run.report.Rows++
+	if row.Err != nil {
+		return run.fail(row.Line, row.Err)
+	}
+
+	if err := run.resolveEngine(ctx, row.Car); err != nil {
+		return run.fail(row.Line, err)
+	}
+
+	spec := row.Car.NewEngine()
+	if spec != nil && run.claimed[*spec] {
+		// The row in hand is not done until the next batch.
+		if err := run.flush(ctx, run.report.Rows-1); err != nil {
+			return err
+		}
+		if err := run.resolveEngine(ctx, row.Car); err != nil {
+			return run.fail(row.Line, err)
+		}
+		spec = row.Car.NewEngine()
+	}
+	if spec != nil {
+		run.claimed[*spec] = true
+	}
+
+	run.batch = append(run.batch, row)
+	if len(run.batch) == batchSize {
+		return run.flush(ctx, run.report.Rows)
+	}
+	return nil
+}
+
+// resolveEngine points a car given engine specs at a live engine with
+// those specs, when there is one.
+func (run *importRun) resolveEngine(ctx context.Context, req *cars.CarRequest) error {
+	spec := req.NewEngine()
+	if spec == nil {
+		return nil
+	}
+
+	engineID, ok := run.engines[*spec]
+	if !ok {
+		engine, err := run.engineRepo.FindEngineBySpec(ctx, spec)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		engineID = engine.EngineID
+		run.engines[*spec] = engineID
+	}
+
+	if engineID != uuid.Nil {
+		req.Engine = &engines.Engine{EngineID: engineID}
+	}
+	return nil
+}
+
+// flush writes the batch, after which the first done rows are done.
+func (run *importRun) flush(ctx context.Context, done int) error {
+	if err := run.write(ctx); err != nil {
+		return err
+	}
+
+	if run.progress != nil {
+		report := *run.report
+		report.Rows = done
+		report.Errors = slices.Clone(report.Errors)
+		return run.progress(ctx, &Checkpoint{Rows: done, Report: &report})
+	}
+	return nil
+}
+
+func (run *importRun) write(ctx context.Context) error {
+	batch := run.batch
+	run.batch = nil
+	clear(run.claimed)
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if run.dryRun {
+		return run.check(ctx, batch)
+	}
+
+	reqs := make([]*cars.CarRequest, len(batch))
+	for i, row := range batch {
+		reqs[i] = row.Car
+	}
+
+	results, err := run.service.CreateCars(ctx, reqs, cars.BulkPartial)
+	if err != nil {
+		return err
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			if err := run.fail(batch[i].Line, result.Err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		run.report.Imported++
+		if spec := reqs[i].NewEngine(); spec != nil {
+			run.engines[*spec] = result.Car.Engine.EngineID
+			run.report.EnginesCreated++
+		}
+	}
+	return nil
+}
+
+// check is write for a dry run.
+func (run *importRun) check(ctx context.Context, batch []*Row) error {
+	for _, row := range batch {
+		err := run.service.ValidateCar(ctx, row.Car)
+		if err == nil && row.Car.VIN != "" {
+			if line, ok := run.vins[row.Car.VIN]; ok {
+				err = apperrors.Conflict("the vin of this car is already used on line %d", line)
+			}
+		}
+		if err != nil {
+			if err := run.fail(row.Line, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		run.report.Imported++
+		if row.Car.VIN != "" {
+			run.vins[row.Car.VIN] = row.Line
+		}
+		// Later rows with the same specs resolve to uuid.Nil and keep their
+		// specs, but would use the engine created for this one.
+		if spec := row.Car.NewEngine(); spec != nil {
+			if _, ok := run.engines[*spec]; !ok {
+				run.engines[*spec] = uuid.Nil
+				run.report.EnginesCreated++
+			}
+		}
+	}
+	return nil
+}
+
+// fail reports the row at line. Errors other than an *apperrors.Error are
+// returned to stop the import.
+func (run *importRun) fail(line int, err error) error {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return err
+	}
+
+	run.report.Failed++
+	if len(run.report.Errors) == maxReportedErrors {
+		run.report.ErrorsTruncated = true
+		return nil
+	}
+
+	run.report.Errors = append(run.report.Errors, &RowError{
+		Line:    line,
+		Code:    appErr.Code,
+		Message: appErr.Message,
+		Fields:  appErr.Fields,
+	})
+	return nil
+}
@@ -0,0 +1,116 @@
+// Package carimport imports car inventory from CSV files. Files are read one
+// row at a time and written in small batches, so their size is not bounded
+// by memory.
+package carimport
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codepnw/go-car-management/apperrors"
+)
+
+// Fields are the car fields a column can hold, by their JSON name. A row
+// names its engine by engineId or gives its specs, which are resolved to a
+// stored engine or create one.
+var Fields = []string{"name", "year", "brand", "fuelType", "price", "vin", "engineId", "displacement", "noOfCylinders", "carRange"}
+
+// requiredFields must have a column, whatever the rows hold.
+var requiredFields = []string{"name", "year", "brand", "fuelType", "price"}
+
+// Mapping maps car fields to the header of the column holding them.
+type Mapping map[string]string
+
+// DefaultMapping expects each field under its own name.
+func DefaultMapping() Mapping {
+	m := make(Mapping, len(Fields))
+	for _, field := range Fields {
+		m[field] = field
+	}
+	return m
+}
+
+// ParseMapping reads overrides of the default mapping written as
+// "field=Header,field=Header", such as "name=Model,price=Price (EUR)".
+func ParseMapping(s string) (Mapping, error) {
+	m := DefaultMapping()
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, header, ok := strings.Cut(pair, "=")
+		field, header = strings.TrimSpace(field), strings.TrimSpace(header)
+		switch {
+		case !ok || header == "":
+			return nil, mappingError("mapping entries must look like field=Header, got %q", pair)
+		case !slices.Contains(Fields, field):
+			return nil, mappingError("unknown field %q, fields are %s", field, strings.Join(Fields, ", "))
+		}
+		m[field] = header
+	}
+	return m, nil
+}
+
+// ParseDelimiter reads the column delimiter: a single character, or "tab".
+// It is a comma when s is empty.
+func ParseDelimiter(s string) (rune, error) {
+	switch s {
+	case "":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		return 0, apperrors.Validation(nil, apperrors.FieldError{Field: "delimiter", Message: "delimiter must be a single character other than a quote or a line break"})
+	}
+	return r, nil
+}
+
+// columns resolves the mapping against the header row, matching headers
+// case-insensitively, and returns the column index of each mapped field.
+func (m Mapping) columns(header []string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for _, field := range slices.Sorted(maps.Keys(m)) {
+		if i, ok := index[strings.ToLower(m[field])]; ok {
+			columns[field] = i
+		}
+	}
+
+	var fields []apperrors.FieldError
+	for _, field := range requiredFields {
+		if _, ok := columns[field]; !ok {
+			fields = append(fields, apperrors.FieldError{Field: field, Message: fmt.Sprintf("the file has no %q column", m[field])})
+		}
+	}
+	_, hasID := columns["engineId"]
+	_, hasRange := columns["carRange"]
+	if !hasID && !hasRange {
+		fields = append(fields, apperrors.FieldError{
+			Field:   "engine",
+			Message: fmt.Sprintf("the file needs an engine column: %q, or %q with the other specs", m["engineId"], m["carRange"]),
+		})
+	}
+
+	if len(fields) > 0 {
+		return nil, apperrors.Validation(nil, fields...)
+	}
+	return columns, nil
+}
+
+func mappingError(format string, args ...any) error {
+	return apperrors.Validation(nil, apperrors.FieldError{Field: "mapping", Message: fmt.Sprintf(format, args...)})
+}
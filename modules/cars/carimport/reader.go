@@ -0,0 +1,149 @@
+package carimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/google/uuid"
+)
+
+// Row is one data row of a file: the car it describes, or why it could not
+// be read.
+type Row struct {
+	// Line is where the row starts in the file; the header is line 1.
+	Line int
+	Car  *cars.CarRequest
+	Err  error
+}
+
+// Reader reads the rows of a CSV file one at a time.
+type Reader struct {
+	csv     *csv.Reader
+	columns map[string]int
+}
+
+// NewReader reads the header row of r and resolves m against it.
+func NewReader(r io.Reader, m Mapping, comma rune) (*Reader, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, apperrors.Validation(nil, apperrors.FieldError{Field: "file", Message: "the file is empty"})
+		}
+		return nil, readError(err)
+	}
+	// Spreadsheets often save UTF-8 with a byte order mark.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	columns, err := m.columns(header)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{csv: cr, columns: columns}, nil
+}
+
+// Read returns the next row, or io.EOF after the last one. A row that is
+// not valid CSV or holds malformed values is returned with Err set; other
+// errors come from reading r.
+func (r *Reader) Read() (*Row, error) {
+	for {
+		record, err := r.csv.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &Row{Line: parseErr.StartLine, Err: readError(parseErr)}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := r.csv.FieldPos(0)
+		if blank(record) {
+			continue
+		}
+
+		car, err := r.parse(record)
+		return &Row{Line: line, Car: car, Err: err}, nil
+	}
+}
+
+func (r *Reader) parse(record []string) (*cars.CarRequest, error) {
+	var fields []apperrors.FieldError
+
+	get := func(field string) string {
+		i, ok := r.columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	number := func(field, errField string) uint16 {
+		raw := get(field)
+		if raw == "" {
+			return 0
+		}
+		v, err := strconv.ParseUint(raw, 10, 16)
+		if err != nil {
+			fields = append(fields, apperrors.FieldError{Field: errField, Message: errField + " must be a whole number between 0 and 65535"})
+		}
+		return uint16(v)
+	}
+
+	req := &cars.CarRequest{
+		Name:     get("name"),
+		Year:     number("year", "year"),
+		Brand:    get("brand"),
+		FuelType: get("fuelType"),
+		VIN:      get("vin"),
+	}
+
+	if raw := get("price"); raw != "" {
+		price, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			fields = append(fields, apperrors.FieldError{Field: "price", Message: "price must be a number"})
+		}
+		req.Price = price
+	}
+
+	if raw := get("engineId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			fields = append(fields, apperrors.FieldError{Field: "engine.engineId", Message: "engineId must be a UUID"})
+		}
+		req.Engine = &engines.Engine{EngineID: id}
+	} else if get("displacement") != "" || get("noOfCylinders") != "" || get("carRange") != "" {
+		req.Engine = &engines.Engine{
+			Displacement:  number("displacement", "engine.displacement"),
+			NoOfCylinders: number("noOfCylinders", "engine.noOfCylinders"),
+			CarRange:      number("carRange", "engine.carRange"),
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, apperrors.Validation(nil, fields...)
+	}
+	return req, nil
+}
+
+func blank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func readError(err error) error {
+	return apperrors.Validation(err, apperrors.FieldError{Field: "file", Message: fmt.Sprintf("the file is not valid CSV: %v", err)})
+}
@@ -0,0 +1,126 @@
+// Package carjobs runs car exports and imports as background jobs.
+package carjobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carexport"
+	"github.com/codepnw/go-car-management/modules/cars/carimport"
+	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	"github.com/codepnw/go-car-management/modules/jobs"
+)
+
+// ExportParams are the params of a jobs.KindExport job.
+type ExportParams struct {
+	Format carexport.Format `json:"format"`
+	Filter cars.CarFilter   `json:"filter"`
+	// Version is the API version the export was submitted to, which sets
+	// the form of NDJSON lines. Jobs submitted before it was recorded have
+	// none.
+	Version string `json:"version,omitempty"`
+}
+
+// ExportResult is the result of an export job.
+type ExportResult struct {
+	Rows int64 `json:"rows"`
+}
+
+type exportRunner struct {
+	service carservices.ICarService
+	views   func(version string) cars.Representation
+}
+
+// NewExportRunner runs export jobs, writing the file of the export as the
+// artifact of the job. An interrupted export starts over. views returns the
+// form of cars in the version of a job.
+func NewExportRunner(service carservices.ICarService, views func(version string) cars.Representation) jobs.Runner {
+	return &exportRunner{service: service, views: views}
+}
+
+func (r *exportRunner) Run(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+	var params ExportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return nil, nil, fmt.Errorf("decode export params: %w", err)
+	}
+
+	file := files.Create(ctx, jobs.ArtifactFile)
+	w, err := carexport.NewWriter(params.Format, file, r.views(params.Version))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &ExportResult{}
+	err = r.service.ExportCars(ctx, &params.Filter, func(car *cars.Car) error {
+		if err := w.Write(car); err != nil {
+			return err
+		}
+		result.Rows++
+		progress.Report(result.Rows, result)
+		return nil
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, &jobs.Artifact{
+		Name:        params.Format.Filename(job.CreatedAt),
+		ContentType: params.Format.ContentType(),
+		Size:        file.Size(),
+	}, nil
+}
+
+// ImportParams are the params of a jobs.KindImport job, whose input file is
+// the CSV to import.
+type ImportParams struct {
+	Mapping carimport.Mapping `json:"mapping"`
+	Comma   rune              `json:"comma"`
+	DryRun  bool              `json:"dryRun"`
+}
+
+type importRunner struct {
+	importer *carimport.Importer
+}
+
+// NewImportRunner runs import jobs. While the job runs its result is the
+// last carimport.Checkpoint, from which an interrupted import resumes; once
+// it succeeded it is the carimport.Report.
+func NewImportRunner(importer *carimport.Importer) jobs.Runner {
+	return &importRunner{importer: importer}
+}
+
+func (r *importRunner) Run(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+	var params ImportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return nil, nil, fmt.Errorf("decode import params: %w", err)
+	}
+
+	opts := &carimport.Options{
+		Mapping: params.Mapping,
+		Comma:   params.Comma,
+		DryRun:  params.DryRun,
+		Progress: func(ctx context.Context, checkpoint *carimport.Checkpoint) error {
+			return progress.Save(ctx, int64(checkpoint.Rows), checkpoint)
+		},
+	}
+	if job.Processed > 0 && len(job.Result) > 0 {
+		opts.Resume = &carimport.Checkpoint{}
+		if err := json.Unmarshal(job.Result, opts.Resume); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	report, err := r.importer.Import(ctx, files.Open(ctx, jobs.InputFile), opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return report, nil, nil
+}
@@ -0,0 +1,598 @@
+package carrepositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/google/uuid"
+)
+
+type carMemoryRepository struct {
+	db *memdb.DB
+}
+
+func NewCarMemoryRepository(db *memdb.DB) ICarRepository {
+	return &carMemoryRepository{db: db}
+}
+
+func (r *carMemoryRepository) GetCarById(ctx context.Context, id string) (*cars.Car, error) {
+	var response cars.Car
+
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &response, apperrors.InvalidID("car", err)
+	}
+
+	err = r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Cars.Get(carID)
+		if !ok || row.DeletedAt != nil {
+			return apperrors.NotFound("car not found")
+		}
+
+		response = *carFromRow(tx, row, true)
+		return nil
+	})
+	if err != nil {
+		return &cars.Car{}, err
+	}
+
+	return &response, nil
+}
+
+func (r *carMemoryRepository) CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error) {
+	var createCar cars.Car
+
+	if req.Engine == nil {
+		return &createCar, cars.ErrEngineNotExists
+	}
+
+	createdAt := time.Now().Local()
+
+	row := memdb.CarRow{
+		CarID:     uuid.New(),
+		Name:      req.Name,
+		Year:      req.Year,
+		Brand:     req.Brand,
+		FuelType:  req.FuelType,
+		EngineID:  uuid.NullUUID{UUID: req.Engine.EngineID, Valid: true},
+		Price:     req.Price,
+		VIN:       req.VIN,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Version:   1,
+	}
+
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		if !engineLive(tx, row.EngineID.UUID) {
+			return cars.ErrEngineNotExists
+		}
+		if vinTaken(tx, row) {
+			return errVINExists
+		}
+
+		tx.Cars.Put(row.CarID, row)
+		createCar = *carFromRow(tx, row, false)
+		createCar.Engine = req.Engine
+		return putCarAudit(ctx, tx, audit.ActionCreate, nil, &createCar)
+	})
+
+	return &createCar, err
+}
+
+func (r *carMemoryRepository) UpdateCar(ctx context.Context, id string, req *cars.CarRequest, version int64) (*cars.Car, error) {
+	var updatedCar cars.Car
+
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &updatedCar, apperrors.InvalidID("car", err)
+	}
+
+	if req.Engine == nil {
+		return &updatedCar, cars.ErrEngineNotExists
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Cars.Get(carID)
+		if !ok || row.DeletedAt != nil {
+			return apperrors.NotFound("car not found")
+		}
+		if version != etag.Any && row.Version != version {
+			return cars.ErrCarModified
+		}
+		before := carFromRow(tx, row, false)
+
+		if !engineLive(tx, req.Engine.EngineID) {
+			return cars.ErrEngineNotExists
+		}
+
+		row.Name = req.Name
+		row.Year = req.Year
+		row.Brand = req.Brand
+		row.FuelType = req.FuelType
+		row.EngineID = uuid.NullUUID{UUID: req.Engine.EngineID, Valid: true}
+		row.Price = req.Price
+		row.VIN = req.VIN
+		row.UpdatedAt = time.Now().Local()
+		row.Version++
+
+		if vinTaken(tx, row) {
+			return errVINExists
+		}
+
+		tx.Cars.Put(carID, row)
+		updatedCar = *carFromRow(tx, row, false)
+		return putCarAudit(ctx, tx, audit.ActionUpdate, before, &updatedCar)
+	})
+
+	return &updatedCar, err
+}
+
+func (r *carMemoryRepository) PatchCar(ctx context.Context, id string, p *cars.CarPatch, version int64) (*cars.Car, error) {
+	var patchedCar cars.Car
+
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &patchedCar, apperrors.InvalidID("car", err)
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Cars.Get(carID)
+		if !ok || row.DeletedAt != nil {
+			return apperrors.NotFound("car not found")
+		}
+		if version != etag.Any && row.Version != version {
+			return cars.ErrCarModified
+		}
+		before := carFromRow(tx, row, false)
+
+		if p.Name != nil {
+			row.Name = *p.Name
+		}
+		if p.Year != nil {
+			row.Year = *p.Year
+		}
+		if p.Brand != nil {
+			row.Brand = *p.Brand
+		}
+		if p.FuelType != nil {
+			row.FuelType = *p.FuelType
+		}
+		if p.EngineID != nil {
+			if !engineLive(tx, *p.EngineID) {
+				return cars.ErrEngineNotExists
+			}
+			row.EngineID = uuid.NullUUID{UUID: *p.EngineID, Valid: true}
+		}
+		if p.Price != nil {
+			row.Price = *p.Price
+		}
+		if p.VIN != nil {
+			row.VIN = *p.VIN
+		}
+		row.UpdatedAt = time.Now().Local()
+		row.Version++
+
+		if vinTaken(tx, row) {
+			return errVINExists
+		}
+
+		tx.Cars.Put(carID, row)
+		patchedCar = *carFromRow(tx, row, false)
+		return putCarAudit(ctx, tx, audit.ActionUpdate, before, &patchedCar)
+	})
+
+	return &patchedCar, err
+}
+
+func (r *carMemoryRepository) DeleteCar(ctx context.Context, id string, version int64) (*cars.Car, error) {
+	var deletedCar cars.Car
+
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &cars.Car{}, apperrors.InvalidID("car", err)
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Cars.Get(carID)
+		if !ok || row.DeletedAt != nil {
+			return apperrors.NotFound("car not found")
+		}
+		if version != etag.Any && row.Version != version {
+			return cars.ErrCarModified
+		}
+		before := carFromRow(tx, row, false)
+
+		deletedAt := time.Now().Local()
+		row.DeletedAt = &deletedAt
+		row.Version++
+
+		tx.Cars.Put(carID, row)
+		deletedCar = *carFromRow(tx, row, false)
+		return putCarAudit(ctx, tx, audit.ActionDelete, before, &deletedCar)
+	})
+	if err != nil {
+		return &cars.Car{}, err
+	}
+
+	return &deletedCar, nil
+}
+
+func (r *carMemoryRepository) RestoreCar(ctx context.Context, id string) (*cars.Car, error) {
+	var restoredCar cars.Car
+
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &cars.Car{}, apperrors.InvalidID("car", err)
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Cars.Get(carID)
+		if !ok || row.DeletedAt == nil {
+			return cars.ErrCarNotInTrash
+		}
+		before := carFromRow(tx, row, false)
+
+		row.DeletedAt = nil
+		row.Version++
+
+		if vinTaken(tx, row) {
+			return errVINExists
+		}
+		if row.EngineID.Valid && !engineLive(tx, row.EngineID.UUID) {
+			return errEngineTrashed
+		}
+
+		tx.Cars.Put(carID, row)
+		restoredCar = *carFromRow(tx, row, false)
+		return putCarAudit(ctx, tx, audit.ActionRestore, before, &restoredCar)
+	})
+	if err != nil {
+		return &cars.Car{}, err
+	}
+
+	return &restoredCar, nil
+}
+
+func (r *carMemoryRepository) CreateCars(ctx context.Context, reqs []*cars.CarRequest) ([]*cars.Car, error) {
+	return r.writeCars(ctx, len(reqs), func(ctx context.Context, i int) (*cars.Car, error) {
+		return r.CreateCar(ctx, reqs[i])
+	})
+}
+
+func (r *carMemoryRepository) UpdateCars(ctx context.Context, updates []*cars.CarUpdate) ([]*cars.Car, error) {
+	return r.writeCars(ctx, len(updates), func(ctx context.Context, i int) (*cars.Car, error) {
+		u := updates[i]
+		return r.UpdateCar(ctx, u.CarID.String(), &u.CarRequest, u.Version)
+	})
+}
+
+func (r *carMemoryRepository) DeleteCars(ctx context.Context, refs []*cars.CarRef) ([]*cars.Car, error) {
+	return r.writeCars(ctx, len(refs), func(ctx context.Context, i int) (*cars.Car, error) {
+		return r.DeleteCar(ctx, refs[i].CarID.String(), refs[i].Version)
+	})
+}
+
+// writeCars applies the n items of a batch in one transaction, one at a
+// time, and rolls it back with a cars.ItemErrors when any of them fails.
+func (r *carMemoryRepository) writeCars(ctx context.Context, n int, write func(ctx context.Context, i int) (*cars.Car, error)) ([]*cars.Car, error) {
+	written := make([]*cars.Car, n)
+	err := r.db.WithinTx(ctx, func(ctx context.Context) error {
+		errs := cars.ItemErrors{}
+		for i := range n {
+			car, err := write(ctx, i)
+			if err != nil {
+				var appErr *apperrors.Error
+				if !errors.As(err, &appErr) {
+					return err
+				}
+				errs[i] = err
+				continue
+			}
+			written[i] = car
+		}
+
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+func (r *carMemoryRepository) ReplaceEngine(ctx context.Context, engineID uuid.UUID, replacement *uuid.UUID) ([]*cars.Car, error) {
+	var newEngineID uuid.NullUUID
+	if replacement != nil {
+		newEngineID = uuid.NullUUID{UUID: *replacement, Valid: true}
+	}
+
+	moved := []*cars.Car{}
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		if newEngineID.Valid && !engineLive(tx, newEngineID.UUID) {
+			return cars.ErrEngineNotExists
+		}
+
+		var rows []memdb.CarRow
+		tx.Cars.Scan(func(_ uuid.UUID, row memdb.CarRow) bool {
+			if row.EngineID.Valid && row.EngineID.UUID == engineID && row.DeletedAt == nil {
+				rows = append(rows, row)
+			}
+			return true
+		})
+		slices.SortFunc(rows, func(a, b memdb.CarRow) int { return bytes.Compare(a.CarID[:], b.CarID[:]) })
+
+		updatedAt := time.Now().Local()
+		for _, row := range rows {
+			before := carFromRow(tx, row, false)
+
+			row.EngineID = newEngineID
+			row.UpdatedAt = updatedAt
+			row.Version++
+
+			tx.Cars.Put(row.CarID, row)
+			car := carFromRow(tx, row, false)
+			if err := putCarAudit(ctx, tx, audit.ActionUpdate, before, car); err != nil {
+				return err
+			}
+			moved = append(moved, car)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return moved, nil
+}
+
+func (r *carMemoryRepository) PurgeCars(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged := 0
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		var expired []memdb.CarRow
+		tx.Cars.Scan(func(_ uuid.UUID, row memdb.CarRow) bool {
+			if row.DeletedAt != nil && row.DeletedAt.Before(deletedBefore) {
+				expired = append(expired, row)
+			}
+			return true
+		})
+
+		for _, row := range expired {
+			tx.Cars.Delete(row.CarID)
+			if err := putCarAudit(ctx, tx, audit.ActionPurge, carFromRow(tx, row, false), nil); err != nil {
+				return err
+			}
+		}
+		purged = len(expired)
+		return nil
+	})
+	return purged, err
+}
+
+func (r *carMemoryRepository) ListCars(ctx context.Context, filter *cars.CarFilter) ([]*cars.Car, int, error) {
+	return r.listCars(ctx, filter, func(memdb.CarRow) bool { return true })
+}
+
+func (r *carMemoryRepository) ListCarsByEngine(ctx context.Context, engineID uuid.UUID, filter *cars.CarFilter) ([]*cars.Car, int, error) {
+	return r.listCars(ctx, filter, func(row memdb.CarRow) bool {
+		return row.EngineID.Valid && row.EngineID.UUID == engineID
+	})
+}
+
+func (r *carMemoryRepository) ExportCars(ctx context.Context, filter *cars.CarFilter, each func(*cars.Car) error) error {
+	query := *filter
+	query.Cursor = nil
+
+	matched, _, err := r.sortedCars(ctx, &query, func(memdb.CarRow) bool { return true })
+	if err != nil {
+		return err
+	}
+
+	for _, car := range matched {
+		if err := each(car); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *carMemoryRepository) listCars(ctx context.Context, filter *cars.CarFilter, match func(memdb.CarRow) bool) ([]*cars.Car, int, error) {
+	matched, total, err := r.sortedCars(ctx, filter, match)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// A before cursor keeps the rows closest to its boundary, which are the
+	// last ones in listing order.
+	if filter.Cursor.Reversed() {
+		start := max(len(matched)-filter.Limit, 0)
+		return append([]*cars.Car{}, matched[start:]...), total, nil
+	}
+
+	start := min(filter.Offset, len(matched))
+	end := min(start+filter.Limit, len(matched))
+
+	return append([]*cars.Car{}, matched[start:end]...), total, nil
+}
+
+// sortedCars returns the cars selected by filter and match past the cursor
+// in listing order, and the total selected regardless of the cursor.
+func (r *carMemoryRepository) sortedCars(ctx context.Context, filter *cars.CarFilter, match func(memdb.CarRow) bool) ([]*cars.Car, int, error) {
+	var matched []*cars.Car
+
+	if !slices.Contains(cars.SortFields, filter.Sort) {
+		return nil, 0, fmt.Errorf("unknown sort field %q", filter.Sort)
+	}
+
+	var boundary any
+	if filter.Cursor != nil {
+		value, err := filter.CursorValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		boundary = value
+	}
+
+	total := 0
+	err := r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		tx.Cars.Scan(func(_ uuid.UUID, row memdb.CarRow) bool {
+			if (row.DeletedAt != nil) != filter.Trashed || !match(row) {
+				return true
+			}
+
+			car := carFromRow(tx, row, true)
+			if !matchCarFilter(car, filter) {
+				return true
+			}
+
+			total++
+			if filter.Cursor == nil || filter.Cursor.After(car.SortValue(filter.Sort), boundary, car.CarID) {
+				matched = append(matched, car)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if filter.Desc {
+			a, b = b, a
+		}
+		if n := pagination.Compare(a.SortValue(filter.Sort), b.SortValue(filter.Sort)); n != 0 {
+			return n < 0
+		}
+		return pagination.Compare(a.CarID, b.CarID) < 0
+	})
+
+	return matched, total, nil
+}
+
+// matchCarFilter is the in-memory equivalent of carFilterQuery. Engine
+// criteria never match a car without an engine, like comparisons against
+// NULL in SQL.
+func matchCarFilter(car *cars.Car, f *cars.CarFilter) bool {
+	if len(f.Brands) > 0 && !slices.ContainsFunc(f.Brands, func(b string) bool { return strings.EqualFold(b, car.Brand) }) {
+		return false
+	}
+	if len(f.FuelTypes) > 0 && !slices.Contains(f.FuelTypes, car.FuelType) {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(strings.ToLower(car.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	if (f.YearMin != nil && car.Year < *f.YearMin) || (f.YearMax != nil && car.Year > *f.YearMax) {
+		return false
+	}
+	if (f.PriceMin != nil && car.Price < *f.PriceMin) || (f.PriceMax != nil && car.Price > *f.PriceMax) {
+		return false
+	}
+
+	hasEngineFilter := f.EngineDisplacementMin != nil || f.EngineDisplacementMax != nil ||
+		f.EngineCylinders != nil || f.EngineCarRangeMin != nil || f.EngineCarRangeMax != nil
+	if !hasEngineFilter {
+		return true
+	}
+
+	e := car.Engine
+	if e == nil {
+		return false
+	}
+	if (f.EngineDisplacementMin != nil && e.Displacement < *f.EngineDisplacementMin) ||
+		(f.EngineDisplacementMax != nil && e.Displacement > *f.EngineDisplacementMax) {
+		return false
+	}
+	if f.EngineCylinders != nil && e.NoOfCylinders != *f.EngineCylinders {
+		return false
+	}
+	if (f.EngineCarRangeMin != nil && e.CarRange < *f.EngineCarRangeMin) ||
+		(f.EngineCarRangeMax != nil && e.CarRange > *f.EngineCarRangeMax) {
+		return false
+	}
+	return true
+}
+
+// vinTaken mirrors the partial unique index on cars.vin, which leaves out
+// trashed cars.
+func vinTaken(tx *memdb.Tx, row memdb.CarRow) bool {
+	if row.VIN == "" {
+		return false
+	}
+
+	taken := false
+	tx.Cars.Scan(func(id uuid.UUID, other memdb.CarRow) bool {
+		taken = id != row.CarID && other.VIN == row.VIN && other.DeletedAt == nil
+		return !taken
+	})
+	return taken
+}
+
+// putCarAudit is the in-memory equivalent of recordCar.
+func putCarAudit(ctx context.Context, tx *memdb.Tx, action audit.Action, before, after *cars.Car) error {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	entry := audit.NewEntry(ctx, audit.EntityCar, subject.CarID, action, subject.Version, before.AuditFields(), after.AuditFields())
+	return auditrepositories.Put(tx, entry)
+}
+
+func engineLive(tx *memdb.Tx, engineID uuid.UUID) bool {
+	engine, ok := tx.Engines.Get(engineID)
+	return ok && engine.DeletedAt == nil
+}
+
+// carFromRow converts a stored row into a Car. With withEngine the engine
+// columns are joined in, otherwise only the engine ID is set, matching the
+// Postgres queries.
+func carFromRow(tx *memdb.Tx, row memdb.CarRow, withEngine bool) *cars.Car {
+	car := &cars.Car{
+		CarID:     row.CarID,
+		Name:      row.Name,
+		Year:      row.Year,
+		Brand:     row.Brand,
+		FuelType:  row.FuelType,
+		Price:     row.Price,
+		VIN:       row.VIN,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+		Version:   row.Version,
+		DeletedAt: row.DeletedAt,
+	}
+
+	if !row.EngineID.Valid {
+		return car
+	}
+
+	car.Engine = &engines.Engine{EngineID: row.EngineID.UUID}
+
+	if withEngine {
+		if engine, ok := tx.Engines.Get(row.EngineID.UUID); ok {
+			car.Engine.Displacement = engine.Displacement
+			car.Engine.NoOfCylinders = engine.NoOfCylinders
+			car.Engine.CarRange = engine.CarRange
+			car.Engine.Version = engine.Version
+		}
+	}
+
+	return car
+}
@@ -1,321 +1,1089 @@
 package carrepositories
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/database/sqlbuilder"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
 	"github.com/codepnw/go-car-management/modules/cars"
 	"github.com/codepnw/go-car-management/modules/engines"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type ICarRepository interface {
 	GetCarById(ctx context.Context, id string) (*cars.Car, error)
-	GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]*cars.Car, error)
 	CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error)
-	UpdateCar(ctx context.Context, id string, req *cars.CarRequest) (*cars.Car, error)
-	DeleteCar(ctx context.Context, id string) (*cars.Car, error)
+	// UpdateCar, PatchCar and DeleteCar only apply when the car is still at
+	// version, failing with a precondition error otherwise. etag.Any skips
+	// the check.
+	UpdateCar(ctx context.Context, id string, req *cars.CarRequest, version int64) (*cars.Car, error)
+	PatchCar(ctx context.Context, id string, p *cars.CarPatch, version int64) (*cars.Car, error)
+	// DeleteCar moves the car to the trash. It stays there, hidden from the
+	// other reads, until RestoreCar brings it back or PurgeCars removes it.
+	DeleteCar(ctx context.Context, id string, version int64) (*cars.Car, error)
+	RestoreCar(ctx context.Context, id string) (*cars.Car, error)
+	// CreateCars, UpdateCars and DeleteCars write a batch of cars with as
+	// few statements as possible and return them in batch order. When some
+	// items cannot be applied nothing is written and the error is a
+	// cars.ItemErrors holding the same errors the single-car methods return.
+	CreateCars(ctx context.Context, reqs []*cars.CarRequest) ([]*cars.Car, error)
+	UpdateCars(ctx context.Context, updates []*cars.CarUpdate) ([]*cars.Car, error)
+	DeleteCars(ctx context.Context, refs []*cars.CarRef) ([]*cars.Car, error)
+	// ReplaceEngine moves every live car using engineID to replacement, or
+	// detaches them from any engine when replacement is nil, and returns the
+	// moved cars.
+	ReplaceEngine(ctx context.Context, engineID uuid.UUID, replacement *uuid.UUID) ([]*cars.Car, error)
+	// PurgeCars permanently deletes the cars trashed before deletedBefore and
+	// returns how many there were.
+	PurgeCars(ctx context.Context, deletedBefore time.Time) (int, error)
+	ListCars(ctx context.Context, filter *cars.CarFilter) ([]*cars.Car, int, error)
+	// ListCarsByEngine is ListCars limited to the cars using engineID.
+	ListCarsByEngine(ctx context.Context, engineID uuid.UUID, filter *cars.CarFilter) ([]*cars.Car, int, error)
+	// ExportCars calls each for every car ListCars would select, in the same
+	// order, without paging: Limit, Offset and Cursor are ignored. Cars are
+	// read one at a time, and an error from each stops the export.
+	ExportCars(ctx context.Context, filter *cars.CarFilter, each func(*cars.Car) error) error
 }
 
+var (
+	errVINExists     = apperrors.Conflict("a car with this vin already exists")
+	errEngineTrashed = apperrors.Conflict("the engine of this car is in the trash, restore it first")
+)
+
 type carRepository struct {
-	db *sql.DB
+	db *database.TxManager
 }
 
+// NewCarRepository returns a repository whose methods join the transaction
+// carried by their context, see database.Transactor.
 func NewCarRepository(db *sql.DB) ICarRepository {
-	return &carRepository{db: db}
+	return &carRepository{db: database.NewTxManager(db)}
 }
 
 func (r *carRepository) GetCarById(ctx context.Context, id string) (*cars.Car, error) {
-	var response cars.Car
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &cars.Car{}, apperrors.InvalidID("car", err)
+	}
 
 	query := `
-		SELECT c.car_id, c.name, c.year, c.brand, c.fuel_type, c.engine_id, c.price, c.created_at, c.updated_at,
-			e.engine_id, e.displacement, e.no_of_cylinders, e.car_range 
-		FROM cars c 
-		LEFT JOIN engines e ON c.engine_id = e.engine_id 
-		WHERE c.car_id = $1;
+		SELECT c.car_id, c.name, c.year, c.brand, c.fuel_type, c.engine_id, c.price, c.vin, c.created_at, c.updated_at, c.version, c.deleted_at,
+			e.engine_id, e.displacement, e.no_of_cylinders, e.car_range, e.version
+		FROM cars c
+		LEFT JOIN engines e ON c.engine_id = e.engine_id
+		WHERE c.car_id = $1 AND c.deleted_at IS NULL;
 	`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&response.CarID,
-		&response.Name,
-		&response.Year,
-		&response.Brand,
-		&response.FuelType,
-		&response.Engine.EngineID,
-		&response.Price,
-		&response.CreatedAt,
-		&response.UpdatedAt,
-		&response.Engine.EngineID,
-		&response.Engine.Displacement,
-		&response.Engine.NoOfCylinders,
-		&response.Engine.CarRange,
-	)
+	response, err := scanCar(r.db.Querier(ctx).QueryRowContext(ctx, query, carID), true)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return &response, nil
+		if errors.Is(err, sql.ErrNoRows) {
+			return &cars.Car{}, apperrors.NotFound("car not found")
 		}
-		return &response, err
+		return &cars.Car{}, err
 	}
 
-	return &response, nil
+	return response, nil
 }
 
-func (r *carRepository) GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]*cars.Car, error) {
-	var response []*cars.Car
-	var query string
+func (r *carRepository) CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error) {
+	if req.Engine == nil {
+		return &cars.Car{}, cars.ErrEngineNotExists
+	}
+
+	createdAt := time.Now().Local()
+
+	newCar := cars.Car{
+		CarID:     uuid.New(),
+		Name:      req.Name,
+		Year:      req.Year,
+		Brand:     req.Brand,
+		FuelType:  req.FuelType,
+		Engine:    req.Engine,
+		Price:     req.Price,
+		VIN:       req.VIN,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
 
-	if isEngine {
-		query = `
-			SELECT c.car_id, c.name, c.year, c.brand, c.fuel_type, c.engine_id, c.Price, c.created_at, c.updated_at
-				e.engine_id, e.displacement, e.no_of_cylinders, e.car_range
-			FROM cars c
-			LEFT JOIN engines e ON c.engine_id = e.engine_id
-			WHERE c.brand = $1;
+	var createdCar *cars.Car
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := lockLiveEngine(ctx, tx, newCar.Engine.EngineID); err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO cars (car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;
 		`
-	} else {
-		query = `
-			SELECT car_id, name, year, brand, fuel_type, engine_id, Price, created_at, updated_at
-			FROM cars WHERE brand = $1;
+		var err error
+		createdCar, err = scanCar(tx.QueryRowContext(
+			ctx,
+			query,
+			newCar.CarID,
+			newCar.Name,
+			newCar.Year,
+			newCar.Brand,
+			newCar.FuelType,
+			newCar.Engine.EngineID,
+			newCar.Price,
+			nullString(newCar.VIN),
+			newCar.CreatedAt,
+			newCar.UpdatedAt,
+		), false)
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				return errVINExists
+			}
+			return err
+		}
+		createdCar.Engine = newCar.Engine
+
+		return recordCar(ctx, tx, audit.ActionCreate, nil, createdCar)
+	})
+	if err != nil {
+		return &cars.Car{}, err
+	}
+	return createdCar, nil
+}
+
+func (r *carRepository) UpdateCar(ctx context.Context, id string, req *cars.CarRequest, version int64) (*cars.Car, error) {
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &cars.Car{}, apperrors.InvalidID("car", err)
+	}
+
+	if req.Engine == nil {
+		return &cars.Car{}, cars.ErrEngineNotExists
+	}
+
+	var updatedCar *cars.Car
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := lockLiveEngine(ctx, tx, req.Engine.EngineID); err != nil {
+			return err
+		}
+		before, err := lockCar(ctx, tx, carID, false)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE cars
+			SET name=$2, year=$3, brand=$4, fuel_type=$5, engine_id=$6, price=$7, vin=$8, updated_at=$9, version=version+1
+			WHERE car_id = $1 AND deleted_at IS NULL AND ($10::bigint = 0 OR version = $10)
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at
 		`
+
+		updatedCar, err = scanCar(tx.QueryRowContext(
+			ctx,
+			query,
+			carID,
+			req.Name,
+			req.Year,
+			req.Brand,
+			req.FuelType,
+			req.Engine.EngineID,
+			req.Price,
+			nullString(req.VIN),
+			time.Now().Local(),
+			version,
+		), false)
+		if err != nil {
+			return carWriteError(ctx, tx, carID, err)
+		}
+
+		return recordCar(ctx, tx, audit.ActionUpdate, before, updatedCar)
+	})
+	if err != nil {
+		return &cars.Car{}, err
 	}
+	return updatedCar, nil
+}
 
-	rows, err := r.db.QueryContext(ctx, query, brand)
+func (r *carRepository) PatchCar(ctx context.Context, id string, p *cars.CarPatch, version int64) (*cars.Car, error) {
+	carID, err := uuid.Parse(id)
 	if err != nil {
-		return nil, err
+		return &cars.Car{}, apperrors.InvalidID("car", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var car cars.Car
-		if isEngine {
-			var engine engines.Engine
-			err := rows.Scan(
-				&car.CarID,
-				&car.Name,
-				&car.Year,
-				&car.Brand,
-				&car.FuelType,
-				&car.Engine.EngineID,
-				&car.Price,
-				&car.CreatedAt,
-				&car.UpdatedAt,
-				&car.Engine.EngineID,
-				&car.Engine.Displacement,
-				&car.Engine.NoOfCylinders,
-				&car.Engine.CarRange,
-			)
-			if err != nil {
-				return nil, err
+	b := sqlbuilder.New()
+	if p.Name != nil {
+		b.Set("name", *p.Name)
+	}
+	if p.Year != nil {
+		b.Set("year", *p.Year)
+	}
+	if p.Brand != nil {
+		b.Set("brand", *p.Brand)
+	}
+	if p.FuelType != nil {
+		b.Set("fuel_type", *p.FuelType)
+	}
+	if p.EngineID != nil {
+		b.Set("engine_id", *p.EngineID)
+	}
+	if p.Price != nil {
+		b.Set("price", *p.Price)
+	}
+	if p.VIN != nil {
+		b.Set("vin", nullString(*p.VIN))
+	}
+	b.Set("updated_at", time.Now().Local())
+	b.SetExpr("version = version + 1")
+	b.Where("car_id = ?", carID)
+	b.Where("deleted_at IS NULL")
+	if version != etag.Any {
+		b.Where("version = ?", version)
+	}
+
+	query := "UPDATE cars " + b.SetClause() + " " + b.WhereClause() +
+		" RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;"
+
+	var patchedCar *cars.Car
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if p.EngineID != nil {
+			if err := lockLiveEngine(ctx, tx, *p.EngineID); err != nil {
+				return err
 			}
+		}
+		before, err := lockCar(ctx, tx, carID, false)
+		if err != nil {
+			return err
+		}
+
+		patchedCar, err = scanCar(tx.QueryRowContext(ctx, query, b.Args()...), false)
+		if err != nil {
+			return carWriteError(ctx, tx, carID, err)
+		}
 
-			car.Engine = &engine
-		} else {
-			err := rows.Scan(
-				&car.CarID,
-				&car.Name,
-				&car.Year,
-				&car.Brand,
-				&car.FuelType,
-				&car.Engine.EngineID,
-				&car.Price,
-				&car.CreatedAt,
-				&car.UpdatedAt,
-			)
+		return recordCar(ctx, tx, audit.ActionUpdate, before, patchedCar)
+	})
+	if err != nil {
+		return &cars.Car{}, err
+	}
+	return patchedCar, nil
+}
+
+func (r *carRepository) DeleteCar(ctx context.Context, id string, version int64) (*cars.Car, error) {
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &cars.Car{}, apperrors.InvalidID("car", err)
+	}
+
+	var deletedCar *cars.Car
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := lockCar(ctx, tx, carID, false)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE cars SET deleted_at = $2, version = version + 1
+			WHERE car_id = $1 AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3)
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;
+		`
+
+		deletedCar, err = scanCar(tx.QueryRowContext(ctx, query, carID, time.Now().Local(), version), false)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return carMissingOrModified(ctx, tx, carID)
+			}
+			return err
+		}
+
+		return recordCar(ctx, tx, audit.ActionDelete, before, deletedCar)
+	})
+	if err != nil {
+		return &cars.Car{}, err
+	}
+	return deletedCar, nil
+}
+
+func (r *carRepository) RestoreCar(ctx context.Context, id string) (*cars.Car, error) {
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return &cars.Car{}, apperrors.InvalidID("car", err)
+	}
+
+	var restoredCar *cars.Car
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := lockCar(ctx, tx, carID, true)
+		if err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE cars SET deleted_at = NULL, version = version + 1
+			WHERE car_id = $1 AND deleted_at IS NOT NULL
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;
+		`
+
+		restoredCar, err = scanCar(tx.QueryRowContext(ctx, query, carID), false)
+		if err != nil {
+			if database.IsUniqueViolation(err) {
+				return errVINExists
+			}
+			return err
+		}
+
+		if restoredCar.Engine != nil {
+			var engineTrashed bool
+			err = tx.QueryRowContext(
+				ctx,
+				"SELECT deleted_at IS NOT NULL FROM engines WHERE engine_id = $1 FOR SHARE;",
+				restoredCar.Engine.EngineID,
+			).Scan(&engineTrashed)
 			if err != nil {
-				return nil, err
+				return err
+			}
+			if engineTrashed {
+				return errEngineTrashed
 			}
 		}
 
-		response = append(response, &car)
+		return recordCar(ctx, tx, audit.ActionRestore, before, restoredCar)
+	})
+	if err != nil {
+		return &cars.Car{}, err
+	}
+	return restoredCar, nil
+}
+
+func (r *carRepository) CreateCars(ctx context.Context, reqs []*cars.CarRequest) ([]*cars.Car, error) {
+	createdAt := time.Now().Local()
+
+	writes := make([]carWrite, len(reqs))
+	b := sqlbuilder.New()
+	rows := make([]string, len(reqs))
+	for i, req := range reqs {
+		var engineID uuid.UUID
+		if req.Engine != nil {
+			engineID = req.Engine.EngineID
+		}
+		writes[i] = carWrite{carID: uuid.New(), engineID: &engineID, vin: req.VIN}
+		rows[i] = b.Row(writes[i].carID, req.Name, req.Year, req.Brand, req.FuelType, engineID, req.Price, nullString(req.VIN), createdAt, createdAt)
 	}
 
-	if err = rows.Err(); err != nil {
+	var created []*cars.Car
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		state, err := loadBatchState(ctx, tx, writes)
+		if err != nil {
+			return err
+		}
+		if errs := state.check(writes); len(errs) > 0 {
+			return errs
+		}
+
+		returned, err := queryCars(ctx, tx,
+			`INSERT INTO cars (car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at)
+			VALUES `+strings.Join(rows, ", ")+`
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;`,
+			b.Args()...,
+		)
+		if err != nil {
+			return carWriteError(ctx, tx, uuid.Nil, err)
+		}
+
+		created = inBatchOrder(returned, writes)
+		entries := make([]*audit.Entry, len(created))
+		for i, car := range created {
+			car.Engine = reqs[i].Engine
+			entries[i] = audit.NewEntry(ctx, audit.EntityCar, car.CarID, audit.ActionCreate, car.Version, nil, car.AuditFields())
+		}
+		return auditrepositories.InsertMany(ctx, tx, entries)
+	})
+	if err != nil {
 		return nil, err
 	}
-	return response, nil
+	return created, nil
 }
 
-func (r *carRepository) CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error) {
-	var createCar cars.Car
-	var engineID uuid.UUID
+func (r *carRepository) UpdateCars(ctx context.Context, updates []*cars.CarUpdate) ([]*cars.Car, error) {
+	b := sqlbuilder.New()
+	updatedAt := b.Arg(time.Now().Local())
 
-	err := r.db.QueryRowContext(ctx, "SELECT id FROM engines WHERE id = $1;", req.Engine.EngineID).Scan(&engineID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return &createCar, errors.New("engine_id does not exists in the engine table")
+	writes := make([]carWrite, len(updates))
+	rows := make([]string, len(updates))
+	for i, u := range updates {
+		var engineID uuid.UUID
+		if u.Engine != nil {
+			engineID = u.Engine.EngineID
 		}
-		return &createCar, err
+		writes[i] = carWrite{carID: u.CarID, existing: true, version: u.Version, engineID: &engineID, vin: u.VIN}
+		rows[i] = b.Row(u.CarID, u.Name, u.Year, u.Brand, u.FuelType, engineID, u.Price, nullString(u.VIN))
 	}
 
-	carId := uuid.New()
-	createdAt := time.Now().Local()
-	updatedAt := createdAt
+	var updated []*cars.Car
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		state, err := loadBatchState(ctx, tx, writes)
+		if err != nil {
+			return err
+		}
+		if errs := state.check(writes); len(errs) > 0 {
+			return errs
+		}
 
-	newCar := cars.Car{
-		CarID:     carId,
-		Name:      req.Name,
-		Year:      req.Year,
-		Brand:     req.Brand,
-		FuelType:  req.FuelType,
-		Engine:    req.Engine,
-		Price:     req.Price,
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
-	}
+		// The VALUES columns are text; the casts give them the column types.
+		returned, err := queryCars(ctx, tx,
+			`UPDATE cars AS c
+			SET name = v.name, year = v.year::integer, brand = v.brand, fuel_type = v.fuel_type,
+				engine_id = v.engine_id::uuid, price = v.price::numeric, vin = v.vin,
+				updated_at = `+updatedAt+`, version = c.version + 1
+			FROM (VALUES `+strings.Join(rows, ", ")+`) AS v (car_id, name, year, brand, fuel_type, engine_id, price, vin)
+			WHERE c.car_id = v.car_id::uuid
+			RETURNING c.car_id, c.name, c.year, c.brand, c.fuel_type, c.engine_id, c.price, c.vin, c.created_at, c.updated_at, c.version, c.deleted_at;`,
+			b.Args()...,
+		)
+		if err != nil {
+			return carWriteError(ctx, tx, uuid.Nil, err)
+		}
 
-	// Transaction
-	tx, err := r.db.BeginTx(ctx, nil)
+		updated = inBatchOrder(returned, writes)
+		return state.record(ctx, tx, audit.ActionUpdate, updated)
+	})
 	if err != nil {
-		return &createCar, err
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (r *carRepository) DeleteCars(ctx context.Context, refs []*cars.CarRef) ([]*cars.Car, error) {
+	writes := make([]carWrite, len(refs))
+	carIDs := make([]string, len(refs))
+	for i, ref := range refs {
+		writes[i] = carWrite{carID: ref.CarID, existing: true, version: ref.Version}
+		carIDs[i] = ref.CarID.String()
 	}
-	defer func() {
+
+	var deleted []*cars.Car
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		state, err := loadBatchState(ctx, tx, writes)
 		if err != nil {
-			tx.Rollback()
-			return
+			return err
+		}
+		if errs := state.check(writes); len(errs) > 0 {
+			return errs
 		}
-		err = tx.Commit()
-	}()
 
-	query := `
-		INSERT INTO cars (car_id, name, year, brand, fuel_type, engine_id, price, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING car_id, name, year, brand, fuel_type, engine_id, price, created_at, updated_at;
-	`
-	err = tx.QueryRowContext(
-		ctx,
-		query,
-		newCar.CarID,
-		newCar.Name,
-		newCar.Year,
-		newCar.Brand,
-		newCar.FuelType,
-		newCar.Engine.EngineID,
-		newCar.Price,
-		newCar.CreatedAt,
-		newCar.UpdatedAt,
-	).Scan(
-		&createCar.CarID,
-		&createCar.Name,
-		&createCar.Year,
-		&createCar.Brand,
-		&createCar.FuelType,
-		&createCar.Engine.EngineID,
-		&createCar.Price,
-		&createCar.CreatedAt,
-		&createCar.UpdatedAt,
-	)
+		returned, err := queryCars(ctx, tx,
+			`UPDATE cars SET deleted_at = $1, version = version + 1
+			WHERE car_id = ANY($2::uuid[])
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;`,
+			time.Now().Local(),
+			pq.Array(carIDs),
+		)
+		if err != nil {
+			return err
+		}
 
+		deleted = inBatchOrder(returned, writes)
+		return state.record(ctx, tx, audit.ActionDelete, deleted)
+	})
 	if err != nil {
-		return &createCar, err
+		return nil, err
 	}
-	return &createCar, nil
+	return deleted, nil
 }
 
-func (r *carRepository) UpdateCar(ctx context.Context, id string, req *cars.CarRequest) (*cars.Car, error) {
-	var updatedCar cars.Car
+func (r *carRepository) ReplaceEngine(ctx context.Context, engineID uuid.UUID, replacement *uuid.UUID) ([]*cars.Car, error) {
+	var newEngineID uuid.NullUUID
+	if replacement != nil {
+		newEngineID = uuid.NullUUID{UUID: *replacement, Valid: true}
+	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	var moved []*cars.Car
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		// Locking the engine first keeps cars from being given it until the
+		// transaction ends, so the cars moved below are all of its cars.
+		if _, err := tx.ExecContext(ctx, "SELECT 1 FROM engines WHERE engine_id = $1 FOR UPDATE;", engineID); err != nil {
+			return err
+		}
+		if replacement != nil {
+			if err := lockLiveEngine(ctx, tx, *replacement); err != nil {
+				return err
+			}
+		}
+
+		before, err := queryCars(ctx, tx,
+			`SELECT car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at
+			FROM cars WHERE engine_id = $1 AND deleted_at IS NULL
+			ORDER BY car_id FOR UPDATE;`,
+			engineID,
+		)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[uuid.UUID]*cars.Car, len(before))
+		carIDs := make([]string, len(before))
+		for i, car := range before {
+			byID[car.CarID] = car
+			carIDs[i] = car.CarID.String()
+		}
+
+		moved, err = queryCars(ctx, tx,
+			`UPDATE cars SET engine_id = $2, updated_at = $3, version = version + 1
+			WHERE car_id = ANY($1::uuid[])
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;`,
+			pq.Array(carIDs),
+			newEngineID,
+			time.Now().Local(),
+		)
+		if err != nil {
+			return err
+		}
+
+		slices.SortFunc(moved, func(a, b *cars.Car) int { return bytes.Compare(a.CarID[:], b.CarID[:]) })
+		for _, car := range moved {
+			if err := recordCar(ctx, tx, audit.ActionUpdate, byID[car.CarID], car); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return &updatedCar, err
+		return nil, err
 	}
-	defer func() {
+	return moved, nil
+}
+
+func (r *carRepository) PurgeCars(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged []*cars.Car
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		purged, err = queryCars(ctx, tx,
+			`DELETE FROM cars WHERE deleted_at < $1
+			RETURNING car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at;`,
+			deletedBefore,
+		)
 		if err != nil {
-			tx.Rollback()
-			return
+			return err
 		}
-		err = tx.Commit()
-	}()
 
-	query := `
-		UPDATE cars
-		SET name=$2, year=$3, brand=$4, fuel_type=$5, engine_id=$6, price=$7, updated_at=$8
-		WHERE car_id = $1
-		RETURNING car_id, name, year, brand, fuel_type, engine_id, price, created_at, updated_at
-	`
+		for _, car := range purged {
+			if err := recordCar(ctx, tx, audit.ActionPurge, car, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
+
+// carSortColumns maps sort fields to columns. Text columns sort by bytes, as
+// pagination.Compare does, rather than by the collation of the database.
+var carSortColumns = map[string]string{
+	"name":      `c.name COLLATE "C"`,
+	"year":      "c.year",
+	"brand":     `c.brand COLLATE "C"`,
+	"fuelType":  `c.fuel_type COLLATE "C"`,
+	"price":     "c.price",
+	"createdAt": "c.created_at",
+	"updatedAt": "c.updated_at",
+}
 
-	err = tx.QueryRowContext(
+func (r *carRepository) ListCars(ctx context.Context, filter *cars.CarFilter) ([]*cars.Car, int, error) {
+	return r.listCars(ctx, carFilterQuery(filter), filter)
+}
+
+func (r *carRepository) ListCarsByEngine(ctx context.Context, engineID uuid.UUID, filter *cars.CarFilter) ([]*cars.Car, int, error) {
+	// cars_engine_idx leads with engine_id, so the cars of one engine are an
+	// index range rather than a scan of the whole table.
+	b := carFilterQuery(filter)
+	b.Where("c.engine_id = ?", engineID)
+	return r.listCars(ctx, b, filter)
+}
+
+func (r *carRepository) listCars(ctx context.Context, b *sqlbuilder.Builder, filter *cars.CarFilter) ([]*cars.Car, int, error) {
+	var total int
+	err := r.db.Querier(ctx).QueryRowContext(
 		ctx,
-		query,
-		id,
-		req.Name,
-		req.Year,
-		req.Brand,
-		req.FuelType,
-		req.Engine.EngineID,
-		req.Price,
-		time.Now().Local(),
-	).Scan(
-		&updatedCar.CarID,
-		&updatedCar.Name,
-		&updatedCar.Year,
-		&updatedCar.Brand,
-		&updatedCar.FuelType,
-		&updatedCar.Engine.EngineID,
-		&updatedCar.Price,
-		&updatedCar.UpdatedAt,
-	)
+		"SELECT count(*) FROM cars c LEFT JOIN engines e ON c.engine_id = e.engine_id "+b.WhereClause()+";",
+		b.Args()...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
 
+	sortColumn, ok := carSortColumns[filter.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown sort field %q", filter.Sort)
+	}
+
+	// A before cursor walks the listing backwards; the rows are flipped back
+	// into listing order below.
+	desc := filter.Desc != filter.Cursor.Reversed()
+
+	if filter.Cursor != nil {
+		value, err := filter.CursorValue()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		b.Where("("+sortColumn+", c.car_id) "+op+" (?, ?)", value, filter.Cursor.ID)
+	}
+
+	b.OrderBy(sortColumn, desc).OrderBy("c.car_id", desc)
+
+	query := `
+		SELECT c.car_id, c.name, c.year, c.brand, c.fuel_type, c.engine_id, c.price, c.vin, c.created_at, c.updated_at, c.version, c.deleted_at,
+			e.engine_id, e.displacement, e.no_of_cylinders, e.car_range, e.version
+		FROM cars c
+		LEFT JOIN engines e ON c.engine_id = e.engine_id
+	` + b.WhereClause() + " " + b.OrderByClause() + " " + b.LimitOffset(filter.Limit, filter.Offset) + ";"
+
+	rows, err := r.db.Querier(ctx).QueryContext(ctx, query, b.Args()...)
 	if err != nil {
-		return &updatedCar, err
+		return nil, 0, err
 	}
-	return &updatedCar, nil
+	defer rows.Close()
+
+	response := []*cars.Car{}
+	for rows.Next() {
+		car, err := scanCar(rows, true)
+		if err != nil {
+			return nil, 0, err
+		}
+		response = append(response, car)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Cursor.Reversed() {
+		slices.Reverse(response)
+	}
+	return response, total, nil
 }
 
-func (r *carRepository) DeleteCar(ctx context.Context, id string) (*cars.Car, error) {
-	var deletedCar cars.Car
+func (r *carRepository) ExportCars(ctx context.Context, filter *cars.CarFilter, each func(*cars.Car) error) error {
+	sortColumn, ok := carSortColumns[filter.Sort]
+	if !ok {
+		return fmt.Errorf("unknown sort field %q", filter.Sort)
+	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	b := carFilterQuery(filter)
+	b.OrderBy(sortColumn, filter.Desc).OrderBy("c.car_id", filter.Desc)
+
+	query := `
+		SELECT c.car_id, c.name, c.year, c.brand, c.fuel_type, c.engine_id, c.price, c.vin, c.created_at, c.updated_at, c.version, c.deleted_at,
+			e.engine_id, e.displacement, e.no_of_cylinders, e.car_range, e.version
+		FROM cars c
+		LEFT JOIN engines e ON c.engine_id = e.engine_id
+	` + b.WhereClause() + " " + b.OrderByClause() + ";"
+
+	rows, err := r.db.Querier(ctx).QueryContext(ctx, query, b.Args()...)
 	if err != nil {
-		return &deletedCar, err
+		return err
 	}
-	defer func() {
+	defer rows.Close()
+
+	for rows.Next() {
+		car, err := scanCar(rows, true)
 		if err != nil {
-			tx.Rollback()
-			return
+			return err
+		}
+		if err := each(car); err != nil {
+			return err
 		}
-		err = tx.Commit()
-	}()
+	}
+	return rows.Err()
+}
 
-	err = tx.QueryRowContext(
-		ctx,
-		`SELECT car_id, name, year, brand, fuel_type, engine_id, price, created_at, updated_at
-		FROM cars WHERE car_id = $1;`,
-		id,
-	).Scan(
-		&deletedCar.CarID,
-		&deletedCar.Name,
-		&deletedCar.Year,
-		&deletedCar.Brand,
-		&deletedCar.FuelType,
-		&deletedCar.Engine.EngineID,
-		&deletedCar.Price,
-		&deletedCar.CreatedAt,
-		&deletedCar.UpdatedAt,
-	)
+func carFilterQuery(f *cars.CarFilter) *sqlbuilder.Builder {
+	b := sqlbuilder.New()
+
+	if f.Trashed {
+		b.Where("c.deleted_at IS NOT NULL")
+	} else {
+		b.Where("c.deleted_at IS NULL")
+	}
+
+	if len(f.Brands) > 0 {
+		brands := make([]string, len(f.Brands))
+		for i, brand := range f.Brands {
+			brands[i] = strings.ToLower(brand)
+		}
+		b.Where("lower(c.brand) = ANY(?)", pq.Array(brands))
+	}
+	if len(f.FuelTypes) > 0 {
+		b.Where("c.fuel_type = ANY(?)", pq.Array(f.FuelTypes))
+	}
+	if f.Name != "" {
+		b.Where("c.name ILIKE ?", sqlbuilder.Contains(f.Name))
+	}
+	if f.YearMin != nil {
+		b.Where("c.year >= ?", *f.YearMin)
+	}
+	if f.YearMax != nil {
+		b.Where("c.year <= ?", *f.YearMax)
+	}
+	if f.PriceMin != nil {
+		b.Where("c.price >= ?", *f.PriceMin)
+	}
+	if f.PriceMax != nil {
+		b.Where("c.price <= ?", *f.PriceMax)
+	}
+	if f.EngineDisplacementMin != nil {
+		b.Where("e.displacement >= ?", *f.EngineDisplacementMin)
+	}
+	if f.EngineDisplacementMax != nil {
+		b.Where("e.displacement <= ?", *f.EngineDisplacementMax)
+	}
+	if f.EngineCylinders != nil {
+		b.Where("e.no_of_cylinders = ?", *f.EngineCylinders)
+	}
+	if f.EngineCarRangeMin != nil {
+		b.Where("e.car_range >= ?", *f.EngineCarRangeMin)
+	}
+	if f.EngineCarRangeMax != nil {
+		b.Where("e.car_range <= ?", *f.EngineCarRangeMax)
+	}
+
+	return b
+}
 
+// lockCar reads a live car, or with trashed a trashed one, and locks its row
+// for the rest of tx so the audit entry sees the state the write replaced.
+func lockCar(ctx context.Context, tx *sql.Tx, carID uuid.UUID, trashed bool) (*cars.Car, error) {
+	condition := "deleted_at IS NULL"
+	if trashed {
+		condition = "deleted_at IS NOT NULL"
+	}
+
+	car, err := scanCar(tx.QueryRowContext(
+		ctx,
+		`SELECT car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at
+		FROM cars WHERE car_id = $1 AND `+condition+` FOR UPDATE;`,
+		carID,
+	), false)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return &cars.Car{}, errors.New("car not found")
+			if trashed {
+				return nil, cars.ErrCarNotInTrash
+			}
+			return nil, apperrors.NotFound("car not found")
 		}
-		return &cars.Car{}, err
+		return nil, err
+	}
+	return car, nil
+}
+
+// recordCar writes the audit entry of a change from before to after. One of
+// them is nil when the car is created or purged.
+func recordCar(ctx context.Context, tx *sql.Tx, action audit.Action, before, after *cars.Car) error {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	entry := audit.NewEntry(ctx, audit.EntityCar, subject.CarID, action, subject.Version, before.AuditFields(), after.AuditFields())
+	return auditrepositories.Insert(ctx, tx, entry)
+}
+
+// carWriteError maps the error of a versioned car write to what the client
+// should see.
+// lockLiveEngine checks that the engine exists outside the trash and holds a
+// share lock on it until the transaction ends, so it cannot be deleted while
+// a car is given it.
+func lockLiveEngine(ctx context.Context, tx *sql.Tx, engineID uuid.UUID) error {
+	var one int
+	err := tx.QueryRowContext(ctx, "SELECT 1 FROM engines WHERE engine_id = $1 AND deleted_at IS NULL FOR SHARE;", engineID).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cars.ErrEngineNotExists
 	}
+	return err
+}
 
-	result, err := tx.ExecContext(ctx, "DELETE FROM cars WHERE car_id = $1;", id)
+func carWriteError(ctx context.Context, tx *sql.Tx, carID uuid.UUID, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return carMissingOrModified(ctx, tx, carID)
+	case database.IsForeignKeyViolation(err):
+		return cars.ErrEngineNotExists
+	case database.IsUniqueViolation(err):
+		return errVINExists
+	}
+	return err
+}
+
+// carMissingOrModified explains why a versioned write matched no row.
+func carMissingOrModified(ctx context.Context, tx *sql.Tx, carID uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM cars WHERE car_id = $1 AND deleted_at IS NULL);", carID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return cars.ErrCarModified
+	}
+	return apperrors.NotFound("car not found")
+}
+
+// carWrite is one item of a batch write as the batch checks see it.
+type carWrite struct {
+	carID uuid.UUID
+	// existing is set for writes to a stored car, which must be live and at
+	// version unless that is etag.Any.
+	existing bool
+	version  int64
+	// engineID is the engine the car is given, nil when it is left alone.
+	engineID *uuid.UUID
+	vin      string
+}
+
+// batchState is what the checks of a batch write need to know about the
+// rows it touches, read in its transaction.
+type batchState struct {
+	// cars are the live cars written to, locked, as they were before.
+	cars map[uuid.UUID]*cars.Car
+	// engines holds the live engines among those referenced.
+	engines map[uuid.UUID]bool
+	// vins maps the VINs taken by live cars to their car.
+	vins map[string]uuid.UUID
+}
+
+func loadBatchState(ctx context.Context, tx *sql.Tx, writes []carWrite) (*batchState, error) {
+	var carIDs, engineIDs, vins []string
+	for _, w := range writes {
+		if w.existing {
+			carIDs = append(carIDs, w.carID.String())
+		}
+		if w.engineID != nil {
+			engineIDs = append(engineIDs, w.engineID.String())
+		}
+		if w.vin != "" {
+			vins = append(vins, w.vin)
+		}
+	}
+
+	state := &batchState{
+		cars:    make(map[uuid.UUID]*cars.Car),
+		engines: make(map[uuid.UUID]bool),
+		vins:    make(map[string]uuid.UUID),
+	}
+
+	// Engines are locked before cars, as the writes of a single car do, so
+	// that the two cannot deadlock.
+	if len(engineIDs) > 0 {
+		err := scanEach(ctx, tx, func(rows *sql.Rows) error {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			state.engines[id] = true
+			return nil
+		}, "SELECT engine_id FROM engines WHERE engine_id = ANY($1::uuid[]) AND deleted_at IS NULL ORDER BY engine_id FOR SHARE;", pq.Array(engineIDs))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(carIDs) > 0 {
+		current, err := queryCars(ctx, tx,
+			`SELECT car_id, name, year, brand, fuel_type, engine_id, price, vin, created_at, updated_at, version, deleted_at
+			FROM cars WHERE car_id = ANY($1::uuid[]) AND deleted_at IS NULL
+			ORDER BY car_id FOR UPDATE;`,
+			pq.Array(carIDs),
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, car := range current {
+			state.cars[car.CarID] = car
+			if car.VIN != "" {
+				state.vins[car.VIN] = car.CarID
+			}
+		}
+	}
+
+	if len(vins) > 0 {
+		err := scanEach(ctx, tx, func(rows *sql.Rows) error {
+			var vin string
+			var id uuid.UUID
+			if err := rows.Scan(&vin, &id); err != nil {
+				return err
+			}
+			state.vins[vin] = id
+			return nil
+		}, "SELECT vin, car_id FROM cars WHERE vin = ANY($1::text[]) AND deleted_at IS NULL;", pq.Array(vins))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// check runs the checks of the single-car writes over the batch, applying
+// the items in order as the in-memory repository does: a VIN released by
+// one item may be taken by a later one.
+func (s *batchState) check(writes []carWrite) cars.ItemErrors {
+	errs := cars.ItemErrors{}
+	written := make(map[uuid.UUID]bool)
+
+	for i, w := range writes {
+		var current *cars.Car
+		if w.existing {
+			current = s.cars[w.carID]
+			switch {
+			case current == nil:
+				errs[i] = apperrors.NotFound("car not found")
+				continue
+			case written[w.carID] || (w.version != etag.Any && current.Version != w.version):
+				errs[i] = cars.ErrCarModified
+				continue
+			}
+		}
+		if w.engineID != nil && !s.engines[*w.engineID] {
+			errs[i] = cars.ErrEngineNotExists
+			continue
+		}
+		if owner, ok := s.vins[w.vin]; w.vin != "" && ok && owner != w.carID {
+			errs[i] = errVINExists
+			continue
+		}
+
+		written[w.carID] = true
+		if current != nil && current.VIN != "" {
+			delete(s.vins, current.VIN)
+		}
+		if w.vin != "" {
+			s.vins[w.vin] = w.carID
+		}
+	}
+	return errs
+}
+
+// record writes the audit entries of a batch change to the cars of s.
+func (s *batchState) record(ctx context.Context, tx *sql.Tx, action audit.Action, changed []*cars.Car) error {
+	entries := make([]*audit.Entry, len(changed))
+	for i, car := range changed {
+		entries[i] = audit.NewEntry(ctx, audit.EntityCar, car.CarID, action, car.Version, s.cars[car.CarID].AuditFields(), car.AuditFields())
+	}
+	return auditrepositories.InsertMany(ctx, tx, entries)
+}
+
+// inBatchOrder orders the rows a batch statement returned like its items.
+func inBatchOrder(returned []*cars.Car, writes []carWrite) []*cars.Car {
+	byID := make(map[uuid.UUID]*cars.Car, len(returned))
+	for _, car := range returned {
+		byID[car.CarID] = car
+	}
+
+	ordered := make([]*cars.Car, len(writes))
+	for i, w := range writes {
+		ordered[i] = byID[w.carID]
+	}
+	return ordered
+}
+
+// scanEach calls scan for every row a query returns.
+func scanEach(ctx context.Context, tx *sql.Tx, scan func(rows *sql.Rows) error, query string, args ...any) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
-		return &cars.Car{}, err
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
 	}
+	return rows.Err()
+}
 
-	rowsAffected, err := result.RowsAffected()
+// queryCars scans every car a query returns, without the engine columns.
+func queryCars(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]*cars.Car, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
-		return &cars.Car{}, err
+		return nil, err
 	}
+	defer rows.Close()
+
+	response := []*cars.Car{}
+	for rows.Next() {
+		car, err := scanCar(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, car)
+	}
+	return response, rows.Err()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	if rowsAffected == 0 {
-		return &cars.Car{}, errors.New("no rows delete")
+// scanCar reads the car columns and, with withEngine, the joined engine
+// columns. Engine columns may be NULL because of the LEFT JOIN.
+func scanCar(row rowScanner, withEngine bool) (*cars.Car, error) {
+	var car cars.Car
+	var engineID uuid.NullUUID
+	var vin sql.NullString
+
+	dest := []any{
+		&car.CarID,
+		&car.Name,
+		&car.Year,
+		&car.Brand,
+		&car.FuelType,
+		&engineID,
+		&car.Price,
+		&vin,
+		&car.CreatedAt,
+		&car.UpdatedAt,
+		&car.Version,
+		&car.DeletedAt,
+	}
+
+	var joinedID uuid.NullUUID
+	var displacement, noOfCylinders, carRange sql.NullInt32
+	var engineVersion sql.NullInt64
+	if withEngine {
+		dest = append(dest, &joinedID, &displacement, &noOfCylinders, &carRange, &engineVersion)
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	car.VIN = vin.String
+
+	if engineID.Valid {
+		car.Engine = &engines.Engine{EngineID: engineID.UUID}
+		if withEngine && joinedID.Valid {
+			car.Engine.Displacement = uint16(displacement.Int32)
+			car.Engine.NoOfCylinders = uint16(noOfCylinders.Int32)
+			car.Engine.CarRange = uint16(carRange.Int32)
+			car.Engine.Version = engineVersion.Int64
+		}
 	}
 
-	return &deletedCar, nil
+	return &car, nil
 }
@@ -0,0 +1,37 @@
+package carrepositories_test
+
+import (
+	"testing"
+
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/codepnw/go-car-management/modules/repotest"
+)
+
+func TestCarRepository(t *testing.T) {
+	repotest.RunCarRepositoryTests(t, func(t *testing.T) *repotest.Repositories {
+		db := repotest.Postgres(t)
+		return &repotest.Repositories{
+			Car:      carrepositories.NewCarRepository(db),
+			Engine:   engrepositories.NewEngineRepository(db),
+			Audit:    auditrepositories.NewAuditRepository(db),
+			Tx:       database.NewTxManager(db),
+			Backfill: repotest.PostgresBackfill(db),
+		}
+	})
+}
+
+func TestCarMemoryRepository(t *testing.T) {
+	repotest.RunCarRepositoryTests(t, func(t *testing.T) *repotest.Repositories {
+		db := memdb.New()
+		return &repotest.Repositories{
+			Car:    carrepositories.NewCarMemoryRepository(db),
+			Engine: engrepositories.NewEngineMemoryRepository(db),
+			Audit:  auditrepositories.NewAuditMemoryRepository(db),
+			Tx:     db,
+		}
+	})
+}
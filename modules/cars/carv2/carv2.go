@@ -0,0 +1,376 @@
+// Package carv2 is the JSON form of cars in version 2 of the API. Compared
+// with version 1, a car is identified by "id", nests its engine under
+// "engine", renders its price as a money object and always carries its
+// nullable fields, as null when they are not set.
+package carv2
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/codepnw/go-car-management/modules/engines/enginev2"
+	"github.com/codepnw/go-car-management/patch"
+	"github.com/google/uuid"
+)
+
+// Currency is the currency of every price. Prices are stored as bare
+// amounts, so it is the only one the API accepts.
+const Currency = "USD"
+
+// Money is an amount in a currency. The amount is a decimal string, so that
+// clients do not round it through floating point.
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount float64) Money {
+	return Money{Amount: strconv.FormatFloat(amount, 'f', -1, 64), Currency: Currency}
+}
+
+var amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Value returns the amount of m, reporting its invalid parts under field.
+func (m *Money) Value(field string) (float64, []apperrors.FieldError) {
+	var fields []apperrors.FieldError
+	if m.Currency != Currency {
+		fields = append(fields, apperrors.FieldError{
+			Field:   field + ".currency",
+			Message: "currency must be " + Currency,
+		})
+	}
+
+	amount, err := strconv.ParseFloat(m.Amount, 64)
+	if err != nil || !amountPattern.MatchString(m.Amount) {
+		fields = append(fields, apperrors.FieldError{
+			Field:   field + ".amount",
+			Message: "amount must be a decimal number, such as 19999.99",
+		})
+	}
+	return amount, fields
+}
+
+type Car struct {
+	ID        uuid.UUID        `json:"id"`
+	Name      string           `json:"name"`
+	Year      uint16           `json:"year"`
+	Brand     string           `json:"brand"`
+	FuelType  string           `json:"fuelType"`
+	Engine    *enginev2.Engine `json:"engine"`
+	Price     Money            `json:"price"`
+	VIN       *string          `json:"vin"`
+	Version   int64            `json:"version"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	DeletedAt *time.Time       `json:"deletedAt"`
+}
+
+// NewCar returns the form of c.
+func NewCar(c *cars.Car) *Car {
+	car := &Car{
+		ID:        c.CarID,
+		Name:      c.Name,
+		Year:      c.Year,
+		Brand:     c.Brand,
+		FuelType:  c.FuelType,
+		Engine:    enginev2.NewEngine(c.Engine),
+		Price:     NewMoney(c.Price),
+		Version:   c.Version,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		DeletedAt: c.DeletedAt,
+	}
+	if c.VIN != "" {
+		car.VIN = &c.VIN
+	}
+	return car
+}
+
+// EngineInput is the engine of a car request: a stored engine by id, or the
+// specs of an engine.
+type EngineInput struct {
+	ID            *uuid.UUID `json:"id,omitempty"`
+	Displacement  uint16     `json:"displacement,omitempty"`
+	NoOfCylinders uint16     `json:"noOfCylinders,omitempty"`
+	CarRange      uint16     `json:"carRange,omitempty"`
+}
+
+func (in *EngineInput) engine() *engines.Engine {
+	if in == nil {
+		return nil
+	}
+
+	e := &engines.Engine{Displacement: in.Displacement, NoOfCylinders: in.NoOfCylinders, CarRange: in.CarRange}
+	if in.ID != nil {
+		e.EngineID = *in.ID
+	}
+	return e
+}
+
+// CarRequest is the body of a create or a replace. It is validated as a
+// cars.CarRequest once converted.
+type CarRequest struct {
+	Name     string       `json:"name"`
+	Year     uint16       `json:"year"`
+	Brand    string       `json:"brand"`
+	FuelType string       `json:"fuelType"`
+	Engine   *EngineInput `json:"engine"`
+	Price    *Money       `json:"price"`
+	VIN      string       `json:"vin,omitempty"`
+}
+
+// NewCarRequest returns the form of req.
+func NewCarRequest(req *cars.CarRequest) *CarRequest {
+	r := &CarRequest{
+		Name:     req.Name,
+		Year:     req.Year,
+		Brand:    req.Brand,
+		FuelType: req.FuelType,
+		VIN:      req.VIN,
+	}
+	if req.Engine != nil {
+		if req.Engine.EngineID != uuid.Nil {
+			r.Engine = &EngineInput{ID: &req.Engine.EngineID}
+		} else {
+			r.Engine = &EngineInput{
+				Displacement:  req.Engine.Displacement,
+				NoOfCylinders: req.Engine.NoOfCylinders,
+				CarRange:      req.Engine.CarRange,
+			}
+		}
+	}
+	if req.Price != 0 {
+		price := NewMoney(req.Price)
+		r.Price = &price
+	}
+	return r
+}
+
+// request converts r, reporting its invalid fields under prefix.
+func (r *CarRequest) request(prefix string) (*cars.CarRequest, []apperrors.FieldError) {
+	req := &cars.CarRequest{
+		Name:     r.Name,
+		Year:     r.Year,
+		Brand:    r.Brand,
+		FuelType: r.FuelType,
+		Engine:   r.Engine.engine(),
+		VIN:      r.VIN,
+	}
+
+	var fields []apperrors.FieldError
+	if r.Price != nil {
+		req.Price, fields = r.Price.Value(prefix + "price")
+	}
+	return req, fields
+}
+
+type CarUpdate struct {
+	ID      uuid.UUID `json:"id"`
+	Version int64     `json:"version"`
+	CarRequest
+}
+
+type CarRef struct {
+	ID      uuid.UUID `json:"id"`
+	Version int64     `json:"version"`
+}
+
+type BulkCreateRequest struct {
+	Items []*CarRequest `json:"items"`
+}
+
+type BulkUpdateRequest struct {
+	Items []*CarUpdate `json:"items"`
+}
+
+type BulkDeleteRequest struct {
+	Items []*CarRef `json:"items"`
+}
+
+// Representation reads and renders cars in this form.
+var Representation cars.Representation = representation{}
+
+type representation struct{}
+
+func (representation) Car(car *cars.Car) any {
+	return NewCar(car)
+}
+
+func (representation) DecodeRequest(body []byte) (*cars.CarRequest, error) {
+	var r CarRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, apperrors.InvalidJSON(err)
+	}
+
+	req, fields := r.request("")
+	if len(fields) > 0 {
+		return nil, apperrors.Validation(nil, fields...)
+	}
+	return req, nil
+}
+
+func (representation) DecodeBulkCreate(body []byte) (*cars.BulkCreateRequest, error) {
+	var r BulkCreateRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, apperrors.InvalidJSON(err)
+	}
+
+	req := &cars.BulkCreateRequest{Items: make([]*cars.CarRequest, len(r.Items))}
+	var fields []apperrors.FieldError
+	for i, item := range r.Items {
+		if item == nil {
+			continue
+		}
+		var errs []apperrors.FieldError
+		req.Items[i], errs = item.request(itemPrefix(i))
+		fields = append(fields, errs...)
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation(nil, fields...)
+	}
+	return req, nil
+}
+
+func (representation) DecodeBulkUpdate(body []byte) (*cars.BulkUpdateRequest, error) {
+	var r BulkUpdateRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, apperrors.InvalidJSON(err)
+	}
+
+	req := &cars.BulkUpdateRequest{Items: make([]*cars.CarUpdate, len(r.Items))}
+	var fields []apperrors.FieldError
+	for i, item := range r.Items {
+		if item == nil {
+			continue
+		}
+		carReq, errs := item.request(itemPrefix(i))
+		req.Items[i] = &cars.CarUpdate{CarID: item.ID, Version: item.Version, CarRequest: *carReq}
+		fields = append(fields, errs...)
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation(nil, fields...)
+	}
+	return req, nil
+}
+
+func (representation) DecodeBulkDelete(body []byte) (*cars.BulkDeleteRequest, error) {
+	var r BulkDeleteRequest
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, apperrors.InvalidJSON(err)
+	}
+
+	req := &cars.BulkDeleteRequest{Items: make([]*cars.CarRef, len(r.Items))}
+	for i, item := range r.Items {
+		if item != nil {
+			req.Items[i] = &cars.CarRef{CarID: item.ID, Version: item.Version}
+		}
+	}
+	return req, nil
+}
+
+func itemPrefix(i int) string {
+	return fmt.Sprintf("items[%d].", i)
+}
+
+// Patch applies p to the CarRequest form of this version. Fields the form
+// does not know are passed through, for ApplyTo to report.
+func (representation) Patch(p *patch.Patch) *patch.Patch {
+	return p.Translate(toPatchDocument, fromPatchDocument)
+}
+
+func toPatchDocument(doc any) (any, error) {
+	var req cars.CarRequest
+	if err := convert(doc, &req); err != nil {
+		return nil, err
+	}
+
+	var out any
+	if err := convert(NewCarRequest(&req), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func fromPatchDocument(doc any) (any, error) {
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return doc, nil
+	}
+	out := maps.Clone(obj)
+
+	var fields []apperrors.FieldError
+	if price, ok := obj["price"]; ok && price != nil {
+		var m Money
+		if err := convert(price, &m); err != nil {
+			fields = append(fields, apperrors.FieldError{Field: "price", Message: "price must be an object with an amount and a currency"})
+		} else {
+			amount, errs := m.Value("price")
+			fields = append(fields, errs...)
+			out["price"] = amount
+		}
+	}
+	if engine, ok := obj["engine"]; ok && engine != nil {
+		var in EngineInput
+		if err := convert(engine, &in); err != nil {
+			fields = append(fields, apperrors.FieldError{Field: "engine", Message: "engine must be an object with an id or the specs of an engine"})
+		} else {
+			var e any
+			if err := convert(in.engine(), &e); err != nil {
+				return nil, err
+			}
+			out["engine"] = e
+		}
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation(nil, fields...)
+	}
+	return out, nil
+}
+
+// changeFields are the audited car fields whose name differs in this form.
+var changeFields = map[string]string{"engineId": "engine.id"}
+
+// Changes renames the engine ID after the nested engine and renders prices
+// as money objects.
+func (representation) Changes(changes []audit.Change) []audit.Change {
+	out := make([]audit.Change, len(changes))
+	for i, c := range changes {
+		if field, ok := changeFields[c.Field]; ok {
+			c.Field = field
+		}
+		if c.Field == "price" {
+			c.Before, c.After = money(c.Before), money(c.After)
+		}
+		out[i] = c
+	}
+
+	slices.SortStableFunc(out, func(a, b audit.Change) int { return strings.Compare(a.Field, b.Field) })
+	return out
+}
+
+// money renders an audited price, a JSON number, as a money object.
+func money(v any) any {
+	if amount, ok := v.(float64); ok {
+		return NewMoney(amount)
+	}
+	return v
+}
+
+// convert decodes the JSON form of v into out.
+func convert(v, out any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
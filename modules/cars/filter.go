@@ -0,0 +1,130 @@
+package cars
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/pagination"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// SortFields are the car fields a listing can be ordered by, keyed by their
+// JSON name.
+var SortFields = []string{"name", "year", "brand", "fuelType", "price", "createdAt", "updatedAt"}
+
+// CarFilter selects cars for a listing. Nil range bounds and empty slices
+// leave that criterion out.
+type CarFilter struct {
+	Brands    []string
+	FuelTypes []string
+	Name      string
+
+	YearMin  *uint16
+	YearMax  *uint16
+	PriceMin *float64
+	PriceMax *float64
+
+	EngineDisplacementMin *uint16
+	EngineDisplacementMax *uint16
+	EngineCylinders       *uint16
+	EngineCarRangeMin     *uint16
+	EngineCarRangeMax     *uint16
+
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+
+	// Trashed lists soft-deleted cars instead of live ones.
+	Trashed bool
+
+	// Cursor resumes a keyset listing. It carries its own sort order, which
+	// replaces Sort and Desc, and rules out Offset.
+	Cursor *pagination.Cursor
+}
+
+// Validate fills in paging defaults and rejects unknown sort fields and
+// inverted ranges.
+func (f *CarFilter) Validate() error {
+	var fields []apperrors.FieldError
+
+	if f.Cursor != nil {
+		if f.Offset != 0 {
+			fields = append(fields, apperrors.FieldError{Field: "offset", Message: "offset cannot be combined with cursor"})
+		}
+		f.Sort, f.Desc = f.Cursor.Sort, f.Cursor.Desc
+	}
+
+	if f.Sort == "" {
+		f.Sort = "createdAt"
+	}
+	if !slices.Contains(SortFields, f.Sort) {
+		fields = append(fields, apperrors.FieldError{
+			Field:   "sort",
+			Message: "sort must be one of " + strings.Join(SortFields, ", "),
+		})
+	}
+
+	if f.Limit == 0 {
+		f.Limit = DefaultListLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		fields = append(fields, apperrors.FieldError{Field: "limit", Message: "limit must be between 1 and 100"})
+	}
+	if f.Offset < 0 {
+		fields = append(fields, apperrors.FieldError{Field: "offset", Message: "offset cannot be negative"})
+	}
+
+	if f.YearMin != nil && f.YearMax != nil && *f.YearMin > *f.YearMax {
+		fields = append(fields, apperrors.FieldError{Field: "yearMin", Message: "yearMin cannot be greater than yearMax"})
+	}
+	if f.PriceMin != nil && f.PriceMax != nil && *f.PriceMin > *f.PriceMax {
+		fields = append(fields, apperrors.FieldError{Field: "priceMin", Message: "priceMin cannot be greater than priceMax"})
+	}
+	if f.EngineDisplacementMin != nil && f.EngineDisplacementMax != nil && *f.EngineDisplacementMin > *f.EngineDisplacementMax {
+		fields = append(fields, apperrors.FieldError{Field: "engineDisplacementMin", Message: "engineDisplacementMin cannot be greater than engineDisplacementMax"})
+	}
+	if f.EngineCarRangeMin != nil && f.EngineCarRangeMax != nil && *f.EngineCarRangeMin > *f.EngineCarRangeMax {
+		fields = append(fields, apperrors.FieldError{Field: "engineCarRangeMin", Message: "engineCarRangeMin cannot be greater than engineCarRangeMax"})
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation(nil, fields...)
+	}
+
+	if f.Cursor != nil {
+		if _, err := f.CursorValue(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CursorValue decodes the sort key of the cursor boundary row.
+func (f *CarFilter) CursorValue() (any, error) {
+	return f.Cursor.DecodeValue((&Car{}).SortValue(f.Sort))
+}
+
+// SortValue returns the value of the car field a listing is sorted by.
+func (c *Car) SortValue(field string) any {
+	switch field {
+	case "name":
+		return c.Name
+	case "year":
+		return c.Year
+	case "brand":
+		return c.Brand
+	case "fuelType":
+		return c.FuelType
+	case "price":
+		return c.Price
+	case "updatedAt":
+		return c.UpdatedAt
+	}
+	return c.CreatedAt
+}
@@ -2,105 +2,524 @@ package carhandlers
 
 import (
 	"context"
+	"mime"
 	"net/http"
 	"time"
 
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/httpquery"
+	"github.com/codepnw/go-car-management/middlewares"
 	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carexport"
 	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/codepnw/go-car-management/patch"
 	"github.com/gin-gonic/gin"
 )
 
+// bulkTimeout bounds a bulk request, which writes up to cars.MaxBulkItems
+// cars.
+const bulkTimeout = time.Minute
+
+// exportTimeout bounds an export, which streams the whole catalog.
+const exportTimeout = 10 * time.Minute
+
 type carHandler struct {
-	service carservices.ICarService
+	service        carservices.ICarService
+	cursors        *pagination.Signer
+	trashRetention time.Duration
+	// view is the form of cars in the API version the handler serves.
+	view cars.Representation
 }
 
-func NewCarHandler(service carservices.ICarService) *carHandler {
-	return &carHandler{service: service}
+func NewCarHandler(service carservices.ICarService, cursors *pagination.Signer, trashRetention time.Duration, view cars.Representation) *carHandler {
+	return &carHandler{service: service, cursors: cursors, trashRetention: trashRetention, view: view}
 }
 
 func (h *carHandler) GetCarByID(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	id := c.Param("id")
 
+	q := httpquery.New(c)
+	asOf := q.Time("asOf")
+	if err := q.Err(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	// A past state is not the current representation, so it gets no ETag.
+	if asOf != nil {
+		resp, err := h.service.GetCarAsOf(ctx, id, *asOf)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": h.view.Car(resp)})
+		return
+	}
+
 	resp, err := h.service.GetCarById(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": resp})
+	c.Header("ETag", etag.Format(resp.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Car(resp)})
 }
 
-func (h *carHandler) GetCarByBrand(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// DiffCar compares the car at two points in time.
+func (h *carHandler) DiffCar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	brand := c.Query("brand")
-	isEngine := c.Query("isEngine") == "true"
+	id := c.Param("id")
+
+	q := httpquery.New(c)
+	q.Require("from", "to")
+	from, to := q.Time("from"), q.Time("to")
+	if err := q.Err(); err != nil {
+		c.Error(err)
+		return
+	}
 
-	resp, err := h.service.GetCarByBrand(ctx, brand, isEngine)
+	changes, err := h.service.DiffCar(ctx, id, *from, *to)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": resp})
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"from": from, "to": to, "changes": h.view.Changes(changes)}})
 }
 
-func (h *carHandler) CreateCar(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (h *carHandler) ListCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	req := &cars.CarRequest{}
+	filter, err := h.parseCarFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	if err := c.ShouldBindJSON(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	page, err := h.service.ListCars(ctx, filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.renderCars(page.Items),
+		"meta": pagination.Meta(h.cursors, page, filter.Limit, filter.Offset),
+	})
+}
+
+// ListEngineCars lists the cars using the engine in the path, filtered and
+// paged like ListCars.
+func (h *carHandler) ListEngineCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	engineID := c.Param("id")
+
+	filter, err := h.parseCarFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	page, err := h.service.ListCarsByEngine(ctx, engineID, filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.renderCars(page.Items),
+		"meta": pagination.Meta(h.cursors, page, filter.Limit, filter.Offset),
+	})
+}
+
+// ExportCars streams every car selected by the listing filters as a file
+// download, in the format of the format query parameter.
+func (h *carHandler) ExportCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), exportTimeout)
+	defer cancel()
+
+	q := httpquery.New(c)
+	filter := carCriteria(q)
+	if err := q.Err(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	format, err := carexport.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The response starts with the first car, so that an error before it
+	// can still be reported as a problem.
+	var w carexport.Writer
+	start := func() error {
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": format.Filename(time.Now()),
+		}))
+		c.Status(http.StatusOK)
+
+		var err error
+		w, err = carexport.NewWriter(format, c.Writer, h.view)
+		return err
+	}
+
+	err = h.service.ExportCars(ctx, filter, func(car *cars.Car) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return w.Write(car)
+	})
+	if err == nil && w == nil {
+		err = start()
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		c.Error(err)
+		if c.Writer.Written() {
+			abortResponse(c)
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+	}
+}
+
+// abortResponse closes the connection of a response whose body is partly
+// sent and can no longer carry an error, so the client sees it cut short
+// rather than complete.
+func abortResponse(c *gin.Context) {
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		return
+	}
+	conn.Close()
+}
+
+func (h *carHandler) CreateCar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	req, err := decodeBody(c, h.view.DecodeRequest)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	createdCar, err := h.service.CreateCar(ctx, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"data": createdCar})
+	c.Header("ETag", etag.Format(createdCar.Version))
+	c.JSON(http.StatusCreated, gin.H{"data": h.view.Car(createdCar)})
 }
 
 func (h *carHandler) UpdateCar(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+	version, err := etag.IfMatch(c.Request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	req, err := decodeBody(c, h.view.DecodeRequest)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	updatedCar, err := h.service.UpdateCar(ctx, id, req, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(updatedCar.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Car(updatedCar)})
+}
+
+func (h *carHandler) PatchCar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	id := c.Param("id")
-	req := &cars.CarRequest{}
+	version, err := etag.IfMatch(c.Request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	if err := c.ShouldBindJSON(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	p, err := patch.Read(c.Request)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	updatedCar, err := h.service.UpdateCar(ctx, id, req)
+	patchedCar, err := h.service.PatchCar(ctx, id, h.view.Patch(p), version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": updatedCar})
+	c.Header("ETag", etag.Format(patchedCar.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Car(patchedCar)})
 }
 
 func (h *carHandler) DeleteCar(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	id := c.Param("id")
+	version, err := etag.IfMatch(c.Request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	deletedCar, err := h.service.DeleteCar(ctx, id, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	deletedCar, err := h.service.DeleteCar(ctx, id)
+	c.JSON(http.StatusNoContent, gin.H{"data": h.view.Car(deletedCar)})
+}
+
+func (h *carHandler) CreateCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bulkTimeout)
+	defer cancel()
+
+	mode, err := cars.ParseBulkMode(c.Query("mode"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusNoContent, gin.H{"data": deletedCar})
-}
\ No newline at end of file
+	req, err := decodeBody(c, h.view.DecodeBulkCreate)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results, err := h.service.CreateCars(ctx, req.Items, mode)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.bulkResponse(c, results, http.StatusCreated)
+}
+
+func (h *carHandler) UpdateCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bulkTimeout)
+	defer cancel()
+
+	mode, err := cars.ParseBulkMode(c.Query("mode"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	req, err := decodeBody(c, h.view.DecodeBulkUpdate)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results, err := h.service.UpdateCars(ctx, req.Items, mode)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.bulkResponse(c, results, http.StatusOK)
+}
+
+func (h *carHandler) DeleteCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), bulkTimeout)
+	defer cancel()
+
+	mode, err := cars.ParseBulkMode(c.Query("mode"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	req, err := decodeBody(c, h.view.DecodeBulkDelete)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	results, err := h.service.DeleteCars(ctx, req.Items, mode)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.bulkResponse(c, results, http.StatusOK)
+}
+
+// bulkResponse renders one result per item, with the status it would have
+// had as a single request and either the written car or the problem
+// document of its error. The response has status when every item succeeded
+// and 207 Multi-Status otherwise.
+func (h *carHandler) bulkResponse(c *gin.Context, results []*cars.BulkResult, status int) {
+	items := make([]gin.H, len(results))
+	failed := 0
+	for i, result := range results {
+		if result.Err != nil {
+			failed++
+			problem := middlewares.NewProblem(result.Err, "", "")
+			items[i] = gin.H{"index": i, "status": problem.Status, "error": problem}
+			continue
+		}
+		items[i] = gin.H{"index": i, "status": status, "data": h.view.Car(result.Car)}
+	}
+
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{
+		"data": items,
+		"meta": gin.H{"succeeded": len(results) - failed, "failed": failed},
+	})
+}
+
+func (h *carHandler) ListTrashedCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, err := h.parseCarFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter.Trashed = true
+
+	page, err := h.service.ListCars(ctx, filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.renderCars(page.Items),
+		"meta": pagination.Meta(h.cursors, page, filter.Limit, filter.Offset),
+	})
+}
+
+func (h *carHandler) RestoreCar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+
+	restoredCar, err := h.service.RestoreCar(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(restoredCar.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Car(restoredCar)})
+}
+
+// PurgeCars permanently deletes the cars that have outlived the trash
+// retention period.
+func (h *carHandler) PurgeCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	purged, err := h.service.PurgeCars(ctx, h.trashRetention)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"purged": purged}})
+}
+
+func (h *carHandler) renderCars(items []*cars.Car) []any {
+	out := make([]any, len(items))
+	for i, car := range items {
+		out[i] = h.view.Car(car)
+	}
+	return out
+}
+
+// decodeBody reads the request body with decode.
+func decodeBody[T any](c *gin.Context, decode func(body []byte) (T, error)) (T, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		var zero T
+		return zero, apperrors.BadRequest(err, "the request body could not be read")
+	}
+	return decode(body)
+}
+
+func (h *carHandler) parseCarFilter(c *gin.Context) (*cars.CarFilter, error) {
+	q := httpquery.New(c)
+
+	filter := carCriteria(q)
+	filter.Limit = q.Int("limit")
+	filter.Offset = q.Int("offset")
+
+	if err := q.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, err := h.cursors.Decode(q.String("cursor"))
+	if err != nil {
+		return nil, err
+	}
+	filter.Cursor = cursor
+
+	return filter, nil
+}
+
+// carCriteria reads the query parameters that select and order cars, which
+// listings and exports share.
+func carCriteria(q *httpquery.Parser) *cars.CarFilter {
+	filter := &cars.CarFilter{
+		Brands:    q.Strings("brand"),
+		FuelTypes: q.Strings("fuelType"),
+		Name:      q.String("name"),
+
+		YearMin:  q.Uint16("yearMin"),
+		YearMax:  q.Uint16("yearMax"),
+		PriceMin: q.Float("priceMin"),
+		PriceMax: q.Float("priceMax"),
+
+		EngineDisplacementMin: q.Uint16("engineDisplacementMin"),
+		EngineDisplacementMax: q.Uint16("engineDisplacementMax"),
+		EngineCylinders:       q.Uint16("engineCylinders"),
+		EngineCarRangeMin:     q.Uint16("engineCarRangeMin"),
+		EngineCarRangeMax:     q.Uint16("engineCarRangeMax"),
+	}
+	filter.Sort, filter.Desc = q.Sort("sort")
+
+	return filter
+}
@@ -0,0 +1,109 @@
+package carhandlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/httpquery"
+	"github.com/codepnw/go-car-management/modules/cars/carimport"
+	"github.com/gin-gonic/gin"
+)
+
+// importTimeout bounds an import. Files are streamed, so it rather than the
+// file size limits how many cars one request imports.
+const importTimeout = 10 * time.Minute
+
+const csvContentType = "text/csv"
+
+type importHandler struct {
+	importer *carimport.Importer
+}
+
+func NewImportHandler(importer *carimport.Importer) *importHandler {
+	return &importHandler{importer: importer}
+}
+
+// ImportCars imports the CSV file sent as the body, with Content-Type
+// text/csv, or as the "file" part of a multipart/form-data upload.
+func (h *importHandler) ImportCars(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), importTimeout)
+	defer cancel()
+
+	opts, err := importOptions(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	file, err := importFile(c.Request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report, err := h.importer.Import(ctx, file, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// importOptions reads the options of an import from the query string.
+func importOptions(c *gin.Context) (*carimport.Options, error) {
+	q := httpquery.New(c)
+	dryRun := q.Bool("dryRun")
+	mapping := q.String("mapping")
+	if err := q.Err(); err != nil {
+		return nil, err
+	}
+
+	opts := &carimport.Options{DryRun: dryRun}
+	var err error
+	if opts.Mapping, err = carimport.ParseMapping(mapping); err != nil {
+		return nil, err
+	}
+	if opts.Comma, err = carimport.ParseDelimiter(c.Query("delimiter")); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// importFile returns the file of an import request without buffering it.
+func importFile(r *http.Request) (io.Reader, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = r.Header.Get("Content-Type")
+	}
+
+	switch mediaType {
+	case csvContentType:
+		return r.Body, nil
+	case "multipart/form-data":
+	default:
+		return nil, apperrors.UnsupportedMediaType(mediaType, csvContentType, "multipart/form-data")
+	}
+
+	parts, err := r.MultipartReader()
+	if err != nil {
+		return nil, apperrors.BadRequest(err, "the request body is not a valid multipart form")
+	}
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, apperrors.Validation(nil, apperrors.FieldError{Field: "file", Message: "the upload has no file part"})
+		}
+		if err != nil {
+			return nil, apperrors.BadRequest(err, "the request body is not a valid multipart form")
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
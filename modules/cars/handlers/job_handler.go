@@ -0,0 +1,94 @@
+package carhandlers
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/codepnw/go-car-management/httpquery"
+	"github.com/codepnw/go-car-management/modules/cars/carexport"
+	"github.com/codepnw/go-car-management/modules/cars/carjobs"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	jobservices "github.com/codepnw/go-car-management/modules/jobs/services"
+	"github.com/gin-gonic/gin"
+)
+
+// jobHandler submits car exports and imports as background jobs, which
+// are then followed at the jobs endpoints.
+type jobHandler struct {
+	service jobservices.IJobService
+	// version is the API version jobs are submitted to.
+	version string
+}
+
+func NewJobHandler(service jobservices.IJobService, version string) *jobHandler {
+	return &jobHandler{service: service, version: version}
+}
+
+// SubmitExport queues an export taking the same query as ExportCars.
+func (h *jobHandler) SubmitExport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	q := httpquery.New(c)
+	filter := carCriteria(q)
+	if err := q.Err(); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	format, err := carexport.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	params := &carjobs.ExportParams{Format: format, Filter: *filter, Version: h.version}
+	job, err := h.service.SubmitJob(ctx, jobs.KindExport, params, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	accepted(c, job)
+}
+
+// SubmitImport queues an import taking the same request as ImportCars. The
+// file is stored with the job before the response is sent.
+func (h *jobHandler) SubmitImport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), importTimeout)
+	defer cancel()
+
+	opts, err := importOptions(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	file, err := importFile(c.Request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	params := &carjobs.ImportParams{Mapping: opts.Mapping, Comma: opts.Comma, DryRun: opts.DryRun}
+	job, err := h.service.SubmitJob(ctx, jobs.KindImport, params, file)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	accepted(c, job)
+}
+
+// accepted responds with a queued job and where to poll it, next to the
+// route it was submitted to.
+func accepted(c *gin.Context, job *jobs.Job) {
+	c.Header("Location", path.Join(path.Dir(c.FullPath()), job.JobID.String()))
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
@@ -0,0 +1,73 @@
+package cars
+
+import (
+	"slices"
+
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/google/uuid"
+)
+
+// CarPatch holds the columns a partial update writes. Nil fields are left
+// as they are.
+type CarPatch struct {
+	Name     *string
+	Year     *uint16
+	Brand    *string
+	FuelType *string
+	EngineID *uuid.UUID
+	Price    *float64
+	VIN      *string
+}
+
+// Request returns the car as the request that would recreate it. PATCH
+// bodies are applied to this form.
+func (c *Car) Request() *CarRequest {
+	req := &CarRequest{
+		Name:     c.Name,
+		Year:     c.Year,
+		Brand:    c.Brand,
+		FuelType: c.FuelType,
+		Price:    c.Price,
+		VIN:      c.VIN,
+	}
+	if c.Engine != nil {
+		req.Engine = &engines.Engine{EngineID: c.Engine.EngineID}
+	}
+	return req
+}
+
+// NewCarPatch takes the fields of req listed in changed, by JSON name.
+func NewCarPatch(req *CarRequest, changed []string) *CarPatch {
+	p := &CarPatch{}
+	for _, field := range changed {
+		switch field {
+		case "name":
+			p.Name = &req.Name
+		case "year":
+			p.Year = &req.Year
+		case "brand":
+			p.Brand = &req.Brand
+		case "fuelType":
+			p.FuelType = &req.FuelType
+		case "engine":
+			if req.Engine != nil {
+				p.EngineID = &req.Engine.EngineID
+			}
+		case "price":
+			p.Price = &req.Price
+		case "vin":
+			p.VIN = &req.VIN
+		}
+	}
+	return p
+}
+
+// PatchValidationFields returns the fields to validate when changed were
+// modified: the changed fields plus those whose rules read one of them.
+func PatchValidationFields(changed []string) []string {
+	fields := slices.Clone(changed)
+	if slices.Contains(changed, "engine") && !slices.Contains(fields, "fuelType") {
+		fields = append(fields, "fuelType")
+	}
+	return fields
+}
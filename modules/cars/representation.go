@@ -0,0 +1,71 @@
+package cars
+
+import (
+	"encoding/json"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/patch"
+)
+
+// Representation is the JSON form of cars in one version of the API. The
+// handlers read and render cars through it, so versions share everything
+// but the shape of their bodies.
+type Representation interface {
+	// Car returns the form of car to render.
+	Car(car *Car) any
+
+	// DecodeRequest reads the body of a create or a replace.
+	DecodeRequest(body []byte) (*CarRequest, error)
+	DecodeBulkCreate(body []byte) (*BulkCreateRequest, error)
+	DecodeBulkUpdate(body []byte) (*BulkUpdateRequest, error)
+	DecodeBulkDelete(body []byte) (*BulkDeleteRequest, error)
+
+	// Patch returns p, written for this form, as a patch of CarRequest.
+	Patch(p *patch.Patch) *patch.Patch
+
+	// Changes returns changes to a car, as the audit log and DiffCar name
+	// them, in this form. changes is left unchanged.
+	Changes(changes []audit.Change) []audit.Change
+}
+
+// V1 is the form of cars in version 1 of the API: the Go types as they are.
+var V1 Representation = v1{}
+
+type v1 struct{}
+
+func (v1) Car(car *Car) any {
+	return car
+}
+
+func (v1) DecodeRequest(body []byte) (*CarRequest, error) {
+	return decodeV1[CarRequest](body)
+}
+
+func (v1) DecodeBulkCreate(body []byte) (*BulkCreateRequest, error) {
+	return decodeV1[BulkCreateRequest](body)
+}
+
+func (v1) DecodeBulkUpdate(body []byte) (*BulkUpdateRequest, error) {
+	return decodeV1[BulkUpdateRequest](body)
+}
+
+func (v1) DecodeBulkDelete(body []byte) (*BulkDeleteRequest, error) {
+	return decodeV1[BulkDeleteRequest](body)
+}
+
+func (v1) Patch(p *patch.Patch) *patch.Patch {
+	return p
+}
+
+func (v1) Changes(changes []audit.Change) []audit.Change {
+	return changes
+}
+
+func decodeV1[T any](body []byte) (*T, error) {
+	v := new(T)
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, apperrors.InvalidJSON(err)
+	}
+	return v, nil
+}
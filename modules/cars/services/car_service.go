@@ -2,28 +2,66 @@ package carservices
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
 	"github.com/codepnw/go-car-management/modules/cars"
 	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
-	"github.com/go-playground/validator/v10"
+	"github.com/codepnw/go-car-management/modules/engines"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/codepnw/go-car-management/patch"
+	"github.com/codepnw/go-car-management/validation"
+	"github.com/google/uuid"
 )
 
 type ICarService interface {
-	GetCarById(ctx context.Context, id string) (*cars.Car, error) 
-	GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]*cars.Car, error) 
-	CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error) 
-	UpdateCar(ctx context.Context, id string, req *cars.CarRequest) (*cars.Car, error)
-	DeleteCar(ctx context.Context, id string) (*cars.Car, error)
+	GetCarById(ctx context.Context, id string) (*cars.Car, error)
+	// GetCarAsOf rebuilds the car, including its engine, as it was at the
+	// given time from the audit log.
+	GetCarAsOf(ctx context.Context, id string, at time.Time) (*cars.Car, error)
+	// DiffCar lists the fields of the car, and of its engine under
+	// "engine.", that differ between two points in time.
+	DiffCar(ctx context.Context, id string, from, to time.Time) ([]audit.Change, error)
+	CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error)
+	// ValidateCar runs the checks of CreateCar on req without writing
+	// anything. Conflicts with stored cars, such as a taken VIN, are only
+	// found by the write itself.
+	ValidateCar(ctx context.Context, req *cars.CarRequest) error
+	UpdateCar(ctx context.Context, id string, req *cars.CarRequest, version int64) (*cars.Car, error)
+	PatchCar(ctx context.Context, id string, p *patch.Patch, version int64) (*cars.Car, error)
+	DeleteCar(ctx context.Context, id string, version int64) (*cars.Car, error)
+	RestoreCar(ctx context.Context, id string) (*cars.Car, error)
+	// CreateCars, UpdateCars and DeleteCars validate every item like their
+	// single-car counterparts, then write the valid ones together. They
+	// return one result per item, in request order; in atomic mode either
+	// every item is written or none is.
+	CreateCars(ctx context.Context, reqs []*cars.CarRequest, mode cars.BulkMode) ([]*cars.BulkResult, error)
+	UpdateCars(ctx context.Context, updates []*cars.CarUpdate, mode cars.BulkMode) ([]*cars.BulkResult, error)
+	DeleteCars(ctx context.Context, refs []*cars.CarRef, mode cars.BulkMode) ([]*cars.BulkResult, error)
+	PurgeCars(ctx context.Context, retention time.Duration) (int, error)
+	ListCars(ctx context.Context, filter *cars.CarFilter) (*pagination.Page[*cars.Car], error)
+	// ListCarsByEngine lists the cars using a live engine.
+	ListCarsByEngine(ctx context.Context, engineID string, filter *cars.CarFilter) (*pagination.Page[*cars.Car], error)
+	// ExportCars calls each for every car filter selects, unpaged, in
+	// listing order.
+	ExportCars(ctx context.Context, filter *cars.CarFilter, each func(*cars.Car) error) error
 }
 
 type carService struct {
-	repo carrepositories.ICarRepository
+	repo       carrepositories.ICarRepository
+	engineRepo engrepositories.IEngineRepository
+	auditRepo  auditrepositories.IAuditRepository
+	tx         database.Transactor
 }
 
-var validate *validator.Validate
-
-func NewCarService(repo carrepositories.ICarRepository) ICarService {
-	return &carService{repo: repo}
+func NewCarService(repo carrepositories.ICarRepository, engineRepo engrepositories.IEngineRepository, auditRepo auditrepositories.IAuditRepository, tx database.Transactor) ICarService {
+	return &carService{repo: repo, engineRepo: engineRepo, auditRepo: auditRepo, tx: tx}
 }
 
 func (s *carService) GetCarById(ctx context.Context, id string) (*cars.Car, error) {
@@ -34,50 +72,495 @@ func (s *carService) GetCarById(ctx context.Context, id string) (*cars.Car, erro
 	return car, nil
 }
 
-func (s *carService) GetCarByBrand(ctx context.Context, brand string, isEngine bool) ([]*cars.Car, error) {
-	results, err := s.repo.GetCarByBrand(ctx, brand, isEngine)
+func (s *carService) GetCarAsOf(ctx context.Context, id string, at time.Time) (*cars.Car, error) {
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperrors.InvalidID("car", err)
+	}
+
+	car, err := s.carAsOf(ctx, carID, at)
 	if err != nil {
 		return nil, err
 	}
-	return results, nil
+	if car == nil {
+		return nil, apperrors.NotFound("no recorded state of car %s at %s", carID, at.Format(time.RFC3339))
+	}
+	return car, nil
 }
 
-func (s *carService) CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error) {
-	err := validate.Struct(req)
+func (s *carService) DiffCar(ctx context.Context, id string, from, to time.Time) ([]audit.Change, error) {
+	carID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperrors.InvalidID("car", err)
+	}
+
+	before, err := s.carAsOf(ctx, carID, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.carAsOf(ctx, carID, to)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil && after == nil {
+		return nil, apperrors.NotFound("no recorded state of car %s between %s and %s", carID, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	return audit.Diff(diffFields(before), diffFields(after)), nil
+}
+
+// carAsOf replays the history of the car, then of the engine it had, up to
+// at. It returns nil when nothing is recorded for the car at that time.
+func (s *carService) carAsOf(ctx context.Context, carID uuid.UUID, at time.Time) (*cars.Car, error) {
+	entries, err := s.auditRepo.ListHistory(ctx, audit.EntityCar, carID)
+	if err != nil {
+		return nil, err
+	}
+	snap := audit.Replay(entries, at)
+	if snap == nil {
+		return nil, nil
+	}
+
+	car := &cars.Car{CarID: carID, CreatedAt: snap.CreatedAt, UpdatedAt: snap.UpdatedAt, Version: snap.Version}
+	if err := car.SetAuditFields(snap.Fields); err != nil {
+		return nil, err
+	}
+	if car.Engine == nil {
+		return car, nil
+	}
+
+	entries, err = s.auditRepo.ListHistory(ctx, audit.EntityEngine, car.Engine.EngineID)
 	if err != nil {
-		if _, ok := err.(*validator.InvalidValidationError); ok {
+		return nil, err
+	}
+	// An engine without recorded state at that time is left as a bare ID.
+	if snap := audit.Replay(entries, at); snap != nil {
+		car.Engine.Version = snap.Version
+		if err := car.Engine.SetAuditFields(snap.Fields); err != nil {
 			return nil, err
 		}
-		return nil, err
 	}
+	return car, nil
+}
+
+func diffFields(car *cars.Car) map[string]any {
+	fields := car.AuditFields()
+	if car != nil && car.Engine != nil {
+		for k, v := range car.Engine.AuditFields() {
+			fields["engine."+k] = v
+		}
+	}
+	return fields
+}
 
-	createdCar, err := s.repo.CreateCar(ctx, req)
+// CreateCar creates the car with an existing engine, or together with a new
+// engine when the request gives an engine spec without an engineId.
+func (s *carService) CreateCar(ctx context.Context, req *cars.CarRequest) (*cars.Car, error) {
+	spec := req.NewEngine()
+	if spec != nil {
+		// The fuel type is then checked against the spec itself.
+		if err := validation.Struct(&cars.NewEngineRequest{Engine: spec}); err != nil {
+			return nil, err
+		}
+		if err := validation.Struct(req); err != nil {
+			return nil, err
+		}
+	}
+
+	var createdCar *cars.Car
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Work on a copy: the transaction may run again after a conflict.
+		carReq := *req
+		if spec != nil {
+			engine, err := s.engineRepo.CreateEngine(ctx, spec)
+			if err != nil {
+				return err
+			}
+			carReq.Engine = engine
+		} else if err := s.validateRequest(ctx, &carReq); err != nil {
+			return err
+		}
+
+		var err error
+		createdCar, err = s.repo.CreateCar(ctx, &carReq)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return createdCar, nil
 }
 
-func (s *carService) UpdateCar(ctx context.Context, id string, req *cars.CarRequest) (*cars.Car, error) {
-	err := validate.Struct(req)
-	if err != nil {
-		if _, ok := err.(*validator.InvalidValidationError); ok {
+func (s *carService) ValidateCar(ctx context.Context, req *cars.CarRequest) error {
+	_, err := checkNewCar(ctx, req, s.loadEngine)
+	return err
+}
+
+// checkNewCar validates a car to create as CreateCar does and returns a copy
+// of req with its engine loaded by loadEngine, unless it is a new engine.
+func checkNewCar(ctx context.Context, req *cars.CarRequest, loadEngine func(ctx context.Context, req *cars.CarRequest) error) (*cars.CarRequest, error) {
+	carReq := *req
+	if spec := carReq.NewEngine(); spec != nil {
+		if err := validation.Struct(&cars.NewEngineRequest{Engine: spec}); err != nil {
 			return nil, err
 		}
+	} else if err := loadEngine(ctx, &carReq); err != nil {
 		return nil, err
 	}
 
-	updatedCar, err := s.repo.UpdateCar(ctx, id, req)
+	if err := validation.Struct(&carReq); err != nil {
+		return nil, err
+	}
+	return &carReq, nil
+}
+
+func (s *carService) UpdateCar(ctx context.Context, id string, req *cars.CarRequest, version int64) (*cars.Car, error) {
+	var updatedCar *cars.Car
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		carReq := *req
+		if err := s.validateRequest(ctx, &carReq); err != nil {
+			return err
+		}
+
+		var err error
+		updatedCar, err = s.repo.UpdateCar(ctx, id, &carReq, version)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return updatedCar, nil
 }
 
-func (s *carService) DeleteCar(ctx context.Context, id string) (*cars.Car, error) {
-	deletedCar, err := s.repo.DeleteCar(ctx, id)
+// PatchCar applies p to the current car. Only the fields the patch changes,
+// and the rules that depend on them, are validated and written.
+func (s *carService) PatchCar(ctx context.Context, id string, p *patch.Patch, version int64) (*cars.Car, error) {
+	var patchedCar *cars.Car
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetCarById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if version != etag.Any && current.Version != version {
+			return cars.ErrCarModified
+		}
+
+		req := current.Request()
+		changed, err := p.ApplyTo(req)
+		if err != nil {
+			return err
+		}
+		if len(changed) == 0 {
+			patchedCar = current
+			return nil
+		}
+
+		if err := s.loadEngine(ctx, req); err != nil {
+			return err
+		}
+		if err := validation.StructPartial(req, cars.PatchValidationFields(changed)...); err != nil {
+			return err
+		}
+
+		patchedCar, err = s.repo.PatchCar(ctx, id, cars.NewCarPatch(req, changed), current.Version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return patchedCar, nil
+}
+
+func (s *carService) DeleteCar(ctx context.Context, id string, version int64) (*cars.Car, error) {
+	deletedCar, err := s.repo.DeleteCar(ctx, id, version)
 	if err != nil {
 		return nil, err
 	}
 	return deletedCar, nil
-}
\ No newline at end of file
+}
+
+func (s *carService) RestoreCar(ctx context.Context, id string) (*cars.Car, error) {
+	restoredCar, err := s.repo.RestoreCar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return restoredCar, nil
+}
+
+func (s *carService) CreateCars(ctx context.Context, reqs []*cars.CarRequest, mode cars.BulkMode) ([]*cars.BulkResult, error) {
+	loadEngine := s.engineLoader()
+	prepared := make([]*cars.CarRequest, len(reqs))
+
+	validate := func(ctx context.Context, i int) error {
+		if reqs[i] == nil {
+			return errNullItem
+		}
+
+		req, err := checkNewCar(ctx, reqs[i], loadEngine)
+		if err != nil {
+			return err
+		}
+		prepared[i] = req
+		return nil
+	}
+
+	write := func(ctx context.Context, items []int) ([]*cars.Car, error) {
+		batch := make([]*cars.CarRequest, len(items))
+		for j, i := range items {
+			// A copy again, as a rejected batch is written once more.
+			req := *prepared[i]
+			if spec := req.NewEngine(); spec != nil {
+				engine, err := s.engineRepo.CreateEngine(ctx, spec)
+				if err != nil {
+					return nil, err
+				}
+				req.Engine = engine
+			}
+			batch[j] = &req
+		}
+		return s.repo.CreateCars(ctx, batch)
+	}
+
+	return s.runBulk(ctx, len(reqs), mode, validate, write)
+}
+
+func (s *carService) UpdateCars(ctx context.Context, updates []*cars.CarUpdate, mode cars.BulkMode) ([]*cars.BulkResult, error) {
+	loadEngine := s.engineLoader()
+	seen := make(map[uuid.UUID]bool)
+	prepared := make([]*cars.CarUpdate, len(updates))
+
+	validate := func(ctx context.Context, i int) error {
+		if updates[i] == nil {
+			return errNullItem
+		}
+		u := *updates[i]
+		if err := checkCarRef(seen, u.CarID, u.Version); err != nil {
+			return err
+		}
+		if err := loadEngine(ctx, &u.CarRequest); err != nil {
+			return err
+		}
+		if err := validation.Struct(&u.CarRequest); err != nil {
+			return err
+		}
+
+		prepared[i] = &u
+		return nil
+	}
+
+	write := func(ctx context.Context, items []int) ([]*cars.Car, error) {
+		batch := make([]*cars.CarUpdate, len(items))
+		for j, i := range items {
+			batch[j] = prepared[i]
+		}
+		return s.repo.UpdateCars(ctx, batch)
+	}
+
+	return s.runBulk(ctx, len(updates), mode, validate, write)
+}
+
+func (s *carService) DeleteCars(ctx context.Context, refs []*cars.CarRef, mode cars.BulkMode) ([]*cars.BulkResult, error) {
+	seen := make(map[uuid.UUID]bool)
+
+	validate := func(ctx context.Context, i int) error {
+		if refs[i] == nil {
+			return errNullItem
+		}
+		return checkCarRef(seen, refs[i].CarID, refs[i].Version)
+	}
+
+	write := func(ctx context.Context, items []int) ([]*cars.Car, error) {
+		batch := make([]*cars.CarRef, len(items))
+		for j, i := range items {
+			batch[j] = refs[i]
+		}
+		return s.repo.DeleteCars(ctx, batch)
+	}
+
+	return s.runBulk(ctx, len(refs), mode, validate, write)
+}
+
+// runBulk validates each of the n items of a bulk request, then writes the
+// valid ones in one transaction. When the write rejects some items, partial
+// mode drops them and writes the others again, while atomic mode gives up.
+// validate and write report the failure of an item with an *apperrors.Error;
+// any other error fails the whole request.
+func (s *carService) runBulk(
+	ctx context.Context,
+	n int,
+	mode cars.BulkMode,
+	validate func(ctx context.Context, i int) error,
+	write func(ctx context.Context, items []int) ([]*cars.Car, error),
+) ([]*cars.BulkResult, error) {
+	if err := cars.CheckBulkSize(n); err != nil {
+		return nil, err
+	}
+
+	results := make([]*cars.BulkResult, n)
+	pending := make([]int, 0, n)
+	for i := range n {
+		results[i] = &cars.BulkResult{}
+		if err := validate(ctx, i); err != nil {
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) {
+				return nil, err
+			}
+			results[i].Err = err
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	for len(pending) > 0 && (mode == cars.BulkPartial || len(pending) == n) {
+		var written []*cars.Car
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			written, err = write(ctx, pending)
+			return err
+		})
+
+		var itemErrs cars.ItemErrors
+		if errors.As(err, &itemErrs) {
+			rest := pending[:0:0]
+			for j, i := range pending {
+				if itemErr, ok := itemErrs[j]; ok {
+					results[i].Err = itemErr
+				} else {
+					rest = append(rest, i)
+				}
+			}
+			pending = rest
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for j, i := range pending {
+			results[i].Car = written[j]
+		}
+		pending = nil
+	}
+
+	// Only an atomic request that failed leaves items behind.
+	for _, i := range pending {
+		results[i].Err = cars.ErrNotApplied
+	}
+	return results, nil
+}
+
+var errNullItem = apperrors.Validation(nil, apperrors.FieldError{Field: "items", Message: "item must be an object"})
+
+// checkCarRef checks the car ID and version of a bulk update or delete item
+// and that no earlier item names the same car.
+func checkCarRef(seen map[uuid.UUID]bool, carID uuid.UUID, version int64) error {
+	switch {
+	case carID == uuid.Nil:
+		return apperrors.Validation(nil, apperrors.FieldError{Field: "carId", Message: "carId is required"})
+	case seen[carID]:
+		return apperrors.Validation(nil, apperrors.FieldError{Field: "carId", Message: "car appears more than once in the request"})
+	case version <= 0:
+		return apperrors.PreconditionRequired("version is required, send the version the car was read at")
+	}
+	seen[carID] = true
+	return nil
+}
+
+// engineLoader returns a loadEngine that reads each engine once, for the
+// many items of a bulk request that usually share a few engines.
+func (s *carService) engineLoader() func(ctx context.Context, req *cars.CarRequest) error {
+	loaded := make(map[uuid.UUID]*engines.Engine)
+
+	return func(ctx context.Context, req *cars.CarRequest) error {
+		if req.Engine == nil {
+			return nil
+		}
+		if engine, ok := loaded[req.Engine.EngineID]; ok {
+			req.Engine = engine
+			return nil
+		}
+
+		if err := s.loadEngine(ctx, req); err != nil {
+			return err
+		}
+		loaded[req.Engine.EngineID] = req.Engine
+		return nil
+	}
+}
+
+// PurgeCars permanently deletes the cars that have been in the trash for
+// longer than retention.
+func (s *carService) PurgeCars(ctx context.Context, retention time.Duration) (int, error) {
+	return s.repo.PurgeCars(ctx, time.Now().Add(-retention))
+}
+
+func (s *carService) ListCars(ctx context.Context, filter *cars.CarFilter) (*pagination.Page[*cars.Car], error) {
+	return listCarPage(filter, func(query *cars.CarFilter) ([]*cars.Car, int, error) {
+		return s.repo.ListCars(ctx, query)
+	})
+}
+
+func (s *carService) ListCarsByEngine(ctx context.Context, engineID string, filter *cars.CarFilter) (*pagination.Page[*cars.Car], error) {
+	engine, err := s.engineRepo.GetEngineByID(ctx, engineID)
+	if err != nil {
+		return nil, err
+	}
+
+	return listCarPage(filter, func(query *cars.CarFilter) ([]*cars.Car, int, error) {
+		return s.repo.ListCarsByEngine(ctx, engine.EngineID, query)
+	})
+}
+
+func (s *carService) ExportCars(ctx context.Context, filter *cars.CarFilter, each func(*cars.Car) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	return s.repo.ExportCars(ctx, filter, each)
+}
+
+func listCarPage(filter *cars.CarFilter, list func(query *cars.CarFilter) ([]*cars.Car, int, error)) (*pagination.Page[*cars.Car], error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Ask for one extra row to learn whether another page follows.
+	query := *filter
+	query.Limit++
+
+	results, total, err := list(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(results, total, filter.Limit, filter.Offset, filter.Sort, filter.Desc, filter.Cursor,
+		func(car *cars.Car) (any, uuid.UUID) {
+			return car.SortValue(filter.Sort), car.CarID
+		},
+	), nil
+}
+
+// validateRequest loads the referenced engine into req so the fuel type can be
+// checked against its real specs, then validates the request.
+func (s *carService) validateRequest(ctx context.Context, req *cars.CarRequest) error {
+	if err := s.loadEngine(ctx, req); err != nil {
+		return err
+	}
+
+	return validation.Struct(req)
+}
+
+func (s *carService) loadEngine(ctx context.Context, req *cars.CarRequest) error {
+	if req.Engine == nil {
+		return nil
+	}
+
+	engine, err := s.engineRepo.GetEngineByID(ctx, req.Engine.EngineID.String())
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return cars.ErrEngineNotExists
+		}
+		return err
+	}
+	req.Engine = engine
+	return nil
+}
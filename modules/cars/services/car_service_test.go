@@ -0,0 +1,422 @@
+package carservices
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	"github.com/codepnw/go-car-management/modules/engines"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/codepnw/go-car-management/patch"
+	"github.com/google/uuid"
+)
+
+func newTestService(t *testing.T) (ICarService, engrepositories.IEngineRepository) {
+	t.Helper()
+
+	db := memdb.New()
+	engineRepo := engrepositories.NewEngineMemoryRepository(db)
+	return NewCarService(carrepositories.NewCarMemoryRepository(db), engineRepo, auditrepositories.NewAuditMemoryRepository(db), db), engineRepo
+}
+
+func TestCreateCarValidatesAgainstEngine(t *testing.T) {
+	ctx := context.Background()
+	service, engineRepo := newTestService(t)
+
+	electric, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{CarRange: 500})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+
+	req := func(fuelType string, engineID uuid.UUID) *cars.CarRequest {
+		return &cars.CarRequest{
+			Name:     "Model 3",
+			Year:     2023,
+			Brand:    "Tesla",
+			FuelType: fuelType,
+			Engine:   &engines.Engine{EngineID: engineID},
+			Price:    42000,
+		}
+	}
+
+	created, err := service.CreateCar(ctx, req("Electric", electric.EngineID))
+	if err != nil {
+		t.Fatalf("CreateCar(Electric): %v", err)
+	}
+	if created.Engine == nil || created.Engine.EngineID != electric.EngineID {
+		t.Fatalf("CreateCar engine = %+v", created.Engine)
+	}
+
+	var appErr *apperrors.Error
+
+	_, err = service.CreateCar(ctx, req("Petrol", electric.EngineID))
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation || appErr.Fields[0].Field != "fuelType" {
+		t.Fatalf("CreateCar(Petrol, electric engine) error = %v, want a fuelType validation error", err)
+	}
+
+	_, err = service.CreateCar(ctx, req("Electric", uuid.New()))
+	if !errors.Is(err, cars.ErrEngineNotExists) {
+		t.Fatalf("CreateCar with a missing engine error = %v, want %v", err, cars.ErrEngineNotExists)
+	}
+
+	// A new engine spec is validated itself, then checked like a stored one.
+	withSpec := func(fuelType string, spec engines.Engine) *cars.CarRequest {
+		r := req(fuelType, uuid.Nil)
+		r.Engine = &spec
+		return r
+	}
+
+	created, err = service.CreateCar(ctx, withSpec("Electric", engines.Engine{CarRange: 450}))
+	if err != nil {
+		t.Fatalf("CreateCar(new engine): %v", err)
+	}
+	if created.Engine == nil || created.Engine.EngineID == uuid.Nil || created.Engine.CarRange != 450 {
+		t.Fatalf("CreateCar(new engine) engine = %+v", created.Engine)
+	}
+
+	// The new engine is rolled back with a car that cannot be created.
+	duplicate := withSpec("Electric", engines.Engine{CarRange: 450})
+	duplicate.VIN = "1HGCM82633A004352"
+	if _, err := service.CreateCar(ctx, duplicate); err != nil {
+		t.Fatalf("CreateCar(new engine, vin): %v", err)
+	}
+	engineCount := func() int {
+		_, total, err := engineRepo.ListEngines(ctx, &engines.EngineFilter{Sort: "carRange", Limit: 100})
+		if err != nil {
+			t.Fatalf("ListEngines: %v", err)
+		}
+		return total
+	}
+	before := engineCount()
+	if _, err := service.CreateCar(ctx, duplicate); !errors.Is(err, apperrors.ErrConflict) {
+		t.Fatalf("CreateCar(duplicate vin) error = %v, want a conflict", err)
+	}
+	if after := engineCount(); after != before {
+		t.Fatalf("engines after a failed create = %d, want %d", after, before)
+	}
+
+	_, err = service.CreateCar(ctx, withSpec("Electric", engines.Engine{}))
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation || appErr.Fields[0].Field != "engine.carRange" {
+		t.Fatalf("CreateCar(engine without range) error = %v, want an engine.carRange validation error", err)
+	}
+
+	_, err = service.CreateCar(ctx, withSpec("Petrol", engines.Engine{CarRange: 450}))
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation || appErr.Fields[0].Field != "fuelType" {
+		t.Fatalf("CreateCar(Petrol, new electric engine) error = %v, want a fuelType validation error", err)
+	}
+}
+
+func TestPatchCar(t *testing.T) {
+	ctx := context.Background()
+	service, engineRepo := newTestService(t)
+
+	petrol, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	electric, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{CarRange: 500})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+
+	created, err := service.CreateCar(ctx, &cars.CarRequest{
+		Name:     "Civic",
+		Year:     2020,
+		Brand:    "Honda",
+		FuelType: "Petrol",
+		Engine:   &engines.Engine{EngineID: petrol.EngineID},
+		Price:    25000,
+		VIN:      "1HGCM82633A004352",
+	})
+	if err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+	id := created.CarID.String()
+
+	mergePatch := func(body string) *patch.Patch {
+		p, err := patch.NewMergePatch([]byte(body))
+		if err != nil {
+			t.Fatalf("NewMergePatch: %v", err)
+		}
+		return p
+	}
+
+	patched, err := service.PatchCar(ctx, id, mergePatch(`{"price": 23500, "vin": null}`), etag.Any)
+	if err != nil {
+		t.Fatalf("PatchCar: %v", err)
+	}
+	if patched.Price != 23500 || patched.VIN != "" || patched.Name != "Civic" || patched.Engine.EngineID != petrol.EngineID {
+		t.Fatalf("PatchCar = %+v, want only price and vin changed", patched)
+	}
+
+	jsonPatch, err := patch.NewJSONPatch([]byte(`[
+		{"op": "test", "path": "/price", "value": 23500},
+		{"op": "replace", "path": "/name", "value": "Civic Si"}
+	]`))
+	if err != nil {
+		t.Fatalf("NewJSONPatch: %v", err)
+	}
+	if patched, err = service.PatchCar(ctx, id, jsonPatch, etag.Any); err != nil || patched.Name != "Civic Si" {
+		t.Fatalf("PatchCar(json patch) = %+v, %v", patched, err)
+	}
+
+	var appErr *apperrors.Error
+
+	// Switching to an electric engine re-checks the unchanged fuel type.
+	_, err = service.PatchCar(ctx, id, mergePatch(`{"engine": {"engineId": "`+electric.EngineID.String()+`"}}`), etag.Any)
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation || appErr.Fields[0].Field != "fuelType" {
+		t.Fatalf("PatchCar(electric engine) error = %v, want a fuelType validation error", err)
+	}
+
+	_, err = service.PatchCar(ctx, id, mergePatch(`{"engine": {"engineId": "`+electric.EngineID.String()+`"}, "fuelType": "Electric"}`), etag.Any)
+	if err != nil {
+		t.Fatalf("PatchCar(engine and fuel type): %v", err)
+	}
+
+	_, err = service.PatchCar(ctx, id, mergePatch(`{"name": null}`), etag.Any)
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation || appErr.Fields[0].Field != "name" {
+		t.Fatalf("PatchCar removing name error = %v, want a name validation error", err)
+	}
+
+	// A stale version fails even when the patch would change nothing.
+	_, err = service.PatchCar(ctx, id, mergePatch(`{}`), 1)
+	if !errors.Is(err, apperrors.ErrPreconditionFailed) {
+		t.Fatalf("PatchCar(stale version) error = %v, want precondition failed", err)
+	}
+
+	got, err := service.GetCarById(ctx, id)
+	if err != nil {
+		t.Fatalf("GetCarById: %v", err)
+	}
+	if got.Name != "Civic Si" || got.FuelType != "Electric" || got.Price != 23500 {
+		t.Fatalf("stored car = %+v", got)
+	}
+}
+
+func TestGetCarAsOf(t *testing.T) {
+	ctx := context.Background()
+	service, engineRepo := newTestService(t)
+
+	// tick returns a time strictly between the changes around it.
+	tick := func() time.Time {
+		time.Sleep(time.Millisecond)
+		at := time.Now()
+		time.Sleep(time.Millisecond)
+		return at
+	}
+
+	beforeCreate := tick()
+
+	engine, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	created, err := service.CreateCar(ctx, &cars.CarRequest{
+		Name:     "Civic",
+		Year:     2020,
+		Brand:    "Honda",
+		FuelType: "Petrol",
+		Engine:   &engines.Engine{EngineID: engine.EngineID},
+		Price:    25000,
+	})
+	if err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+	id := created.CarID.String()
+
+	listed := tick()
+
+	merge, err := patch.NewMergePatch([]byte(`{"price": 23500}`))
+	if err != nil {
+		t.Fatalf("NewMergePatch: %v", err)
+	}
+	if _, err := service.PatchCar(ctx, id, merge, etag.Any); err != nil {
+		t.Fatalf("PatchCar: %v", err)
+	}
+	if _, err := engineRepo.UpdateEngine(ctx, engine.EngineID.String(), &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 650}, etag.Any); err != nil {
+		t.Fatalf("UpdateEngine: %v", err)
+	}
+
+	discounted := tick()
+
+	got, err := service.GetCarAsOf(ctx, id, listed)
+	if err != nil {
+		t.Fatalf("GetCarAsOf(listed): %v", err)
+	}
+	if got.Price != 25000 || got.Name != "Civic" || got.Version != 1 || got.Engine == nil || got.Engine.CarRange != 600 {
+		t.Fatalf("GetCarAsOf(listed) = %+v, engine %+v", got, got.Engine)
+	}
+
+	got, err = service.GetCarAsOf(ctx, id, discounted)
+	if err != nil {
+		t.Fatalf("GetCarAsOf(discounted): %v", err)
+	}
+	if got.Price != 23500 || got.Version != 2 || got.Engine.CarRange != 650 {
+		t.Fatalf("GetCarAsOf(discounted) = %+v, engine %+v", got, got.Engine)
+	}
+
+	if _, err := service.GetCarAsOf(ctx, id, beforeCreate); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("GetCarAsOf(before create) error = %v, want not found", err)
+	}
+
+	changes, err := service.DiffCar(ctx, id, listed, discounted)
+	if err != nil {
+		t.Fatalf("DiffCar: %v", err)
+	}
+	want := []audit.Change{
+		{Field: "engine.carRange", Before: 600.0, After: 650.0},
+		{Field: "price", Before: 25000.0, After: 23500.0},
+	}
+	if len(changes) != len(want) || changes[0] != want[0] || changes[1] != want[1] {
+		t.Fatalf("DiffCar = %+v, want %+v", changes, want)
+	}
+}
+
+func TestListCarsByEngine(t *testing.T) {
+	ctx := context.Background()
+	service, engineRepo := newTestService(t)
+
+	engine, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	if _, err := service.CreateCar(ctx, &cars.CarRequest{
+		Name: "Civic", Year: 2020, Brand: "Honda", FuelType: "Petrol",
+		Engine: &engines.Engine{EngineID: engine.EngineID}, Price: 25000,
+	}); err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+
+	page, err := service.ListCarsByEngine(ctx, engine.EngineID.String(), &cars.CarFilter{})
+	if err != nil {
+		t.Fatalf("ListCarsByEngine: %v", err)
+	}
+	if page.Total != 1 || len(page.Items) != 1 || page.Items[0].Name != "Civic" {
+		t.Fatalf("ListCarsByEngine = %+v, want the Civic", page)
+	}
+
+	if _, err := service.ListCarsByEngine(ctx, uuid.NewString(), &cars.CarFilter{}); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("ListCarsByEngine(unknown engine) error = %v, want not found", err)
+	}
+	if _, err := service.ListCarsByEngine(ctx, "not-a-uuid", &cars.CarFilter{}); !errors.Is(err, apperrors.ErrInvalidID) {
+		t.Fatalf("ListCarsByEngine(invalid id) error = %v, want invalid id", err)
+	}
+}
+
+func TestCreateCarsModes(t *testing.T) {
+	ctx := context.Background()
+	service, engineRepo := newTestService(t)
+
+	engine, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+
+	req := func(name, vin string) *cars.CarRequest {
+		return &cars.CarRequest{
+			Name: name, Year: 2020, Brand: "Honda", FuelType: "Petrol",
+			Engine: &engines.Engine{EngineID: engine.EngineID}, Price: 25000, VIN: vin,
+		}
+	}
+	withNewEngine := req("Jazz", "")
+	withNewEngine.Engine = &engines.Engine{Displacement: 1300, NoOfCylinders: 4, CarRange: 500}
+	invalid := req("", "")
+
+	// Both VINs are valid, but the second item repeats the first: only the
+	// write finds that out.
+	items := []*cars.CarRequest{req("Civic", "1HGCM82633A004352"), req("Accord", "1HGCM82633A004352"), withNewEngine, invalid}
+
+	results, err := service.CreateCars(ctx, items, cars.BulkAtomic)
+	if err != nil {
+		t.Fatalf("CreateCars(atomic): %v", err)
+	}
+	assertResultErrors(t, results, cars.ErrNotApplied, cars.ErrNotApplied, cars.ErrNotApplied, apperrors.ErrValidation)
+
+	results, err = service.CreateCars(ctx, items[:3], cars.BulkAtomic)
+	if err != nil {
+		t.Fatalf("CreateCars(atomic): %v", err)
+	}
+	assertResultErrors(t, results, cars.ErrNotApplied, apperrors.ErrConflict, cars.ErrNotApplied)
+
+	// The engine of the rolled back Jazz is gone too.
+	_, total, err := engineRepo.ListEngines(ctx, &engines.EngineFilter{Sort: "carRange", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListEngines: %v", err)
+	}
+	if total != 1 {
+		t.Fatalf("engines after a failed atomic request = %d, want 1", total)
+	}
+
+	results, err = service.CreateCars(ctx, items, cars.BulkPartial)
+	if err != nil {
+		t.Fatalf("CreateCars(partial): %v", err)
+	}
+	assertResultErrors(t, results, nil, apperrors.ErrConflict, nil, apperrors.ErrValidation)
+	if results[0].Car.Name != "Civic" || results[2].Car.Engine.CarRange != 500 {
+		t.Fatalf("CreateCars(partial) cars = %+v, %+v", results[0].Car, results[2].Car)
+	}
+
+	if _, err := service.CreateCars(ctx, nil, cars.BulkPartial); !errors.Is(err, apperrors.ErrValidation) {
+		t.Fatalf("CreateCars(no items) error = %v, want a validation error", err)
+	}
+}
+
+func TestUpdateAndDeleteCarsRefs(t *testing.T) {
+	ctx := context.Background()
+	service, engineRepo := newTestService(t)
+
+	engine, err := engineRepo.CreateEngine(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	carReq := cars.CarRequest{
+		Name: "Civic", Year: 2020, Brand: "Honda", FuelType: "Petrol",
+		Engine: &engines.Engine{EngineID: engine.EngineID}, Price: 25000,
+	}
+	created, err := service.CreateCar(ctx, &carReq)
+	if err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+
+	results, err := service.UpdateCars(ctx, []*cars.CarUpdate{
+		{CarID: created.CarID, Version: 1, CarRequest: carReq},
+		{CarID: created.CarID, Version: 1, CarRequest: carReq},
+		{CarID: uuid.New(), CarRequest: carReq},
+	}, cars.BulkPartial)
+	if err != nil {
+		t.Fatalf("UpdateCars: %v", err)
+	}
+	assertResultErrors(t, results, nil, apperrors.ErrValidation, apperrors.ErrPreconditionRequired)
+
+	results, err = service.DeleteCars(ctx, []*cars.CarRef{{CarID: created.CarID, Version: 1}, {CarID: uuid.New(), Version: 1}}, cars.BulkPartial)
+	if err != nil {
+		t.Fatalf("DeleteCars: %v", err)
+	}
+	assertResultErrors(t, results, apperrors.ErrPreconditionFailed, apperrors.ErrNotFound)
+}
+
+// assertResultErrors checks the error of each bulk result, nil for an item
+// that was written.
+func assertResultErrors(t *testing.T, results []*cars.BulkResult, want ...error) {
+	t.Helper()
+
+	if len(results) != len(want) {
+		t.Fatalf("%d results, want %d", len(results), len(want))
+	}
+	for i, result := range results {
+		switch {
+		case want[i] == nil && (result.Err != nil || result.Car == nil):
+			t.Fatalf("result %d = %+v, want a written car", i, result)
+		case want[i] != nil && !errors.Is(result.Err, want[i]):
+			t.Fatalf("result %d error = %v, want %v", i, result.Err, want[i])
+		}
+	}
+}
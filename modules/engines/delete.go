@@ -0,0 +1,33 @@
+package engines
+
+import (
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/google/uuid"
+)
+
+// CascadeDetach is the cascade mode that clears the engine of its cars.
+const CascadeDetach = "detach"
+
+// DeleteOptions says what happens to the live cars using an engine that is
+// deleted. Without options the delete fails while there are any.
+type DeleteOptions struct {
+	Cascade string
+	// ReassignTo moves the cars to this engine instead.
+	ReassignTo *uuid.UUID
+}
+
+func (o *DeleteOptions) Validate() error {
+	var fields []apperrors.FieldError
+
+	if o.Cascade != "" && o.Cascade != CascadeDetach {
+		fields = append(fields, apperrors.FieldError{Field: "cascade", Message: "cascade must be " + CascadeDetach})
+	}
+	if o.Cascade != "" && o.ReassignTo != nil {
+		fields = append(fields, apperrors.FieldError{Field: "reassignTo", Message: "reassignTo cannot be combined with cascade"})
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation(nil, fields...)
+	}
+	return nil
+}
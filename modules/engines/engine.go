@@ -1,16 +1,64 @@
 package engines
 
-import "github.com/google/uuid"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/google/uuid"
+)
 
 type Engine struct {
-	EngineID      uuid.UUID `json:"engineId" db:"engine_id"`
-	Displacement  uint16    `json:"displacement" db:"displacement"`
-	NoOfCylinders uint16    `json:"noOfCylinders" db:"no_of_cylinders"`
-	CarRange      uint16    `json:"carRange" db:"car_range"`
+	EngineID      uuid.UUID  `json:"engineId" db:"engine_id"`
+	Displacement  uint16     `json:"displacement" db:"displacement"`
+	NoOfCylinders uint16     `json:"noOfCylinders" db:"no_of_cylinders"`
+	CarRange      uint16     `json:"carRange" db:"car_range"`
+	Version       int64      `json:"version,omitempty" db:"version"`
+	DeletedAt     *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
 }
 
 type EngineRequest struct {
-	Displacement  uint16 `json:"displacement" validate:"required"`
-	NoOfCylinders uint16 `json:"noOfCylinders" validate:"required"`
+	Displacement  uint16 `json:"displacement" validate:"combustion=NoOfCylinders"`
+	NoOfCylinders uint16 `json:"noOfCylinders"`
 	CarRange      uint16 `json:"carRange" validate:"required"`
 }
+
+var ErrEngineNotInTrash = apperrors.NotFound("engine not found in trash")
+
+var ErrEngineModified = apperrors.PreconditionFailed("engine has been modified since it was read, fetch it again and retry")
+
+// AuditFields returns the fields the audit log tracks, nil for a nil engine.
+func (e *Engine) AuditFields() map[string]any {
+	if e == nil {
+		return nil
+	}
+
+	return map[string]any{
+		"displacement":  e.Displacement,
+		"noOfCylinders": e.NoOfCylinders,
+		"carRange":      e.CarRange,
+		"deletedAt":     e.DeletedAt,
+	}
+}
+
+// SetAuditFields is the inverse of AuditFields: it sets the fields of e from
+// their JSON form, as rebuilt from the audit log.
+func (e *Engine) SetAuditFields(fields map[string]any) error {
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	var v struct {
+		Displacement  uint16     `json:"displacement"`
+		NoOfCylinders uint16     `json:"noOfCylinders"`
+		CarRange      uint16     `json:"carRange"`
+		DeletedAt     *time.Time `json:"deletedAt"`
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+
+	e.Displacement, e.NoOfCylinders, e.CarRange, e.DeletedAt = v.Displacement, v.NoOfCylinders, v.CarRange, v.DeletedAt
+	return nil
+}
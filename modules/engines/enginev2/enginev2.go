@@ -0,0 +1,53 @@
+// Package enginev2 is the JSON form of engines in version 2 of the API, where
+// every resource is identified by "id" and always carries its version.
+package enginev2
+
+import (
+	"time"
+
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/google/uuid"
+)
+
+type Engine struct {
+	ID            uuid.UUID  `json:"id"`
+	Displacement  uint16     `json:"displacement"`
+	NoOfCylinders uint16     `json:"noOfCylinders"`
+	CarRange      uint16     `json:"carRange"`
+	Version       int64      `json:"version"`
+	DeletedAt     *time.Time `json:"deletedAt"`
+}
+
+type EngineUsage struct {
+	Engine
+	UsageCount int `json:"usageCount"`
+}
+
+// NewEngine returns the form of e, nil for a nil engine.
+func NewEngine(e *engines.Engine) *Engine {
+	if e == nil {
+		return nil
+	}
+
+	return &Engine{
+		ID:            e.EngineID,
+		Displacement:  e.Displacement,
+		NoOfCylinders: e.NoOfCylinders,
+		CarRange:      e.CarRange,
+		Version:       e.Version,
+		DeletedAt:     e.DeletedAt,
+	}
+}
+
+// Representation renders engines in this form.
+var Representation engines.Representation = representation{}
+
+type representation struct{}
+
+func (representation) Engine(engine *engines.Engine) any {
+	return NewEngine(engine)
+}
+
+func (representation) EngineUsage(engine *engines.EngineUsage) any {
+	return &EngineUsage{Engine: *NewEngine(&engine.Engine), UsageCount: engine.UsageCount}
+}
@@ -0,0 +1,121 @@
+package engines
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/pagination"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// SortFields are the engine fields a listing can be ordered by, keyed by
+// their JSON name.
+var SortFields = []string{"displacement", "noOfCylinders", "carRange", "usageCount"}
+
+// EngineUsage is an engine in a listing together with the number of cars
+// that use it.
+type EngineUsage struct {
+	Engine
+	UsageCount int `json:"usageCount"`
+}
+
+// EngineFilter selects engines for a listing. Nil bounds leave that
+// criterion out.
+type EngineFilter struct {
+	DisplacementMin *uint16
+	DisplacementMax *uint16
+	NoOfCylinders   *uint16
+	CarRangeMin     *uint16
+	CarRangeMax     *uint16
+
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+
+	// Trashed lists soft-deleted engines instead of live ones.
+	Trashed bool
+
+	// Cursor resumes a keyset listing. It carries its own sort order, which
+	// replaces Sort and Desc, and rules out Offset.
+	Cursor *pagination.Cursor
+}
+
+// Validate fills in paging defaults and rejects unknown sort fields and
+// inverted ranges.
+func (f *EngineFilter) Validate() error {
+	var fields []apperrors.FieldError
+
+	if f.Cursor != nil {
+		if f.Offset != 0 {
+			fields = append(fields, apperrors.FieldError{Field: "offset", Message: "offset cannot be combined with cursor"})
+		}
+		f.Sort, f.Desc = f.Cursor.Sort, f.Cursor.Desc
+	}
+
+	if f.Sort == "" {
+		f.Sort = "displacement"
+	}
+	if !slices.Contains(SortFields, f.Sort) {
+		fields = append(fields, apperrors.FieldError{
+			Field:   "sort",
+			Message: "sort must be one of " + strings.Join(SortFields, ", "),
+		})
+	}
+
+	if f.Limit == 0 {
+		f.Limit = DefaultListLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		fields = append(fields, apperrors.FieldError{Field: "limit", Message: "limit must be between 1 and 100"})
+	}
+	if f.Offset < 0 {
+		fields = append(fields, apperrors.FieldError{Field: "offset", Message: "offset cannot be negative"})
+	}
+
+	if f.DisplacementMin != nil && f.DisplacementMax != nil && *f.DisplacementMin > *f.DisplacementMax {
+		fields = append(fields, apperrors.FieldError{Field: "displacementMin", Message: "displacementMin cannot be greater than displacementMax"})
+	}
+	if f.CarRangeMin != nil && f.CarRangeMax != nil && *f.CarRangeMin > *f.CarRangeMax {
+		fields = append(fields, apperrors.FieldError{Field: "carRangeMin", Message: "carRangeMin cannot be greater than carRangeMax"})
+	}
+
+	if len(fields) > 0 {
+		return apperrors.Validation(nil, fields...)
+	}
+
+	if f.Cursor != nil {
+		if _, err := f.CursorValue(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CursorValue decodes the sort key of the cursor boundary row.
+func (f *EngineFilter) CursorValue() (any, error) {
+	return f.Cursor.DecodeValue((&EngineUsage{}).SortValue(f.Sort))
+}
+
+// SortValue returns the value of the engine field a listing is sorted by.
+func (e *Engine) SortValue(field string) any {
+	switch field {
+	case "noOfCylinders":
+		return e.NoOfCylinders
+	case "carRange":
+		return e.CarRange
+	}
+	return e.Displacement
+}
+
+func (e *EngineUsage) SortValue(field string) any {
+	if field == "usageCount" {
+		return e.UsageCount
+	}
+	return e.Engine.SortValue(field)
+}
@@ -5,86 +5,258 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/httpquery"
 	"github.com/codepnw/go-car-management/modules/engines"
 	engservices "github.com/codepnw/go-car-management/modules/engines/services"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/codepnw/go-car-management/patch"
 	"github.com/gin-gonic/gin"
 )
 
 type enginHandler struct {
-	service engservices.IEngineService
+	service        engservices.IEngineService
+	cursors        *pagination.Signer
+	trashRetention time.Duration
+	// view is the form of engines in the API version the handler serves.
+	view engines.Representation
 }
 
-func NewEngineHandler(service engservices.IEngineService) *enginHandler {
-	return &enginHandler{service: service}
+func NewEngineHandler(service engservices.IEngineService, cursors *pagination.Signer, trashRetention time.Duration, view engines.Representation) *enginHandler {
+	return &enginHandler{service: service, cursors: cursors, trashRetention: trashRetention, view: view}
 }
 
 func (h *enginHandler) GetEngineByID(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	id := c.Param("id")
 
 	resp, err := h.service.GetEngineByID(ctx, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": resp})
+	c.Header("ETag", etag.Format(resp.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Engine(resp)})
+}
+
+func (h *enginHandler) ListEngines(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, err := h.parseEngineFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	page, err := h.service.ListEngines(ctx, filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.renderEngines(page.Items),
+		"meta": pagination.Meta(h.cursors, page, filter.Limit, filter.Offset),
+	})
 }
 
 func (h *enginHandler) CreateEngine(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	req := &engines.EngineRequest{}
 
 	if err := c.ShouldBindJSON(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.InvalidJSON(err))
 		return
 	}
 
 	createdEngine, err := h.service.CreateEngine(ctx, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"data": createdEngine})
+	c.Header("ETag", etag.Format(createdEngine.Version))
+	c.JSON(http.StatusCreated, gin.H{"data": h.view.Engine(createdEngine)})
 }
 
 func (h *enginHandler) UpdateEngine(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	id := c.Param("id")
+	version, err := etag.IfMatch(c.Request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	req := &engines.EngineRequest{}
 
 	if err := c.ShouldBindJSON(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(apperrors.InvalidJSON(err))
+		return
+	}
+
+	updatedEngine, err := h.service.UpdateEngine(ctx, id, req, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(updatedEngine.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Engine(updatedEngine)})
+}
+
+func (h *enginHandler) PatchEngine(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+	version, err := etag.IfMatch(c.Request)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	updatedEngine, err := h.service.UpdateEngine(ctx, id, req)
+	p, err := patch.Read(c.Request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": updatedEngine})
+	patchedEngine, err := h.service.PatchEngine(ctx, id, p, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(patchedEngine.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Engine(patchedEngine)})
 }
 
 func (h *enginHandler) DeleteEngine(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	id := c.Param("id")
+	version, err := etag.IfMatch(c.Request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	q := httpquery.New(c)
+	opts := &engines.DeleteOptions{
+		Cascade:    q.String("cascade"),
+		ReassignTo: q.UUID("reassignTo"),
+	}
+	if err := q.Err(); err != nil {
+		c.Error(err)
+		return
+	}
+
+	deletedEngine, err := h.service.DeleteEngine(ctx, id, version, opts)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, gin.H{"data": h.view.Engine(deletedEngine)})
+}
+
+func (h *enginHandler) ListTrashedEngines(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	filter, err := h.parseEngineFilter(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter.Trashed = true
+
+	page, err := h.service.ListEngines(ctx, filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": h.renderEngines(page.Items),
+		"meta": pagination.Meta(h.cursors, page, filter.Limit, filter.Offset),
+	})
+}
+
+func (h *enginHandler) RestoreEngine(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
 	id := c.Param("id")
 
-	deletedEngine, err := h.service.DeleteEngine(ctx, id)
+	restoredEngine, err := h.service.RestoreEngine(ctx, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag.Format(restoredEngine.Version))
+	c.JSON(http.StatusOK, gin.H{"data": h.view.Engine(restoredEngine)})
+}
+
+// PurgeEngines permanently deletes the engines that have outlived the trash
+// retention period.
+func (h *enginHandler) PurgeEngines(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	purged, err := h.service.PurgeEngines(ctx, h.trashRetention)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusNoContent, gin.H{"data": deletedEngine})
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"purged": purged}})
+}
+
+func (h *enginHandler) renderEngines(items []*engines.EngineUsage) []any {
+	out := make([]any, len(items))
+	for i, engine := range items {
+		out[i] = h.view.EngineUsage(engine)
+	}
+	return out
+}
+
+func (h *enginHandler) parseEngineFilter(c *gin.Context) (*engines.EngineFilter, error) {
+	q := httpquery.New(c)
+
+	filter := &engines.EngineFilter{
+		DisplacementMin: q.Uint16("displacementMin"),
+		DisplacementMax: q.Uint16("displacementMax"),
+		NoOfCylinders:   q.Uint16("noOfCylinders"),
+		CarRangeMin:     q.Uint16("carRangeMin"),
+		CarRangeMax:     q.Uint16("carRangeMax"),
+
+		Limit:  q.Int("limit"),
+		Offset: q.Int("offset"),
+	}
+	filter.Sort, filter.Desc = q.Sort("sort")
+
+	if err := q.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, err := h.cursors.Decode(q.String("cursor"))
+	if err != nil {
+		return nil, err
+	}
+	filter.Cursor = cursor
+
+	return filter, nil
 }
@@ -0,0 +1,47 @@
+package engines
+
+import "slices"
+
+// EnginePatch holds the columns a partial update writes. Nil fields are left
+// as they are.
+type EnginePatch struct {
+	Displacement  *uint16
+	NoOfCylinders *uint16
+	CarRange      *uint16
+}
+
+// Request returns the engine as the request that would recreate it. PATCH
+// bodies are applied to this form.
+func (e *Engine) Request() *EngineRequest {
+	return &EngineRequest{
+		Displacement:  e.Displacement,
+		NoOfCylinders: e.NoOfCylinders,
+		CarRange:      e.CarRange,
+	}
+}
+
+// NewEnginePatch takes the fields of req listed in changed, by JSON name.
+func NewEnginePatch(req *EngineRequest, changed []string) *EnginePatch {
+	p := &EnginePatch{}
+	for _, field := range changed {
+		switch field {
+		case "displacement":
+			p.Displacement = &req.Displacement
+		case "noOfCylinders":
+			p.NoOfCylinders = &req.NoOfCylinders
+		case "carRange":
+			p.CarRange = &req.CarRange
+		}
+	}
+	return p
+}
+
+// PatchValidationFields returns the fields to validate when changed were
+// modified: the changed fields plus those whose rules read one of them.
+func PatchValidationFields(changed []string) []string {
+	fields := slices.Clone(changed)
+	if slices.Contains(changed, "noOfCylinders") && !slices.Contains(fields, "displacement") {
+		fields = append(fields, "displacement")
+	}
+	return fields
+}
@@ -0,0 +1,398 @@
+package engrepositories
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/google/uuid"
+)
+
+type engineMemoryRepository struct {
+	db *memdb.DB
+}
+
+func NewEngineMemoryRepository(db *memdb.DB) IEngineRepository {
+	return &engineMemoryRepository{db: db}
+}
+
+func (r *engineMemoryRepository) GetEngineByID(ctx context.Context, id string) (*engines.Engine, error) {
+	var engine engines.Engine
+
+	engineID, err := uuid.Parse(id)
+	if err != nil {
+		return &engine, apperrors.InvalidID("engine", err)
+	}
+
+	err = r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Engines.Get(engineID)
+		if !ok || row.DeletedAt != nil {
+			return apperrors.NotFound("engine not found")
+		}
+
+		engine = engineFromRow(row)
+		return nil
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
+
+	return &engine, nil
+}
+
+func (r *engineMemoryRepository) FindEngineBySpec(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error) {
+	var found *engines.Engine
+
+	err := r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		tx.Engines.Scan(func(id uuid.UUID, row memdb.EngineRow) bool {
+			if row.DeletedAt != nil || row.Displacement != req.Displacement ||
+				row.NoOfCylinders != req.NoOfCylinders || row.CarRange != req.CarRange {
+				return true
+			}
+			if found == nil || bytes.Compare(id[:], found.EngineID[:]) < 0 {
+				engine := engineFromRow(row)
+				found = &engine
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, apperrors.NotFound("no engine with these specs")
+	}
+
+	return found, nil
+}
+
+func (r *engineMemoryRepository) CreateEngine(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error) {
+	row := memdb.EngineRow{
+		EngineID:      uuid.New(),
+		Displacement:  req.Displacement,
+		NoOfCylinders: req.NoOfCylinders,
+		CarRange:      req.CarRange,
+		Version:       1,
+	}
+
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		tx.Engines.Put(row.EngineID, row)
+		engine := engineFromRow(row)
+		return putEngineAudit(ctx, tx, audit.ActionCreate, nil, &engine)
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
+
+	engine := engineFromRow(row)
+	return &engine, nil
+}
+
+func (r *engineMemoryRepository) UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest, version int64) (*engines.Engine, error) {
+	engineID, err := uuid.Parse(id)
+	if err != nil {
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
+	}
+
+	row := memdb.EngineRow{
+		EngineID:      engineID,
+		Displacement:  req.Displacement,
+		NoOfCylinders: req.NoOfCylinders,
+		CarRange:      req.CarRange,
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		current, ok := tx.Engines.Get(engineID)
+		if !ok || current.DeletedAt != nil {
+			return apperrors.NotFound("engine not found")
+		}
+		if version != etag.Any && current.Version != version {
+			return engines.ErrEngineModified
+		}
+
+		row.Version = current.Version + 1
+		tx.Engines.Put(engineID, row)
+
+		before, after := engineFromRow(current), engineFromRow(row)
+		return putEngineAudit(ctx, tx, audit.ActionUpdate, &before, &after)
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
+
+	engine := engineFromRow(row)
+	return &engine, nil
+}
+
+func (r *engineMemoryRepository) PatchEngine(ctx context.Context, id string, p *engines.EnginePatch, version int64) (*engines.Engine, error) {
+	var engine engines.Engine
+
+	engineID, err := uuid.Parse(id)
+	if err != nil {
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Engines.Get(engineID)
+		if !ok || row.DeletedAt != nil {
+			return apperrors.NotFound("engine not found")
+		}
+		if version != etag.Any && row.Version != version {
+			return engines.ErrEngineModified
+		}
+		before := engineFromRow(row)
+
+		if p.Displacement != nil {
+			row.Displacement = *p.Displacement
+		}
+		if p.NoOfCylinders != nil {
+			row.NoOfCylinders = *p.NoOfCylinders
+		}
+		if p.CarRange != nil {
+			row.CarRange = *p.CarRange
+		}
+		row.Version++
+
+		tx.Engines.Put(engineID, row)
+		engine = engineFromRow(row)
+		return putEngineAudit(ctx, tx, audit.ActionUpdate, &before, &engine)
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
+
+	return &engine, nil
+}
+
+func (r *engineMemoryRepository) DeleteEngine(ctx context.Context, id string, version int64) (*engines.Engine, error) {
+	var engine engines.Engine
+
+	engineID, err := uuid.Parse(id)
+	if err != nil {
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Engines.Get(engineID)
+		if !ok || row.DeletedAt != nil {
+			return apperrors.NotFound("engine not found")
+		}
+		if version != etag.Any && row.Version != version {
+			return engines.ErrEngineModified
+		}
+		before := engineFromRow(row)
+
+		if carIDs := engineCars(tx, engineID); len(carIDs) > 0 {
+			return engineInUse(carIDs[:min(len(carIDs), maxListedCars)], len(carIDs))
+		}
+
+		deletedAt := time.Now().Local()
+		row.DeletedAt = &deletedAt
+		row.Version++
+
+		tx.Engines.Put(engineID, row)
+		engine = engineFromRow(row)
+		return putEngineAudit(ctx, tx, audit.ActionDelete, &before, &engine)
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
+
+	return &engine, nil
+}
+
+func (r *engineMemoryRepository) RestoreEngine(ctx context.Context, id string) (*engines.Engine, error) {
+	var engine engines.Engine
+
+	engineID, err := uuid.Parse(id)
+	if err != nil {
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
+	}
+
+	err = r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		row, ok := tx.Engines.Get(engineID)
+		if !ok || row.DeletedAt == nil {
+			return engines.ErrEngineNotInTrash
+		}
+		before := engineFromRow(row)
+
+		row.DeletedAt = nil
+		row.Version++
+
+		tx.Engines.Put(engineID, row)
+		engine = engineFromRow(row)
+		return putEngineAudit(ctx, tx, audit.ActionRestore, &before, &engine)
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
+
+	return &engine, nil
+}
+
+func (r *engineMemoryRepository) PurgeEngines(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged := 0
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		var expired []engines.Engine
+		tx.Engines.Scan(func(id uuid.UUID, row memdb.EngineRow) bool {
+			if row.DeletedAt != nil && row.DeletedAt.Before(deletedBefore) && !engineReferenced(tx, id, true) {
+				expired = append(expired, engineFromRow(row))
+			}
+			return true
+		})
+
+		for _, engine := range expired {
+			tx.Engines.Delete(engine.EngineID)
+			if err := putEngineAudit(ctx, tx, audit.ActionPurge, &engine, nil); err != nil {
+				return err
+			}
+		}
+		purged = len(expired)
+		return nil
+	})
+	return purged, err
+}
+
+func (r *engineMemoryRepository) ListEngines(ctx context.Context, filter *engines.EngineFilter) ([]*engines.EngineUsage, int, error) {
+	var matched []*engines.EngineUsage
+
+	if !slices.Contains(engines.SortFields, filter.Sort) {
+		return nil, 0, fmt.Errorf("unknown sort field %q", filter.Sort)
+	}
+
+	var boundary any
+	if filter.Cursor != nil {
+		value, err := filter.CursorValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		boundary = value
+	}
+
+	total := 0
+	err := r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		usage := make(map[uuid.UUID]int)
+		tx.Cars.Scan(func(_ uuid.UUID, car memdb.CarRow) bool {
+			if car.EngineID.Valid && car.DeletedAt == nil {
+				usage[car.EngineID.UUID]++
+			}
+			return true
+		})
+
+		tx.Engines.Scan(func(_ uuid.UUID, row memdb.EngineRow) bool {
+			if (row.DeletedAt != nil) != filter.Trashed {
+				return true
+			}
+
+			engine := &engines.EngineUsage{Engine: engineFromRow(row), UsageCount: usage[row.EngineID]}
+			if !matchEngineFilter(&engine.Engine, filter) {
+				return true
+			}
+
+			total++
+			if filter.Cursor == nil || filter.Cursor.After(engine.SortValue(filter.Sort), boundary, engine.EngineID) {
+				matched = append(matched, engine)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if filter.Desc {
+			a, b = b, a
+		}
+		if n := pagination.Compare(a.SortValue(filter.Sort), b.SortValue(filter.Sort)); n != 0 {
+			return n < 0
+		}
+		return pagination.Compare(a.EngineID, b.EngineID) < 0
+	})
+
+	if filter.Cursor.Reversed() {
+		start := max(len(matched)-filter.Limit, 0)
+		return append([]*engines.EngineUsage{}, matched[start:]...), total, nil
+	}
+
+	start := min(filter.Offset, len(matched))
+	end := min(start+filter.Limit, len(matched))
+
+	return append([]*engines.EngineUsage{}, matched[start:end]...), total, nil
+}
+
+// matchEngineFilter is the in-memory equivalent of engineFilterQuery.
+func matchEngineFilter(e *engines.Engine, f *engines.EngineFilter) bool {
+	if (f.DisplacementMin != nil && e.Displacement < *f.DisplacementMin) ||
+		(f.DisplacementMax != nil && e.Displacement > *f.DisplacementMax) {
+		return false
+	}
+	if f.NoOfCylinders != nil && e.NoOfCylinders != *f.NoOfCylinders {
+		return false
+	}
+	if (f.CarRangeMin != nil && e.CarRange < *f.CarRangeMin) ||
+		(f.CarRangeMax != nil && e.CarRange > *f.CarRangeMax) {
+		return false
+	}
+	return true
+}
+
+// putEngineAudit is the in-memory equivalent of recordEngine.
+func putEngineAudit(ctx context.Context, tx *memdb.Tx, action audit.Action, before, after *engines.Engine) error {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	entry := audit.NewEntry(ctx, audit.EntityEngine, subject.EngineID, action, subject.Version, before.AuditFields(), after.AuditFields())
+	return auditrepositories.Put(tx, entry)
+}
+
+// engineCars returns the IDs of the live cars using the engine, in order.
+func engineCars(tx *memdb.Tx, engineID uuid.UUID) []uuid.UUID {
+	var carIDs []uuid.UUID
+	tx.Cars.Scan(func(_ uuid.UUID, car memdb.CarRow) bool {
+		if car.EngineID.Valid && car.EngineID.UUID == engineID && car.DeletedAt == nil {
+			carIDs = append(carIDs, car.CarID)
+		}
+		return true
+	})
+
+	slices.SortFunc(carIDs, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	return carIDs
+}
+
+// engineReferenced reports whether any car uses the engine. Trashed cars only
+// count with withTrashed, which mirrors the cars.engine_id foreign key.
+func engineReferenced(tx *memdb.Tx, engineID uuid.UUID, withTrashed bool) bool {
+	referenced := false
+	tx.Cars.Scan(func(_ uuid.UUID, car memdb.CarRow) bool {
+		referenced = car.EngineID.Valid && car.EngineID.UUID == engineID && (withTrashed || car.DeletedAt == nil)
+		return !referenced
+	})
+	return referenced
+}
+
+func engineFromRow(row memdb.EngineRow) engines.Engine {
+	return engines.Engine{
+		EngineID:      row.EngineID,
+		Displacement:  row.Displacement,
+		NoOfCylinders: row.NoOfCylinders,
+		CarRange:      row.CarRange,
+		Version:       row.Version,
+		DeletedAt:     row.DeletedAt,
+	}
+}
@@ -5,216 +5,553 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-
+	"slices"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/database/sqlbuilder"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
 	"github.com/codepnw/go-car-management/modules/engines"
 	"github.com/google/uuid"
 )
 
 type IEngineRepository interface {
 	GetEngineByID(ctx context.Context, id string) (*engines.Engine, error)
+	// FindEngineBySpec returns a live engine with exactly the specs of req.
+	// When several match, the one with the lowest ID is returned.
+	FindEngineBySpec(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error)
 	CreateEngine(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error)
-	UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest) (*engines.Engine, error)
-	DeleteEngine(ctx context.Context, id string) (*engines.Engine, error)
+	// UpdateEngine, PatchEngine and DeleteEngine only apply when the engine
+	// is still at version, failing with a precondition error otherwise.
+	// etag.Any skips the check.
+	UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest, version int64) (*engines.Engine, error)
+	PatchEngine(ctx context.Context, id string, p *engines.EnginePatch, version int64) (*engines.Engine, error)
+	// DeleteEngine moves the engine to the trash. Engines still used by live
+	// cars cannot be deleted.
+	DeleteEngine(ctx context.Context, id string, version int64) (*engines.Engine, error)
+	RestoreEngine(ctx context.Context, id string) (*engines.Engine, error)
+	// PurgeEngines permanently deletes the engines trashed before
+	// deletedBefore. Engines still referenced by trashed cars are kept until
+	// those cars are purged.
+	PurgeEngines(ctx context.Context, deletedBefore time.Time) (int, error)
+	ListEngines(ctx context.Context, filter *engines.EngineFilter) ([]*engines.EngineUsage, int, error)
+}
+
+var errEngineInUse = apperrors.Conflict("engine is still used by cars; delete or move them first, or delete with ?cascade=detach or ?reassignTo=<engineId>")
+
+// maxListedCars caps the car IDs an engine in use conflict lists.
+const maxListedCars = 100
+
+// engineInUse lists the first carIDs of the live cars using an engine, and
+// how many there are, in the conflict sent to the client.
+func engineInUse(carIDs []uuid.UUID, count int) error {
+	return errEngineInUse.With("carIds", carIDs).With("carCount", count)
 }
 
 type enginRepository struct {
-	db *sql.DB
+	db *database.TxManager
 }
 
+// NewEngineRepository returns a repository whose methods join the
+// transaction carried by their context, see database.Transactor.
 func NewEngineRepository(db *sql.DB) IEngineRepository {
-	return &enginRepository{db: db}
+	return &enginRepository{db: database.NewTxManager(db)}
 }
 
 func (r *enginRepository) GetEngineByID(ctx context.Context, id string) (*engines.Engine, error) {
 	var engine engines.Engine
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	engineID, err := uuid.Parse(id)
 	if err != nil {
-		return &engine, err
+		return &engine, apperrors.InvalidID("engine", err)
 	}
 
-	defer func() {
-		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("Transaction rollback error: %v\n", rbErr)
-			}
-		} else {
-			if cmErr := tx.Commit(); cmErr != nil {
-				fmt.Printf("Transaction commit error: %v\n", cmErr)
-			}
-		}
-	}()
-
-	err = tx.QueryRowContext(
+	err = r.db.Querier(ctx).QueryRowContext(
 		ctx,
-		"SELECT engine_id, displacement, no_of_cylinders, car_range FROM engines WHERE engine_id = $1;",
-		id,
+		"SELECT engine_id, displacement, no_of_cylinders, car_range, version FROM engines WHERE engine_id = $1 AND deleted_at IS NULL;",
+		engineID,
 	).Scan(
 		&engine.EngineID,
 		&engine.Displacement,
 		&engine.NoOfCylinders,
 		&engine.CarRange,
+		&engine.Version,
 	)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return &engine, nil
+			return &engines.Engine{}, apperrors.NotFound("engine not found")
 		}
-		return &engine, err
+		return &engines.Engine{}, err
 	}
 
 	return &engine, err
 }
 
-func (r *enginRepository) CreateEngine(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return &engines.Engine{}, err
-	}
-
-	defer func() {
-		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("Transaction rollback error: %v\n", rbErr)
-			}
-		} else {
-			if cmErr := tx.Commit(); cmErr != nil {
-				fmt.Printf("Transaction commit error: %v\n", cmErr)
-			}
-		}
-	}()
-
-	engineID := uuid.New()
+func (r *enginRepository) FindEngineBySpec(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error) {
+	var engine engines.Engine
 
-	_, err = tx.ExecContext(
+	err := r.db.Querier(ctx).QueryRowContext(
 		ctx,
-		`INSERT INTO engines (engine_id, displacement, no_of_cylinders, car_range)
-		VALUES ($1, $2, $3, $4);`,
-		engineID,
+		`SELECT engine_id, displacement, no_of_cylinders, car_range, version FROM engines
+		WHERE displacement = $1 AND no_of_cylinders = $2 AND car_range = $3 AND deleted_at IS NULL
+		ORDER BY engine_id LIMIT 1;`,
 		req.Displacement,
 		req.NoOfCylinders,
 		req.CarRange,
+	).Scan(
+		&engine.EngineID,
+		&engine.Displacement,
+		&engine.NoOfCylinders,
+		&engine.CarRange,
+		&engine.Version,
 	)
+
 	if err != nil {
-		return &engines.Engine{}, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NotFound("no engine with these specs")
+		}
+		return nil, err
 	}
 
+	return &engine, nil
+}
+
+func (r *enginRepository) CreateEngine(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error) {
 	engine := &engines.Engine{
-		EngineID:      engineID,
+		EngineID:      uuid.New(),
 		Displacement:  req.Displacement,
 		NoOfCylinders: req.NoOfCylinders,
 		CarRange:      req.CarRange,
+		Version:       1,
 	}
 
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO engines (engine_id, displacement, no_of_cylinders, car_range)
+			VALUES ($1, $2, $3, $4);`,
+			engine.EngineID,
+			engine.Displacement,
+			engine.NoOfCylinders,
+			engine.CarRange,
+		)
+		if err != nil {
+			return err
+		}
+
+		return recordEngine(ctx, tx, audit.ActionCreate, nil, engine)
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
 	return engine, nil
 }
 
-func (r *enginRepository) UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest) (*engines.Engine, error) {
+func (r *enginRepository) UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest, version int64) (*engines.Engine, error) {
 	engineID, err := uuid.Parse(id)
 	if err != nil {
-		return &engines.Engine{}, fmt.Errorf("invalid engine id: %w", err)
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
 	}
 
-	// Transaction
-	tx, err := r.db.BeginTx(ctx, nil)
+	var engine *engines.Engine
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := lockEngine(ctx, tx, engineID, false)
+		if err != nil {
+			return err
+		}
+
+		engine, err = scanEngine(tx.QueryRowContext(
+			ctx,
+			`UPDATE engines SET displacement = $1, no_of_cylinders = $2, car_range = $3, version = version + 1
+			WHERE engine_id = $4 AND deleted_at IS NULL AND ($5::bigint = 0 OR version = $5)
+			RETURNING `+engineColumns+`;`,
+			req.Displacement,
+			req.NoOfCylinders,
+			req.CarRange,
+			engineID,
+			version,
+		))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return engineMissingOrModified(ctx, tx, engineID)
+			}
+			return err
+		}
+
+		return recordEngine(ctx, tx, audit.ActionUpdate, before, engine)
+	})
 	if err != nil {
 		return &engines.Engine{}, err
 	}
+	return engine, nil
+}
+
+func (r *enginRepository) PatchEngine(ctx context.Context, id string, p *engines.EnginePatch, version int64) (*engines.Engine, error) {
+	engineID, err := uuid.Parse(id)
+	if err != nil {
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
+	}
 
-	defer func() {
+	b := sqlbuilder.New()
+	if p.Displacement != nil {
+		b.Set("displacement", *p.Displacement)
+	}
+	if p.NoOfCylinders != nil {
+		b.Set("no_of_cylinders", *p.NoOfCylinders)
+	}
+	if p.CarRange != nil {
+		b.Set("car_range", *p.CarRange)
+	}
+	b.SetExpr("version = version + 1")
+	b.Where("engine_id = ?", engineID)
+	b.Where("deleted_at IS NULL")
+	if version != etag.Any {
+		b.Where("version = ?", version)
+	}
+
+	var engine *engines.Engine
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := lockEngine(ctx, tx, engineID, false)
 		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("transaction rollback error: %v\n", rbErr)
-			}
-		} else {
-			if cmErr := tx.Commit(); cmErr != nil {
-				fmt.Printf("transaction commit error: %v\n", cmErr)
+			return err
+		}
+
+		engine, err = scanEngine(tx.QueryRowContext(
+			ctx,
+			"UPDATE engines "+b.SetClause()+" "+b.WhereClause()+" RETURNING "+engineColumns+";",
+			b.Args()...,
+		))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return engineMissingOrModified(ctx, tx, engineID)
 			}
+			return err
 		}
-	}()
 
-	results, err := tx.ExecContext(
-		ctx,
-		"UPDATE engines SET displacement = $1, no_of_cylinders = $2, car_range = $3 WHERE engine_id = $4",
-		req.Displacement,
-		req.NoOfCylinders,
-		req.CarRange,
-		engineID,
-	)
+		return recordEngine(ctx, tx, audit.ActionUpdate, before, engine)
+	})
 	if err != nil {
 		return &engines.Engine{}, err
 	}
+	return engine, nil
+}
 
-	rowAffected, err := results.RowsAffected()
+func (r *enginRepository) DeleteEngine(ctx context.Context, id string, version int64) (*engines.Engine, error) {
+	engineID, err := uuid.Parse(id)
 	if err != nil {
-		return &engines.Engine{}, err
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
 	}
 
-	if rowAffected == 0 {
-		return &engines.Engine{}, errors.New("no rows updated")
-	}
+	var engine *engines.Engine
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := lockEngine(ctx, tx, engineID, false)
+		if err != nil {
+			return err
+		}
 
-	engine := &engines.Engine{
-		EngineID:      engineID,
-		Displacement:  req.Displacement,
-		NoOfCylinders: req.NoOfCylinders,
-		CarRange:      req.CarRange,
-	}
+		engine, err = scanEngine(tx.QueryRowContext(
+			ctx,
+			`UPDATE engines SET deleted_at = $2, version = version + 1
+			WHERE engine_id = $1 AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3)
+			RETURNING `+engineColumns+`;`,
+			engineID,
+			time.Now().Local(),
+			version,
+		))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return engineMissingOrModified(ctx, tx, engineID)
+			}
+			return err
+		}
+
+		// The foreign key only guards the purge, so live cars are checked here.
+		rows, err := tx.QueryContext(
+			ctx,
+			`SELECT car_id, count(*) OVER () FROM cars
+			WHERE engine_id = $1 AND deleted_at IS NULL
+			ORDER BY car_id LIMIT $2;`,
+			engineID,
+			maxListedCars,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		carIDs := []uuid.UUID{}
+		var count int
+		for rows.Next() {
+			var carID uuid.UUID
+			if err := rows.Scan(&carID, &count); err != nil {
+				return err
+			}
+			carIDs = append(carIDs, carID)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if count > 0 {
+			return engineInUse(carIDs, count)
+		}
 
+		return recordEngine(ctx, tx, audit.ActionDelete, before, engine)
+	})
+	if err != nil {
+		return &engines.Engine{}, err
+	}
 	return engine, nil
 }
 
-func (r *enginRepository) DeleteEngine(ctx context.Context, id string) (*engines.Engine, error) {
-	var engine engines.Engine
+func (r *enginRepository) RestoreEngine(ctx context.Context, id string) (*engines.Engine, error) {
+	engineID, err := uuid.Parse(id)
+	if err != nil {
+		return &engines.Engine{}, apperrors.InvalidID("engine", err)
+	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
+	var engine *engines.Engine
+	err = r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := lockEngine(ctx, tx, engineID, true)
+		if err != nil {
+			return err
+		}
+
+		engine, err = scanEngine(tx.QueryRowContext(
+			ctx,
+			`UPDATE engines SET deleted_at = NULL, version = version + 1
+			WHERE engine_id = $1
+			RETURNING `+engineColumns+`;`,
+			engineID,
+		))
+		if err != nil {
+			return err
+		}
+
+		return recordEngine(ctx, tx, audit.ActionRestore, before, engine)
+	})
 	if err != nil {
 		return &engines.Engine{}, err
 	}
+	return engine, nil
+}
 
-	defer func() {
+func (r *enginRepository) PurgeEngines(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var purged []*engines.Engine
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(
+			ctx,
+			`DELETE FROM engines e
+			WHERE e.deleted_at < $1
+				AND NOT EXISTS (SELECT 1 FROM cars c WHERE c.engine_id = e.engine_id)
+			RETURNING `+engineColumns+`;`,
+			deletedBefore,
+		)
 		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				fmt.Printf("Transaction rollback error: %v\n", rbErr)
+			return err
+		}
+
+		purged = nil
+		for rows.Next() {
+			engine, err := scanEngine(rows)
+			if err != nil {
+				rows.Close()
+				return err
 			}
-		} else {
-			if cmErr := tx.Commit(); cmErr != nil {
-				fmt.Printf("Transaction commit error: %v\n", cmErr)
+			purged = append(purged, engine)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, engine := range purged {
+			if err := recordEngine(ctx, tx, audit.ActionPurge, engine, nil); err != nil {
+				return err
 			}
 		}
-	}()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
 
-	err = tx.QueryRowContext(
-		ctx,
-		"SELECT engine_id, displacement, no_of_cylinders, car_range FROM engines WHERE engine_id = $1;",
-		id,
-	).Scan(
+var engineSortColumns = map[string]string{
+	"displacement":  "e.displacement",
+	"noOfCylinders": "e.no_of_cylinders",
+	"carRange":      "e.car_range",
+	"usageCount":    "e.usage_count",
+}
+
+// engineUsageTable is engines with the number of referencing cars, so usage
+// can be filtered, sorted and paged on like any other column.
+const engineUsageTable = `(
+	SELECT engine_id, displacement, no_of_cylinders, car_range, version, deleted_at,
+		(SELECT count(*) FROM cars c WHERE c.engine_id = engines.engine_id AND c.deleted_at IS NULL) AS usage_count
+	FROM engines
+) e`
+
+func (r *enginRepository) ListEngines(ctx context.Context, filter *engines.EngineFilter) ([]*engines.EngineUsage, int, error) {
+	b := engineFilterQuery(filter)
+
+	var total int
+	err := r.db.Querier(ctx).QueryRowContext(ctx, "SELECT count(*) FROM engines e "+b.WhereClause()+";", b.Args()...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortColumn, ok := engineSortColumns[filter.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown sort field %q", filter.Sort)
+	}
+
+	desc := filter.Desc != filter.Cursor.Reversed()
+
+	if filter.Cursor != nil {
+		value, err := filter.CursorValue()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		b.Where("("+sortColumn+", e.engine_id) "+op+" (?, ?)", value, filter.Cursor.ID)
+	}
+
+	b.OrderBy(sortColumn, desc).OrderBy("e.engine_id", desc)
+
+	query := "SELECT e.engine_id, e.displacement, e.no_of_cylinders, e.car_range, e.version, e.deleted_at, e.usage_count FROM " + engineUsageTable + " " +
+		b.WhereClause() + " " + b.OrderByClause() + " " + b.LimitOffset(filter.Limit, filter.Offset) + ";"
+
+	rows, err := r.db.Querier(ctx).QueryContext(ctx, query, b.Args()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	response := []*engines.EngineUsage{}
+	for rows.Next() {
+		var engine engines.EngineUsage
+		err := rows.Scan(&engine.EngineID, &engine.Displacement, &engine.NoOfCylinders, &engine.CarRange, &engine.Version, &engine.DeletedAt, &engine.UsageCount)
+		if err != nil {
+			return nil, 0, err
+		}
+		response = append(response, &engine)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Cursor.Reversed() {
+		slices.Reverse(response)
+	}
+	return response, total, nil
+}
+
+const engineColumns = "engine_id, displacement, no_of_cylinders, car_range, version, deleted_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanEngine reads the columns listed in engineColumns.
+func scanEngine(row rowScanner) (*engines.Engine, error) {
+	var engine engines.Engine
+	err := row.Scan(
 		&engine.EngineID,
 		&engine.Displacement,
 		&engine.NoOfCylinders,
 		&engine.CarRange,
+		&engine.Version,
+		&engine.DeletedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return &engine, nil
+}
 
+// lockEngine reads a live engine, or with trashed a trashed one, and locks its
+// row for the rest of tx so the audit entry sees the state the write replaced.
+func lockEngine(ctx context.Context, tx *sql.Tx, engineID uuid.UUID, trashed bool) (*engines.Engine, error) {
+	condition := "deleted_at IS NULL"
+	if trashed {
+		condition = "deleted_at IS NOT NULL"
+	}
+
+	engine, err := scanEngine(tx.QueryRowContext(
+		ctx,
+		"SELECT "+engineColumns+" FROM engines WHERE engine_id = $1 AND "+condition+" FOR UPDATE;",
+		engineID,
+	))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return &engine, nil
+			if trashed {
+				return nil, engines.ErrEngineNotInTrash
+			}
+			return nil, apperrors.NotFound("engine not found")
 		}
-		return &engine, err
+		return nil, err
 	}
+	return engine, nil
+}
 
-	result, err := tx.ExecContext(ctx, "DELETE FROM engines WHERE engine_id = $1;", id)
-	if err != nil {
-		return &engines.Engine{}, err
+// recordEngine writes the audit entry of a change from before to after. One
+// of them is nil when the engine is created or purged.
+func recordEngine(ctx context.Context, tx *sql.Tx, action audit.Action, before, after *engines.Engine) error {
+	subject := after
+	if subject == nil {
+		subject = before
 	}
 
-	rowAffected, err := result.RowsAffected()
-	if err != nil {
-		return &engines.Engine{}, err
+	entry := audit.NewEntry(ctx, audit.EntityEngine, subject.EngineID, action, subject.Version, before.AuditFields(), after.AuditFields())
+	return auditrepositories.Insert(ctx, tx, entry)
+}
+
+// engineMissingOrModified explains why a versioned write matched no row.
+func engineMissingOrModified(ctx context.Context, tx *sql.Tx, engineID uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM engines WHERE engine_id = $1 AND deleted_at IS NULL);", engineID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return engines.ErrEngineModified
 	}
+	return apperrors.NotFound("engine not found")
+}
 
-	if rowAffected == 0 {
-		return &engines.Engine{}, errors.New("no rows delete")
+func engineFilterQuery(f *engines.EngineFilter) *sqlbuilder.Builder {
+	b := sqlbuilder.New()
+
+	if f.Trashed {
+		b.Where("e.deleted_at IS NOT NULL")
+	} else {
+		b.Where("e.deleted_at IS NULL")
 	}
 
-	return &engine, nil
+	if f.DisplacementMin != nil {
+		b.Where("e.displacement >= ?", *f.DisplacementMin)
+	}
+	if f.DisplacementMax != nil {
+		b.Where("e.displacement <= ?", *f.DisplacementMax)
+	}
+	if f.NoOfCylinders != nil {
+		b.Where("e.no_of_cylinders = ?", *f.NoOfCylinders)
+	}
+	if f.CarRangeMin != nil {
+		b.Where("e.car_range >= ?", *f.CarRangeMin)
+	}
+	if f.CarRangeMax != nil {
+		b.Where("e.car_range <= ?", *f.CarRangeMax)
+	}
+
+	return b
 }
@@ -0,0 +1,36 @@
+package engrepositories_test
+
+import (
+	"testing"
+
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/codepnw/go-car-management/modules/repotest"
+)
+
+func TestEngineRepository(t *testing.T) {
+	repotest.RunEngineRepositoryTests(t, func(t *testing.T) *repotest.Repositories {
+		db := repotest.Postgres(t)
+		return &repotest.Repositories{
+			Car:    carrepositories.NewCarRepository(db),
+			Engine: engrepositories.NewEngineRepository(db),
+			Audit:  auditrepositories.NewAuditRepository(db),
+			Tx:     database.NewTxManager(db),
+		}
+	})
+}
+
+func TestEngineMemoryRepository(t *testing.T) {
+	repotest.RunEngineRepositoryTests(t, func(t *testing.T) *repotest.Repositories {
+		db := memdb.New()
+		return &repotest.Repositories{
+			Car:    carrepositories.NewCarMemoryRepository(db),
+			Engine: engrepositories.NewEngineMemoryRepository(db),
+			Audit:  auditrepositories.NewAuditMemoryRepository(db),
+			Tx:     db,
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package engines
+
+// Representation is the JSON form of engines in one version of the API.
+// Engine requests have the same form in every version.
+type Representation interface {
+	// Engine returns the form of engine to render.
+	Engine(engine *Engine) any
+	// EngineUsage returns the form of an engine in a listing.
+	EngineUsage(engine *EngineUsage) any
+}
+
+// V1 is the form of engines in version 1 of the API: the Go types as they
+// are.
+var V1 Representation = v1{}
+
+type v1 struct{}
+
+func (v1) Engine(engine *Engine) any {
+	return engine
+}
+
+func (v1) EngineUsage(engine *EngineUsage) any {
+	return engine
+}
@@ -2,27 +2,44 @@ package engservices
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
 	"github.com/codepnw/go-car-management/modules/engines"
 	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
-	"github.com/go-playground/validator/v10"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/codepnw/go-car-management/patch"
+	"github.com/codepnw/go-car-management/validation"
+	"github.com/google/uuid"
 )
 
 type IEngineService interface {
 	GetEngineByID(ctx context.Context, id string) (*engines.Engine, error)
 	CreateEngine(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error)
-	UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest) (*engines.Engine, error)
-	DeleteEngine(ctx context.Context, id string) (*engines.Engine, error)
+	UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest, version int64) (*engines.Engine, error)
+	PatchEngine(ctx context.Context, id string, p *patch.Patch, version int64) (*engines.Engine, error)
+	// DeleteEngine moves the engine to the trash. The live cars using it are
+	// detached or reassigned in the same transaction as opts says, otherwise
+	// the delete fails with a conflict listing them.
+	DeleteEngine(ctx context.Context, id string, version int64, opts *engines.DeleteOptions) (*engines.Engine, error)
+	RestoreEngine(ctx context.Context, id string) (*engines.Engine, error)
+	PurgeEngines(ctx context.Context, retention time.Duration) (int, error)
+	ListEngines(ctx context.Context, filter *engines.EngineFilter) (*pagination.Page[*engines.EngineUsage], error)
 }
 
 type engineService struct {
-	repo engrepositories.IEngineRepository
+	repo    engrepositories.IEngineRepository
+	carRepo carrepositories.ICarRepository
+	tx      database.Transactor
 }
 
-var validate *validator.Validate
-
-func NewEngineService(repo engrepositories.IEngineRepository) IEngineService {
-	return &engineService{repo: repo}
+func NewEngineService(repo engrepositories.IEngineRepository, carRepo carrepositories.ICarRepository, tx database.Transactor) IEngineService {
+	return &engineService{repo: repo, carRepo: carRepo, tx: tx}
 }
 
 func (s *engineService) GetEngineByID(ctx context.Context, id string) (*engines.Engine, error) {
@@ -34,11 +51,7 @@ func (s *engineService) GetEngineByID(ctx context.Context, id string) (*engines.
 }
 
 func (s *engineService) CreateEngine(ctx context.Context, req *engines.EngineRequest) (*engines.Engine, error) {
-	err := validate.Struct(req)
-	if err != nil {
-		if _, ok := err.(*validator.InvalidValidationError); ok {
-			return nil, err
-		}
+	if err := validation.Struct(req); err != nil {
 		return nil, err
 	}
 
@@ -49,26 +62,144 @@ func (s *engineService) CreateEngine(ctx context.Context, req *engines.EngineReq
 	return createdEngine, nil
 }
 
-func (s *engineService) UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest) (*engines.Engine, error) {
-	err := validate.Struct(req)
-	if err != nil {
-		if _, ok := err.(*validator.InvalidValidationError); ok {
-			return nil, err
-		}
+func (s *engineService) UpdateEngine(ctx context.Context, id string, req *engines.EngineRequest, version int64) (*engines.Engine, error) {
+	if err := validation.Struct(req); err != nil {
 		return nil, err
 	}
 
-	updatedEngine, err := s.repo.UpdateEngine(ctx, id, req)
+	updatedEngine, err := s.repo.UpdateEngine(ctx, id, req, version)
 	if err != nil {
 		return nil, err
 	}
 	return updatedEngine, nil
 }
 
-func (s *engineService) DeleteEngine(ctx context.Context, id string) (*engines.Engine, error) {
-	deletedEngine, err := s.repo.DeleteEngine(ctx, id)
+// PatchEngine applies p to the current engine. Only the fields the patch
+// changes, and the rules that depend on them, are validated and written.
+func (s *engineService) PatchEngine(ctx context.Context, id string, p *patch.Patch, version int64) (*engines.Engine, error) {
+	current, err := s.repo.GetEngineByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != etag.Any && current.Version != version {
+		return nil, engines.ErrEngineModified
+	}
+
+	req := current.Request()
+	changed, err := p.ApplyTo(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		return current, nil
+	}
+
+	if err := validation.StructPartial(req, engines.PatchValidationFields(changed)...); err != nil {
+		return nil, err
+	}
+
+	patchedEngine, err := s.repo.PatchEngine(ctx, id, engines.NewEnginePatch(req, changed), current.Version)
+	if err != nil {
+		return nil, err
+	}
+	return patchedEngine, nil
+}
+
+func (s *engineService) DeleteEngine(ctx context.Context, id string, version int64, opts *engines.DeleteOptions) (*engines.Engine, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	var deletedEngine *engines.Engine
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if opts.Cascade != "" || opts.ReassignTo != nil {
+			engineID, err := uuid.Parse(id)
+			if err != nil {
+				return apperrors.InvalidID("engine", err)
+			}
+			if err := s.moveCars(ctx, engineID, opts); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		deletedEngine, err = s.repo.DeleteEngine(ctx, id, version)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return deletedEngine, nil
 }
+
+// moveCars detaches the live cars using the engine, or reassigns them to
+// the engine in opts.ReassignTo, which must suit the fuel type of each.
+func (s *engineService) moveCars(ctx context.Context, engineID uuid.UUID, opts *engines.DeleteOptions) error {
+	if opts.ReassignTo == nil {
+		_, err := s.carRepo.ReplaceEngine(ctx, engineID, nil)
+		return err
+	}
+
+	if *opts.ReassignTo == engineID {
+		return reassignError("reassignTo must be another engine")
+	}
+	target, err := s.repo.GetEngineByID(ctx, opts.ReassignTo.String())
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return reassignError("engine does not exist")
+		}
+		return err
+	}
+
+	moved, err := s.carRepo.ReplaceEngine(ctx, engineID, &target.EngineID)
+	if err != nil {
+		return err
+	}
+	for _, car := range moved {
+		req := car.Request()
+		req.Engine = target
+		if err := validation.StructPartial(req, "fuelType"); err != nil {
+			return reassignError(fmt.Sprintf("car %s runs on %s, which the engine does not suit", car.CarID, car.FuelType))
+		}
+	}
+	return nil
+}
+
+func reassignError(message string) error {
+	return apperrors.Validation(nil, apperrors.FieldError{Field: "reassignTo", Message: message})
+}
+
+func (s *engineService) RestoreEngine(ctx context.Context, id string) (*engines.Engine, error) {
+	restoredEngine, err := s.repo.RestoreEngine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return restoredEngine, nil
+}
+
+// PurgeEngines permanently deletes the engines that have been in the trash
+// for longer than retention and are no longer referenced by any car.
+func (s *engineService) PurgeEngines(ctx context.Context, retention time.Duration) (int, error) {
+	return s.repo.PurgeEngines(ctx, time.Now().Add(-retention))
+}
+
+func (s *engineService) ListEngines(ctx context.Context, filter *engines.EngineFilter) (*pagination.Page[*engines.EngineUsage], error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Ask for one extra row to learn whether another page follows.
+	query := *filter
+	query.Limit++
+
+	results, total, err := s.repo.ListEngines(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(results, total, filter.Limit, filter.Offset, filter.Sort, filter.Desc, filter.Cursor,
+		func(engine *engines.EngineUsage) (any, uuid.UUID) {
+			return engine.SortValue(filter.Sort), engine.EngineID
+		},
+	), nil
+}
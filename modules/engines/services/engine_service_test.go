@@ -0,0 +1,151 @@
+package engservices
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	"github.com/codepnw/go-car-management/modules/engines"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/google/uuid"
+)
+
+type fixture struct {
+	service IEngineService
+	engines engrepositories.IEngineRepository
+	cars    carrepositories.ICarRepository
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	db := memdb.New()
+	engineRepo := engrepositories.NewEngineMemoryRepository(db)
+	carRepo := carrepositories.NewCarMemoryRepository(db)
+	return &fixture{service: NewEngineService(engineRepo, carRepo, db), engines: engineRepo, cars: carRepo}
+}
+
+func (f *fixture) engine(t *testing.T, req *engines.EngineRequest) *engines.Engine {
+	t.Helper()
+
+	engine, err := f.engines.CreateEngine(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	return engine
+}
+
+func (f *fixture) car(t *testing.T, fuelType string, engine *engines.Engine) *cars.Car {
+	t.Helper()
+
+	car, err := f.cars.CreateCar(context.Background(), &cars.CarRequest{
+		Name:     "Civic",
+		Year:     2020,
+		Brand:    "Honda",
+		FuelType: fuelType,
+		Engine:   &engines.Engine{EngineID: engine.EngineID},
+		Price:    25999.99,
+	})
+	if err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+	return car
+}
+
+func (f *fixture) carEngine(t *testing.T, car *cars.Car) *engines.Engine {
+	t.Helper()
+
+	got, err := f.cars.GetCarById(context.Background(), car.CarID.String())
+	if err != nil {
+		t.Fatalf("GetCarById: %v", err)
+	}
+	return got.Engine
+}
+
+func TestDeleteEngineInUse(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	engine := f.engine(t, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	f.car(t, "Petrol", engine)
+
+	_, err := f.service.DeleteEngine(ctx, engine.EngineID.String(), etag.Any, &engines.DeleteOptions{})
+	if !errors.Is(err, apperrors.ErrConflict) {
+		t.Fatalf("DeleteEngine error = %v, want %v", err, apperrors.ErrConflict)
+	}
+
+	invalid := []*engines.DeleteOptions{
+		{Cascade: "delete"},
+		{Cascade: engines.CascadeDetach, ReassignTo: &engine.EngineID},
+		{ReassignTo: &engine.EngineID},
+	}
+	for _, opts := range invalid {
+		_, err := f.service.DeleteEngine(ctx, engine.EngineID.String(), etag.Any, opts)
+		if !errors.Is(err, apperrors.ErrValidation) {
+			t.Fatalf("DeleteEngine(%+v) error = %v, want %v", opts, err, apperrors.ErrValidation)
+		}
+	}
+}
+
+func TestDeleteEngineDetach(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	engine := f.engine(t, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	car := f.car(t, "Petrol", engine)
+
+	_, err := f.service.DeleteEngine(ctx, engine.EngineID.String(), etag.Any, &engines.DeleteOptions{Cascade: engines.CascadeDetach})
+	if err != nil {
+		t.Fatalf("DeleteEngine: %v", err)
+	}
+	if got := f.carEngine(t, car); got != nil {
+		t.Fatalf("car engine after detach = %+v, want none", got)
+	}
+}
+
+func TestDeleteEngineReassign(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	engine := f.engine(t, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+	petrol := f.engine(t, &engines.EngineRequest{Displacement: 2487, NoOfCylinders: 4, CarRange: 700})
+	electric := f.engine(t, &engines.EngineRequest{CarRange: 500})
+	car := f.car(t, "Petrol", engine)
+
+	missing := uuid.New()
+	_, err := f.service.DeleteEngine(ctx, engine.EngineID.String(), etag.Any, &engines.DeleteOptions{ReassignTo: &missing})
+	assertFieldError(t, err, "reassignTo")
+
+	// A petrol car cannot move to an electric engine; nothing is changed.
+	_, err = f.service.DeleteEngine(ctx, engine.EngineID.String(), etag.Any, &engines.DeleteOptions{ReassignTo: &electric.EngineID})
+	assertFieldError(t, err, "reassignTo")
+	if got := f.carEngine(t, car); got == nil || got.EngineID != engine.EngineID {
+		t.Fatalf("car engine after failed reassign = %+v, want %s", got, engine.EngineID)
+	}
+	if _, err := f.engines.GetEngineByID(ctx, engine.EngineID.String()); err != nil {
+		t.Fatalf("GetEngineByID after failed reassign: %v", err)
+	}
+
+	_, err = f.service.DeleteEngine(ctx, engine.EngineID.String(), etag.Any, &engines.DeleteOptions{ReassignTo: &petrol.EngineID})
+	if err != nil {
+		t.Fatalf("DeleteEngine: %v", err)
+	}
+	if got := f.carEngine(t, car); got == nil || got.EngineID != petrol.EngineID {
+		t.Fatalf("car engine after reassign = %+v, want %s", got, petrol.EngineID)
+	}
+	_, err = f.engines.GetEngineByID(ctx, engine.EngineID.String())
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Fatalf("GetEngineByID after delete error = %v, want %v", err, apperrors.ErrNotFound)
+	}
+}
+
+func assertFieldError(t *testing.T, err error, field string) {
+	t.Helper()
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation || len(appErr.Fields) == 0 || appErr.Fields[0].Field != field {
+		t.Fatalf("error = %v, want a %s validation error", err, field)
+	}
+}
@@ -0,0 +1,67 @@
+package jobhandlers
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"time"
+
+	jobservices "github.com/codepnw/go-car-management/modules/jobs/services"
+	"github.com/gin-gonic/gin"
+)
+
+// artifactTimeout bounds the download of an artifact, which is streamed
+// from storage.
+const artifactTimeout = 10 * time.Minute
+
+type jobHandler struct {
+	service jobservices.IJobService
+}
+
+func NewJobHandler(service jobservices.IJobService) *jobHandler {
+	return &jobHandler{service: service}
+}
+
+func (h *jobHandler) GetJob(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.service.GetJob(ctx, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// CancelJob cancels a queued job right away. A running job stops at its
+// next heartbeat, so the response is only an acknowledgement.
+func (h *jobHandler) CancelJob(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := h.service.CancelJob(ctx, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": job})
+}
+
+// GetArtifact downloads the file a succeeded job produced.
+func (h *jobHandler) GetArtifact(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), artifactTimeout)
+	defer cancel()
+
+	artifact, file, err := h.service.OpenArtifact(ctx, c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, artifact.Size, artifact.ContentType, file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": artifact.Name}),
+	})
+}
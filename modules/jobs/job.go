@@ -0,0 +1,130 @@
+// Package jobs runs long imports and exports in the background. Jobs and
+// their files are stored in the database: a job queued or running when the
+// process stops is picked up again when it restarts, and its result stays
+// available to download.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindExport Kind = "export"
+	KindImport Kind = "import"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// Finished reports whether a job in status s is done for good.
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCanceled
+}
+
+// The files of a job.
+const (
+	// InputFile is the file a job was submitted with, such as the CSV of an
+	// import.
+	InputFile = "input"
+	// ArtifactFile is the file a job produces, such as the file of an export.
+	ArtifactFile = "artifact"
+)
+
+type Job struct {
+	JobID  uuid.UUID `json:"jobId"`
+	Kind   Kind      `json:"kind"`
+	Status Status    `json:"status"`
+	// Params are the options the job was submitted with, read by its runner.
+	Params json.RawMessage `json:"-"`
+	// Processed counts the rows the job has handled so far.
+	Processed int64 `json:"processed"`
+	// Result is what the runner reported last: its progress while the job
+	// runs, its outcome once it succeeded.
+	Result          json.RawMessage `json:"result,omitempty"`
+	Error           *Failure        `json:"error,omitempty"`
+	Artifact        *Artifact       `json:"artifact,omitempty"`
+	CancelRequested bool            `json:"cancelRequested"`
+	// Attempt counts the runs of the job. A job is run again when the
+	// process running it stopped before it finished.
+	Attempt    int        `json:"attempt"`
+	LeaseUntil *time.Time `json:"-"`
+	Actor      string     `json:"actor"`
+	RequestID  string     `json:"requestId,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// Failure is why a job failed, as the API would have reported it.
+type Failure struct {
+	Code    apperrors.Code         `json:"code"`
+	Message string                 `json:"message"`
+	Errors  []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// Artifact describes the ArtifactFile of a succeeded job.
+type Artifact struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+// Progress takes how far a running job got. Results must be marshalable to
+// JSON; they are saved as is, and a job resumed after a restart gets the last
+// one saved.
+type Progress interface {
+	// Report records the progress, which is saved with the next heartbeat of
+	// the run.
+	Report(processed int64, result any)
+	// Save records the progress and saves it before returning. Runners call
+	// it right after committing work that must not be done again when the
+	// job is resumed.
+	Save(ctx context.Context, processed int64, result any) error
+}
+
+// Files gives a runner the files of its job.
+type Files interface {
+	Open(ctx context.Context, name string) io.Reader
+	// Create replaces the file name; it is complete once the writer is
+	// closed.
+	Create(ctx context.Context, name string) FileWriter
+}
+
+type FileWriter interface {
+	io.WriteCloser
+	// Size is the number of bytes written so far.
+	Size() int64
+}
+
+// Runner does the work of one kind of job. A job interrupted by a restart is
+// run again with the Processed and Result of its last saved progress, so a
+// runner either resumes from there or starts over. The returned result is
+// saved as the Result of the job; artifact, when not nil, describes the
+// ArtifactFile the runner wrote.
+type Runner interface {
+	Run(ctx context.Context, job *Job, files Files, progress Progress) (result any, artifact *Artifact, err error)
+}
+
+var (
+	ErrJobFinished = apperrors.Conflict("the job has already finished")
+	ErrNoArtifact  = apperrors.NotFound("the job has no artifact")
+	// ErrJobLost is returned when the job of a run was taken over, after
+	// its lease expired, by another run.
+	ErrJobLost = apperrors.Conflict("the job is no longer held by this run")
+)
@@ -0,0 +1,256 @@
+package jobrepositories
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/google/uuid"
+)
+
+type jobMemoryRepository struct {
+	db *memdb.DB
+}
+
+func NewJobMemoryRepository(db *memdb.DB) IJobRepository {
+	return &jobMemoryRepository{db: db}
+}
+
+func (r *jobMemoryRepository) CreateJob(ctx context.Context, job *jobs.Job) (*jobs.Job, error) {
+	now := time.Now()
+	row := memdb.JobRow{
+		JobID:     job.JobID,
+		Kind:      string(job.Kind),
+		Status:    string(jobs.StatusQueued),
+		Params:    job.Params,
+		Actor:     job.Actor,
+		RequestID: job.RequestID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		tx.Jobs.Put(row.JobID, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobFromRow(row)
+}
+
+func (r *jobMemoryRepository) GetJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error) {
+	var row memdb.JobRow
+	var ok bool
+	err := r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		row, ok = tx.Jobs.Get(jobID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errJobNotFound
+	}
+	return jobFromRow(row)
+}
+
+func (r *jobMemoryRepository) ClaimJob(ctx context.Context, lease time.Duration) (*jobs.Job, error) {
+	var claimed *memdb.JobRow
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		now := time.Now()
+		tx.Jobs.Scan(func(_ uuid.UUID, row memdb.JobRow) bool {
+			waiting := row.Status == string(jobs.StatusQueued) ||
+				(row.Status == string(jobs.StatusRunning) && row.LeaseUntil != nil && row.LeaseUntil.Before(now))
+			if waiting && (claimed == nil || row.CreatedAt.Before(claimed.CreatedAt)) {
+				claimed = &row
+			}
+			return true
+		})
+		if claimed == nil {
+			return nil
+		}
+
+		leaseUntil := now.Add(lease)
+		claimed.Status = string(jobs.StatusRunning)
+		claimed.Attempt++
+		claimed.LeaseUntil = &leaseUntil
+		if claimed.StartedAt == nil {
+			claimed.StartedAt = &now
+		}
+		claimed.UpdatedAt = now
+		tx.Jobs.Put(claimed.JobID, *claimed)
+		return nil
+	})
+	if err != nil || claimed == nil {
+		return nil, err
+	}
+	return jobFromRow(*claimed)
+}
+
+func (r *jobMemoryRepository) SaveProgress(ctx context.Context, jobID uuid.UUID, attempt int, processed int64, result []byte, lease time.Duration) (*jobs.Job, error) {
+	return r.updateRun(ctx, jobID, attempt, func(row *memdb.JobRow, now time.Time) {
+		leaseUntil := now.Add(lease)
+		row.Processed = processed
+		row.Result = result
+		row.LeaseUntil = &leaseUntil
+	})
+}
+
+func (r *jobMemoryRepository) FinishJob(ctx context.Context, job *jobs.Job) (*jobs.Job, error) {
+	failure, err := marshalNullable(job.Error)
+	if err != nil {
+		return nil, err
+	}
+	artifact, err := marshalNullable(job.Artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.updateRun(ctx, job.JobID, job.Attempt, func(row *memdb.JobRow, now time.Time) {
+		row.Status = string(job.Status)
+		row.Processed = job.Processed
+		row.Result = job.Result
+		row.Error = failure
+		row.Artifact = artifact
+		row.LeaseUntil = nil
+		row.FinishedAt = &now
+	})
+}
+
+// updateRun applies update to a job still held by its run attempt.
+func (r *jobMemoryRepository) updateRun(ctx context.Context, jobID uuid.UUID, attempt int, update func(row *memdb.JobRow, now time.Time)) (*jobs.Job, error) {
+	var row memdb.JobRow
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		var ok bool
+		row, ok = tx.Jobs.Get(jobID)
+		if !ok || row.Attempt != attempt || row.Status != string(jobs.StatusRunning) {
+			return jobs.ErrJobLost
+		}
+
+		now := time.Now()
+		update(&row, now)
+		row.UpdatedAt = now
+		tx.Jobs.Put(jobID, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobFromRow(row)
+}
+
+func (r *jobMemoryRepository) CancelJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error) {
+	var row memdb.JobRow
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		var ok bool
+		row, ok = tx.Jobs.Get(jobID)
+		if !ok {
+			return errJobNotFound
+		}
+		if jobs.Status(row.Status).Finished() {
+			return jobs.ErrJobFinished
+		}
+
+		now := time.Now()
+		row.CancelRequested = true
+		if row.Status == string(jobs.StatusQueued) {
+			row.Status = string(jobs.StatusCanceled)
+			row.FinishedAt = &now
+		}
+		row.UpdatedAt = now
+		tx.Jobs.Put(jobID, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobFromRow(row)
+}
+
+func (r *jobMemoryRepository) PurgeJobs(ctx context.Context, finishedBefore time.Time) (int, error) {
+	purged := 0
+	err := r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		tx.Jobs.Scan(func(id uuid.UUID, row memdb.JobRow) bool {
+			if row.FinishedAt != nil && row.FinishedAt.Before(finishedBefore) {
+				tx.Jobs.Delete(id)
+				purged++
+			}
+			return true
+		})
+
+		tx.JobFiles.Scan(func(key memdb.JobFileKey, row memdb.JobFileRow) bool {
+			if _, ok := tx.Jobs.Get(key.JobID); !ok && row.CreatedAt.Before(finishedBefore) {
+				tx.JobFiles.Delete(key)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+func (r *jobMemoryRepository) WriteChunk(ctx context.Context, jobID uuid.UUID, name string, seq int, data []byte) error {
+	return r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		tx.JobFiles.Put(memdb.JobFileKey{JobID: jobID, Name: name, Seq: seq}, memdb.JobFileRow{
+			Data:      append([]byte{}, data...),
+			CreatedAt: time.Now(),
+		})
+		return nil
+	})
+}
+
+func (r *jobMemoryRepository) ReadChunk(ctx context.Context, jobID uuid.UUID, name string, seq int) ([]byte, error) {
+	var row memdb.JobFileRow
+	var ok bool
+	err := r.db.ViewContext(ctx, func(tx *memdb.Tx) error {
+		row, ok = tx.JobFiles.Get(memdb.JobFileKey{JobID: jobID, Name: name, Seq: seq})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, io.EOF
+	}
+	return row.Data, nil
+}
+
+func (r *jobMemoryRepository) DeleteFile(ctx context.Context, jobID uuid.UUID, name string) error {
+	return r.db.UpdateContext(ctx, func(tx *memdb.Tx) error {
+		tx.JobFiles.Scan(func(key memdb.JobFileKey, _ memdb.JobFileRow) bool {
+			if key.JobID == jobID && key.Name == name {
+				tx.JobFiles.Delete(key)
+			}
+			return true
+		})
+		return nil
+	})
+}
+
+func jobFromRow(row memdb.JobRow) (*jobs.Job, error) {
+	job := &jobs.Job{
+		JobID:           row.JobID,
+		Kind:            jobs.Kind(row.Kind),
+		Status:          jobs.Status(row.Status),
+		Processed:       row.Processed,
+		CancelRequested: row.CancelRequested,
+		Attempt:         row.Attempt,
+		LeaseUntil:      row.LeaseUntil,
+		Actor:           row.Actor,
+		RequestID:       row.RequestID,
+		CreatedAt:       row.CreatedAt,
+		StartedAt:       row.StartedAt,
+		FinishedAt:      row.FinishedAt,
+		UpdatedAt:       row.UpdatedAt,
+	}
+	if err := unmarshalJobJSON(job, row.Params, row.Result, row.Error, row.Artifact); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
@@ -0,0 +1,338 @@
+package jobrepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type IJobRepository interface {
+	// CreateJob stores a new queued job.
+	CreateJob(ctx context.Context, job *jobs.Job) (*jobs.Job, error)
+	GetJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error)
+	// ClaimJob starts a new run of the oldest job that is queued, or running
+	// with an expired lease, holding it for lease. It returns nil when no job
+	// is waiting.
+	ClaimJob(ctx context.Context, lease time.Duration) (*jobs.Job, error)
+	// SaveProgress records the progress of the run attempt of a job, extends
+	// its lease and returns the job. It fails with jobs.ErrJobLost when the
+	// run no longer holds the job.
+	SaveProgress(ctx context.Context, jobID uuid.UUID, attempt int, processed int64, result []byte, lease time.Duration) (*jobs.Job, error)
+	// FinishJob ends the run job.Attempt of job with its Status, Processed,
+	// Result, Error and Artifact. It fails with jobs.ErrJobLost when the run
+	// no longer holds the job.
+	FinishJob(ctx context.Context, job *jobs.Job) (*jobs.Job, error)
+	// CancelJob cancels a queued job and asks a running one to stop. It
+	// fails with jobs.ErrJobFinished when the job is already done.
+	CancelJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error)
+	// PurgeJobs deletes the jobs finished before finishedBefore with their
+	// files, and the files left without a job since then, and returns how
+	// many jobs there were.
+	PurgeJobs(ctx context.Context, finishedBefore time.Time) (int, error)
+
+	// Files are stored in chunks numbered from 0.
+	WriteChunk(ctx context.Context, jobID uuid.UUID, name string, seq int, data []byte) error
+	// ReadChunk returns io.EOF past the last chunk of the file.
+	ReadChunk(ctx context.Context, jobID uuid.UUID, name string, seq int) ([]byte, error)
+	DeleteFile(ctx context.Context, jobID uuid.UUID, name string) error
+}
+
+var errJobNotFound = apperrors.NotFound("job not found")
+
+type jobRepository struct {
+	db *database.TxManager
+}
+
+func NewJobRepository(db *sql.DB) IJobRepository {
+	return &jobRepository{db: database.NewTxManager(db)}
+}
+
+const jobColumns = `job_id, kind, status, params, processed, result, error, artifact, cancel_requested, attempt,
+	lease_until, actor, request_id, created_at, started_at, finished_at, updated_at`
+
+func (r *jobRepository) CreateJob(ctx context.Context, job *jobs.Job) (*jobs.Job, error) {
+	return scanJob(r.db.Querier(ctx).QueryRowContext(
+		ctx,
+		`INSERT INTO jobs (job_id, kind, status, params, actor, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+jobColumns+`;`,
+		job.JobID,
+		job.Kind,
+		jobs.StatusQueued,
+		[]byte(job.Params),
+		job.Actor,
+		sql.NullString{String: job.RequestID, Valid: job.RequestID != ""},
+	))
+}
+
+func (r *jobRepository) GetJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error) {
+	job, err := scanJob(r.db.Querier(ctx).QueryRowContext(
+		ctx,
+		`SELECT `+jobColumns+` FROM jobs WHERE job_id = $1;`,
+		jobID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errJobNotFound
+	}
+	return job, err
+}
+
+func (r *jobRepository) ClaimJob(ctx context.Context, lease time.Duration) (*jobs.Job, error) {
+	// SKIP LOCKED lets several workers claim jobs at once without waiting
+	// on each other.
+	job, err := scanJob(r.db.Querier(ctx).QueryRowContext(
+		ctx,
+		`UPDATE jobs SET status = 'running', attempt = attempt + 1, started_at = coalesce(started_at, now()),
+			lease_until = now() + $1::float8 * interval '1 millisecond', updated_at = now()
+		WHERE job_id = (
+			SELECT job_id FROM jobs
+			WHERE status = 'queued' OR (status = 'running' AND lease_until < now())
+			ORDER BY created_at LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns+`;`,
+		lease.Milliseconds(),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return job, err
+}
+
+func (r *jobRepository) SaveProgress(ctx context.Context, jobID uuid.UUID, attempt int, processed int64, result []byte, lease time.Duration) (*jobs.Job, error) {
+	job, err := scanJob(r.db.Querier(ctx).QueryRowContext(
+		ctx,
+		`UPDATE jobs SET processed = $3, result = $4, lease_until = now() + $5::float8 * interval '1 millisecond', updated_at = now()
+		WHERE job_id = $1 AND attempt = $2 AND status = 'running'
+		RETURNING `+jobColumns+`;`,
+		jobID,
+		attempt,
+		processed,
+		nullJSON(result),
+		lease.Milliseconds(),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, jobs.ErrJobLost
+	}
+	return job, err
+}
+
+func (r *jobRepository) FinishJob(ctx context.Context, job *jobs.Job) (*jobs.Job, error) {
+	failure, err := marshalNullable(job.Error)
+	if err != nil {
+		return nil, err
+	}
+	artifact, err := marshalNullable(job.Artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	finished, err := scanJob(r.db.Querier(ctx).QueryRowContext(
+		ctx,
+		`UPDATE jobs SET status = $3, processed = $4, result = $5, error = $6, artifact = $7,
+			lease_until = NULL, finished_at = now(), updated_at = now()
+		WHERE job_id = $1 AND attempt = $2 AND status = 'running'
+		RETURNING `+jobColumns+`;`,
+		job.JobID,
+		job.Attempt,
+		job.Status,
+		job.Processed,
+		nullJSON(job.Result),
+		nullJSON(failure),
+		nullJSON(artifact),
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, jobs.ErrJobLost
+	}
+	return finished, err
+}
+
+func (r *jobRepository) CancelJob(ctx context.Context, jobID uuid.UUID) (*jobs.Job, error) {
+	var job *jobs.Job
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var status jobs.Status
+		err := tx.QueryRowContext(ctx, `SELECT status FROM jobs WHERE job_id = $1 FOR UPDATE;`, jobID).Scan(&status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errJobNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if status.Finished() {
+			return jobs.ErrJobFinished
+		}
+
+		// A queued job is canceled on the spot; a running one stops when
+		// its run next saves its progress.
+		job, err = scanJob(tx.QueryRowContext(
+			ctx,
+			`UPDATE jobs SET cancel_requested = true, updated_at = now(),
+				status = CASE WHEN status = 'queued' THEN 'canceled' ELSE status END,
+				finished_at = CASE WHEN status = 'queued' THEN now() END
+			WHERE job_id = $1
+			RETURNING `+jobColumns+`;`,
+			jobID,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *jobRepository) PurgeJobs(ctx context.Context, finishedBefore time.Time) (int, error) {
+	var purged int
+	err := r.db.InTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `DELETE FROM jobs WHERE finished_at < $1 RETURNING job_id;`, finishedBefore)
+		if err != nil {
+			return err
+		}
+		var ids []uuid.UUID
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		purged = len(ids)
+
+		_, err = tx.ExecContext(
+			ctx,
+			`DELETE FROM job_files f
+			WHERE f.job_id = ANY($1::uuid[])
+				OR (f.created_at < $2 AND NOT EXISTS (SELECT 1 FROM jobs j WHERE j.job_id = f.job_id));`,
+			pq.Array(ids),
+			finishedBefore,
+		)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+func (r *jobRepository) WriteChunk(ctx context.Context, jobID uuid.UUID, name string, seq int, data []byte) error {
+	_, err := r.db.Querier(ctx).ExecContext(
+		ctx,
+		`INSERT INTO job_files (job_id, name, seq, data) VALUES ($1, $2, $3, $4);`,
+		jobID,
+		name,
+		seq,
+		data,
+	)
+	return err
+}
+
+func (r *jobRepository) ReadChunk(ctx context.Context, jobID uuid.UUID, name string, seq int) ([]byte, error) {
+	var data []byte
+	err := r.db.Querier(ctx).QueryRowContext(
+		ctx,
+		`SELECT data FROM job_files WHERE job_id = $1 AND name = $2 AND seq = $3;`,
+		jobID,
+		name,
+		seq,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, io.EOF
+	}
+	return data, err
+}
+
+func (r *jobRepository) DeleteFile(ctx context.Context, jobID uuid.UUID, name string) error {
+	_, err := r.db.Querier(ctx).ExecContext(ctx, `DELETE FROM job_files WHERE job_id = $1 AND name = $2;`, jobID, name)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (*jobs.Job, error) {
+	var job jobs.Job
+	var params, result, failure, artifact []byte
+	var requestID sql.NullString
+
+	err := row.Scan(
+		&job.JobID,
+		&job.Kind,
+		&job.Status,
+		&params,
+		&job.Processed,
+		&result,
+		&failure,
+		&artifact,
+		&job.CancelRequested,
+		&job.Attempt,
+		&job.LeaseUntil,
+		&job.Actor,
+		&requestID,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.RequestID = requestID.String
+	if err := unmarshalJobJSON(&job, params, result, failure, artifact); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// unmarshalJobJSON fills in the fields of job stored as JSON, which are nil
+// when the column is NULL.
+func unmarshalJobJSON(job *jobs.Job, params, result, failure, artifact []byte) error {
+	job.Params = params
+	job.Result = result
+
+	if failure != nil {
+		job.Error = &jobs.Failure{}
+		if err := json.Unmarshal(failure, job.Error); err != nil {
+			return err
+		}
+	}
+	if artifact != nil {
+		job.Artifact = &jobs.Artifact{}
+		if err := json.Unmarshal(artifact, job.Artifact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalNullable returns the JSON of v, or nil for a nil pointer.
+func marshalNullable[T any](v *T) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// nullJSON passes nil JSON as NULL; a nil []byte would be sent as an empty
+// string, which is not valid JSON.
+func nullJSON(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,21 @@
+package jobrepositories_test
+
+import (
+	"testing"
+
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/jobs/jobrepositories"
+	"github.com/codepnw/go-car-management/modules/repotest"
+)
+
+func TestJobRepository(t *testing.T) {
+	repotest.RunJobRepositoryTests(t, func(t *testing.T) *repotest.Repositories {
+		return &repotest.Repositories{Job: jobrepositories.NewJobRepository(repotest.Postgres(t))}
+	})
+}
+
+func TestJobMemoryRepository(t *testing.T) {
+	repotest.RunJobRepositoryTests(t, func(t *testing.T) *repotest.Repositories {
+		return &repotest.Repositories{Job: jobrepositories.NewJobMemoryRepository(memdb.New())}
+	})
+}
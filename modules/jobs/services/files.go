@@ -0,0 +1,106 @@
+package jobservices
+
+import (
+	"context"
+	"io"
+
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/codepnw/go-car-management/modules/jobs/jobrepositories"
+	"github.com/google/uuid"
+)
+
+// chunkSize is how much of a file one stored chunk holds, and so about how
+// much of it is in memory at once.
+const chunkSize = 1 << 20
+
+// jobFiles are the files of one job.
+type jobFiles struct {
+	repo  jobrepositories.IJobRepository
+	jobID uuid.UUID
+}
+
+func (f *jobFiles) Open(ctx context.Context, name string) io.Reader {
+	return &fileReader{ctx: ctx, repo: f.repo, jobID: f.jobID, name: name}
+}
+
+func (f *jobFiles) Create(ctx context.Context, name string) jobs.FileWriter {
+	w := &fileWriter{ctx: ctx, repo: f.repo, jobID: f.jobID, name: name, buf: make([]byte, 0, chunkSize)}
+	// A file left from an earlier run of the job is replaced.
+	w.err = f.repo.DeleteFile(ctx, f.jobID, name)
+	return w
+}
+
+// fileReader reads a file one chunk at a time.
+type fileReader struct {
+	ctx   context.Context
+	repo  jobrepositories.IJobRepository
+	jobID uuid.UUID
+	name  string
+	seq   int
+	chunk []byte
+	err   error
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.chunk, r.err = r.repo.ReadChunk(r.ctx, r.jobID, r.name, r.seq)
+		r.seq++
+	}
+
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+// fileWriter stores a file as it is written, a chunk at a time.
+type fileWriter struct {
+	ctx   context.Context
+	repo  jobrepositories.IJobRepository
+	jobID uuid.UUID
+	name  string
+	seq   int
+	buf   []byte
+	size  int64
+	err   error
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.err != nil {
+			return written, w.err
+		}
+
+		n := min(len(p), chunkSize-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		w.size += int64(n)
+
+		if len(w.buf) == chunkSize {
+			w.flush()
+		}
+	}
+	return written, w.err
+}
+
+func (w *fileWriter) flush() {
+	if w.err != nil || len(w.buf) == 0 {
+		return
+	}
+	w.err = w.repo.WriteChunk(w.ctx, w.jobID, w.name, w.seq, w.buf)
+	w.seq++
+	w.buf = w.buf[:0]
+}
+
+func (w *fileWriter) Close() error {
+	w.flush()
+	return w.err
+}
+
+func (w *fileWriter) Size() int64 {
+	return w.size
+}
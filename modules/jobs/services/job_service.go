@@ -0,0 +1,100 @@
+package jobservices
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/codepnw/go-car-management/modules/jobs/jobrepositories"
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/google/uuid"
+)
+
+type IJobService interface {
+	// SubmitJob queues a job of kind with params, which are stored as JSON.
+	// input, when not nil, is stored as the InputFile of the job first.
+	SubmitJob(ctx context.Context, kind jobs.Kind, params any, input io.Reader) (*jobs.Job, error)
+	GetJob(ctx context.Context, id string) (*jobs.Job, error)
+	CancelJob(ctx context.Context, id string) (*jobs.Job, error)
+	// OpenArtifact returns the artifact of a succeeded job and its file.
+	OpenArtifact(ctx context.Context, id string) (*jobs.Artifact, io.Reader, error)
+	// PurgeJobs deletes the jobs finished longer than retention ago, with
+	// their files, and returns how many there were.
+	PurgeJobs(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type jobService struct {
+	repo jobrepositories.IJobRepository
+}
+
+func NewJobService(repo jobrepositories.IJobRepository) IJobService {
+	return &jobService{repo: repo}
+}
+
+func (s *jobService) SubmitJob(ctx context.Context, kind jobs.Kind, params any, input io.Reader) (*jobs.Job, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &jobs.Job{
+		JobID:     uuid.New(),
+		Kind:      kind,
+		Params:    raw,
+		Actor:     requestctx.Actor(ctx),
+		RequestID: requestctx.RequestID(ctx),
+	}
+
+	// The job is only queued once its input is complete, so no worker can
+	// start on part of it.
+	if input != nil {
+		files := &jobFiles{repo: s.repo, jobID: job.JobID}
+		w := files.Create(ctx, jobs.InputFile)
+		_, err := io.Copy(w, input)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			s.repo.DeleteFile(context.WithoutCancel(ctx), job.JobID, jobs.InputFile)
+			return nil, err
+		}
+	}
+
+	return s.repo.CreateJob(ctx, job)
+}
+
+func (s *jobService) GetJob(ctx context.Context, id string) (*jobs.Job, error) {
+	jobID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperrors.InvalidID("job", err)
+	}
+	return s.repo.GetJob(ctx, jobID)
+}
+
+func (s *jobService) CancelJob(ctx context.Context, id string) (*jobs.Job, error) {
+	jobID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperrors.InvalidID("job", err)
+	}
+	return s.repo.CancelJob(ctx, jobID)
+}
+
+func (s *jobService) OpenArtifact(ctx context.Context, id string) (*jobs.Artifact, io.Reader, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != jobs.StatusSucceeded || job.Artifact == nil {
+		return nil, nil, jobs.ErrNoArtifact
+	}
+
+	files := &jobFiles{repo: s.repo, jobID: job.JobID}
+	return job.Artifact, files.Open(ctx, jobs.ArtifactFile), nil
+}
+
+func (s *jobService) PurgeJobs(ctx context.Context, retention time.Duration) (int, error) {
+	return s.repo.PurgeJobs(ctx, time.Now().Add(-retention))
+}
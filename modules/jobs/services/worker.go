@@ -0,0 +1,287 @@
+package jobservices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/codepnw/go-car-management/modules/jobs/jobrepositories"
+	"github.com/codepnw/go-car-management/requestctx"
+)
+
+// Worker runs the queued jobs with the runner of their kind.
+type Worker struct {
+	repo    jobrepositories.IJobRepository
+	runners map[jobs.Kind]jobs.Runner
+
+	// Concurrency is how many jobs run at once.
+	Concurrency int
+	// PollInterval is how long an idle worker waits before it looks for a
+	// job again.
+	PollInterval time.Duration
+	// Lease is how long a job is held by its run without a heartbeat. A job
+	// whose run stopped with the process is run again once it expires.
+	Lease time.Duration
+	// Heartbeat is how often a run saves its reported progress, renews its
+	// lease and checks whether it was canceled. Progress a runner saves is
+	// written right away.
+	Heartbeat time.Duration
+	// MaxAttempts is how many runs a job gets before it is failed.
+	MaxAttempts int
+}
+
+func NewWorker(repo jobrepositories.IJobRepository, runners map[jobs.Kind]jobs.Runner) *Worker {
+	return &Worker{
+		repo:         repo,
+		runners:      runners,
+		Concurrency:  2,
+		PollInterval: time.Second,
+		Lease:        30 * time.Second,
+		Heartbeat:    5 * time.Second,
+		MaxAttempts:  3,
+	}
+}
+
+var errTooManyAttempts = apperrors.Conflict("the job was interrupted too many times")
+
+// Run runs jobs until ctx is done. The jobs running then are left to be
+// picked up again once their lease expires.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range w.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.repo.ClaimJob(ctx, w.Lease)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("claim job: %v", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.PollInterval):
+			}
+			continue
+		}
+		w.runJob(ctx, job)
+	}
+}
+
+func (w *Worker) runJob(ctx context.Context, job *jobs.Job) {
+	runner, ok := w.runners[job.Kind]
+	switch {
+	case job.CancelRequested:
+		w.finish(ctx, job, jobs.StatusCanceled, nil)
+		return
+	case job.Attempt > w.MaxAttempts:
+		w.finish(ctx, job, jobs.StatusFailed, errTooManyAttempts)
+		return
+	case !ok:
+		// No runner is registered for the kind: the server is misconfigured,
+		// which finish logs as an internal error.
+		w.finish(ctx, job, jobs.StatusFailed, fmt.Errorf("no runner for job kind %q", job.Kind))
+		return
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runCtx = requestctx.WithActor(requestctx.WithRequestID(runCtx, job.RequestID), job.Actor)
+
+	files := &jobFiles{repo: w.repo, jobID: job.JobID}
+	run := &jobRun{
+		repo:      w.repo,
+		job:       job,
+		lease:     w.Lease,
+		cancel:    cancel,
+		processed: job.Processed,
+		result:    job.Result,
+	}
+
+	done := make(chan struct{})
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		w.heartbeat(runCtx, run, done)
+	}()
+
+	result, artifact, err := runner.Run(runCtx, job, files, run)
+	close(done)
+	<-heartbeat
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	job.Processed, job.Result = run.processed, run.result
+
+	switch {
+	case run.lost:
+		log.Printf("job %s: taken over by another run", job.JobID)
+	case err == nil:
+		raw, err := json.Marshal(result)
+		if err != nil {
+			w.finish(ctx, job, jobs.StatusFailed, err)
+			return
+		}
+		job.Result, job.Artifact = raw, artifact
+		w.finish(ctx, job, jobs.StatusSucceeded, nil)
+	case run.canceled:
+		w.finish(ctx, job, jobs.StatusCanceled, nil)
+	case ctx.Err() != nil:
+		// The worker is stopping; the job runs again once its lease expires.
+	default:
+		w.finish(ctx, job, jobs.StatusFailed, err)
+	}
+}
+
+// jobRun is the state a run shares with its heartbeat, and the
+// jobs.Progress of the run.
+type jobRun struct {
+	repo   jobrepositories.IJobRepository
+	job    *jobs.Job
+	lease  time.Duration
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	processed int64
+	result    json.RawMessage
+	canceled  bool
+	lost      bool
+
+	// saveMu orders the saves of the heartbeat and of the runner, so an
+	// older progress never overwrites a newer one.
+	saveMu sync.Mutex
+}
+
+func (run *jobRun) Report(processed int64, result any) {
+	if err := run.record(processed, result); err != nil {
+		log.Printf("job %s: progress: %v", run.job.JobID, err)
+	}
+}
+
+func (run *jobRun) Save(ctx context.Context, processed int64, result any) error {
+	if err := run.record(processed, result); err != nil {
+		return err
+	}
+	return run.save(ctx)
+}
+
+func (run *jobRun) record(processed int64, result any) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	run.mu.Lock()
+	run.processed, run.result = processed, raw
+	run.mu.Unlock()
+	return nil
+}
+
+// save writes the last recorded progress and renews the lease of the job. It
+// stops the run through cancel, and returns an error, when the job was
+// canceled or taken over.
+func (run *jobRun) save(ctx context.Context) error {
+	run.saveMu.Lock()
+	defer run.saveMu.Unlock()
+
+	run.mu.Lock()
+	processed, result := run.processed, run.result
+	run.mu.Unlock()
+
+	saved, err := run.repo.SaveProgress(ctx, run.job.JobID, run.job.Attempt, processed, result, run.lease)
+	switch {
+	case errors.Is(err, jobs.ErrJobLost):
+		run.stop(&run.lost)
+		return err
+	case err != nil:
+		return err
+	case saved.CancelRequested:
+		run.stop(&run.canceled)
+		return context.Canceled
+	}
+	return nil
+}
+
+// stop sets the flag saying why the run stops, then cancels it.
+func (run *jobRun) stop(reason *bool) {
+	run.mu.Lock()
+	*reason = true
+	run.mu.Unlock()
+	run.cancel()
+}
+
+func (run *jobRun) stopped() bool {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return run.canceled || run.lost
+}
+
+// heartbeat saves the progress of run until done is closed or the run is
+// stopped.
+func (w *Worker) heartbeat(ctx context.Context, run *jobRun, done <-chan struct{}) {
+	ticker := time.NewTicker(w.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		err := run.save(ctx)
+		if run.stopped() {
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("job %s: save progress: %v", run.job.JobID, err)
+		}
+	}
+}
+
+// finish ends the run of job with status, failing it with err. The artifact
+// of a job that did not succeed is deleted.
+func (w *Worker) finish(ctx context.Context, job *jobs.Job, status jobs.Status, err error) {
+	// A run that ends as the worker stops still records how it ended.
+	ctx = context.WithoutCancel(ctx)
+
+	job.Status = status
+	if err != nil {
+		job.Error = failure(err)
+		if job.Error.Code == apperrors.CodeInternal {
+			log.Printf("job %s: %v", job.JobID, err)
+		}
+	}
+	if status != jobs.StatusSucceeded {
+		job.Artifact = nil
+		if err := w.repo.DeleteFile(ctx, job.JobID, jobs.ArtifactFile); err != nil {
+			log.Printf("job %s: delete artifact: %v", job.JobID, err)
+		}
+	}
+
+	if _, err := w.repo.FinishJob(ctx, job); err != nil {
+		log.Printf("job %s: finish: %v", job.JobID, err)
+	}
+}
+
+// failure describes err the way the API would: the details of errors that
+// are not an *apperrors.Error are only logged.
+func failure(err error) *jobs.Failure {
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return &jobs.Failure{Code: apperrors.CodeInternal, Message: "the job failed unexpectedly"}
+	}
+	return &jobs.Failure{Code: appErr.Code, Message: appErr.Message, Errors: appErr.Fields}
+}
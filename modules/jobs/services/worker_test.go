@@ -0,0 +1,334 @@
+package jobservices
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/codepnw/go-car-management/modules/jobs/jobrepositories"
+	"github.com/codepnw/go-car-management/requestctx"
+)
+
+// runnerFunc adapts a function to jobs.Runner.
+type runnerFunc func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error)
+
+func (f runnerFunc) Run(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+	return f(ctx, job, files, progress)
+}
+
+func newTestService(t *testing.T) (IJobService, jobrepositories.IJobRepository) {
+	t.Helper()
+
+	repo := jobrepositories.NewJobMemoryRepository(memdb.New())
+	return NewJobService(repo), repo
+}
+
+// startWorker runs a fast worker until the test ends.
+func startWorker(t *testing.T, repo jobrepositories.IJobRepository, runner jobs.Runner) {
+	t.Helper()
+
+	w := NewWorker(repo, map[jobs.Kind]jobs.Runner{jobs.KindImport: runner})
+	w.PollInterval = 5 * time.Millisecond
+	w.Heartbeat = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+}
+
+func waitFinished(t *testing.T, service IJobService, id string) *jobs.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := service.GetJob(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if job.Status.Finished() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s", job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWorkerRunsJob(t *testing.T) {
+	service, repo := newTestService(t)
+
+	// The input spans several stored chunks.
+	input := bytes.Repeat([]byte("0123456789"), chunkSize/4)
+
+	var actor string
+	startWorker(t, repo, runnerFunc(func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+		actor = requestctx.Actor(ctx)
+
+		w := files.Create(ctx, jobs.ArtifactFile)
+		n, err := io.Copy(w, files.Open(ctx, jobs.InputFile))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, nil, err
+		}
+		progress.Report(n, nil)
+		return map[string]int64{"bytes": n}, &jobs.Artifact{Name: "copy.bin", ContentType: "application/octet-stream", Size: w.Size()}, nil
+	}))
+
+	ctx := requestctx.WithActor(context.Background(), "alice")
+	job, err := service.SubmitJob(ctx, jobs.KindImport, map[string]bool{"dryRun": true}, bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	if job.Status != jobs.StatusQueued || job.Actor != "alice" {
+		t.Fatalf("SubmitJob = %+v", job)
+	}
+
+	job = waitFinished(t, service, job.JobID.String())
+	if job.Status != jobs.StatusSucceeded || job.Processed != int64(len(input)) {
+		t.Fatalf("job = %+v, want it succeeded", job)
+	}
+	if string(job.Result) != fmt.Sprintf(`{"bytes":%d}`, len(input)) {
+		t.Fatalf("Result = %s", job.Result)
+	}
+	if actor != "alice" {
+		t.Fatalf("runner actor = %q, want the submitter", actor)
+	}
+
+	artifact, file, err := service.OpenArtifact(context.Background(), job.JobID.String())
+	if err != nil {
+		t.Fatalf("OpenArtifact: %v", err)
+	}
+	got, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("read artifact: %v", err)
+	}
+	if artifact.Size != int64(len(input)) || !bytes.Equal(got, input) {
+		t.Fatalf("artifact = %+v with %d bytes, want a copy of the input", artifact, len(got))
+	}
+}
+
+func TestWorkerFailsJob(t *testing.T) {
+	service, repo := newTestService(t)
+
+	startWorker(t, repo, runnerFunc(func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+		w := files.Create(ctx, jobs.ArtifactFile)
+		w.Write([]byte("partial"))
+		w.Close()
+		return nil, nil, apperrors.Validation(nil, apperrors.FieldError{Field: "engine", Message: "missing"})
+	}))
+
+	job, err := service.SubmitJob(context.Background(), jobs.KindImport, nil, nil)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+
+	job = waitFinished(t, service, job.JobID.String())
+	if job.Status != jobs.StatusFailed || job.Error == nil || job.Error.Code != apperrors.CodeValidation || len(job.Error.Errors) != 1 {
+		t.Fatalf("job = %+v, want it failed with the validation error", job)
+	}
+
+	_, _, err = service.OpenArtifact(context.Background(), job.JobID.String())
+	if !errors.Is(err, jobs.ErrNoArtifact) {
+		t.Fatalf("OpenArtifact: %v, want ErrNoArtifact", err)
+	}
+	if _, err := repo.ReadChunk(context.Background(), job.JobID, jobs.ArtifactFile, 0); !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadChunk(artifact): %v, want the partial artifact deleted", err)
+	}
+}
+
+func TestWorkerFailsJobWithoutRunner(t *testing.T) {
+	service, repo := newTestService(t)
+	startWorker(t, repo, runnerFunc(func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+		return nil, nil, nil
+	}))
+
+	// The worker only has a runner for imports.
+	job, err := service.SubmitJob(context.Background(), jobs.KindExport, nil, nil)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+
+	job = waitFinished(t, service, job.JobID.String())
+	if job.Status != jobs.StatusFailed || job.Error == nil || job.Error.Code != apperrors.CodeInternal {
+		t.Fatalf("job = %+v, want it failed with an internal error", job)
+	}
+}
+
+func TestWorkerCancelsRunningJob(t *testing.T) {
+	service, repo := newTestService(t)
+
+	started := make(chan struct{})
+	startWorker(t, repo, runnerFunc(func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, nil, ctx.Err()
+	}))
+
+	job, err := service.SubmitJob(context.Background(), jobs.KindImport, nil, nil)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	<-started
+
+	if _, err := service.CancelJob(context.Background(), job.JobID.String()); err != nil {
+		t.Fatalf("CancelJob: %v", err)
+	}
+
+	job = waitFinished(t, service, job.JobID.String())
+	if job.Status != jobs.StatusCanceled || job.Error != nil {
+		t.Fatalf("job = %+v, want it canceled", job)
+	}
+
+	_, err = service.CancelJob(context.Background(), job.JobID.String())
+	if !errors.Is(err, jobs.ErrJobFinished) {
+		t.Fatalf("CancelJob of a canceled job: %v, want ErrJobFinished", err)
+	}
+}
+
+func TestWorkerResumesInterruptedJob(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService(t)
+
+	job, err := service.SubmitJob(ctx, jobs.KindImport, nil, nil)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+
+	// A run that stopped with its process after saving some progress.
+	interrupted, err := repo.ClaimJob(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatalf("ClaimJob: %v", err)
+	}
+	if _, err := repo.SaveProgress(ctx, job.JobID, interrupted.Attempt, 7, []byte(`{"rows":7}`), time.Millisecond); err != nil {
+		t.Fatalf("SaveProgress: %v", err)
+	}
+
+	var resumed jobs.Job
+	startWorker(t, repo, runnerFunc(func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+		resumed = *job
+		progress.Report(job.Processed+3, nil)
+		return nil, nil, nil
+	}))
+
+	job = waitFinished(t, service, job.JobID.String())
+	if job.Status != jobs.StatusSucceeded || job.Processed != 10 || job.Attempt != 2 {
+		t.Fatalf("job = %+v, want it succeeded on attempt 2", job)
+	}
+	if resumed.Processed != 7 || string(resumed.Result) != `{"rows":7}` {
+		t.Fatalf("runner got %+v, want the saved progress", resumed)
+	}
+}
+
+// TestWorkerResumesAfterSavedBatch kills a run that commits its work in
+// batches and checks that the run taking over does every batch once, however
+// long the heartbeat.
+func TestWorkerResumesAfterSavedBatch(t *testing.T) {
+	const batches, batchSize, killedAfter = 10, 4, 3
+	service, repo := newTestService(t)
+
+	// rows counts the writes of each row, as a store of imported cars would.
+	var mu sync.Mutex
+	rows := make(map[int64]int)
+	killed := make(chan struct{})
+	runner := runnerFunc(func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+		for done := job.Processed; done < batches*batchSize; done += batchSize {
+			if job.Attempt == 1 && done == killedAfter*batchSize {
+				close(killed)
+				<-ctx.Done()
+				return nil, nil, ctx.Err()
+			}
+
+			mu.Lock()
+			for row := done; row < done+batchSize; row++ {
+				rows[row]++
+			}
+			mu.Unlock()
+			if err := progress.Save(ctx, done+batchSize, nil); err != nil {
+				return nil, nil, err
+			}
+		}
+		return nil, nil, nil
+	})
+
+	job, err := service.SubmitJob(context.Background(), jobs.KindImport, nil, nil)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+
+	// The first worker never beats, and is stopped as its process would be.
+	w := NewWorker(repo, map[jobs.Kind]jobs.Runner{jobs.KindImport: runner})
+	w.PollInterval = 5 * time.Millisecond
+	w.Heartbeat = time.Hour
+	w.Lease = 20 * time.Millisecond
+	ctx, kill := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		w.Run(ctx)
+	}()
+	<-killed
+	kill()
+	<-stopped
+
+	startWorker(t, repo, runner)
+
+	job = waitFinished(t, service, job.JobID.String())
+	if job.Status != jobs.StatusSucceeded || job.Attempt != 2 || job.Processed != batches*batchSize {
+		t.Fatalf("job = %+v, want it succeeded on attempt 2", job)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(rows) != batches*batchSize {
+		t.Fatalf("%d rows written, want %d", len(rows), batches*batchSize)
+	}
+	for row, n := range rows {
+		if n != 1 {
+			t.Fatalf("row %d written %d times, want once", row, n)
+		}
+	}
+}
+
+func TestWorkerGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newTestService(t)
+
+	job, err := service.SubmitJob(ctx, jobs.KindImport, nil, nil)
+	if err != nil {
+		t.Fatalf("SubmitJob: %v", err)
+	}
+	for range 3 {
+		if _, err := repo.ClaimJob(ctx, time.Millisecond); err != nil {
+			t.Fatalf("ClaimJob: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	startWorker(t, repo, runnerFunc(func(ctx context.Context, job *jobs.Job, files jobs.Files, progress jobs.Progress) (any, *jobs.Artifact, error) {
+		t.Error("runner called past MaxAttempts")
+		return nil, nil, nil
+	}))
+
+	job = waitFinished(t, service, job.JobID.String())
+	if job.Status != jobs.StatusFailed || job.Error == nil || job.Error.Code != apperrors.CodeConflict {
+		t.Fatalf("job = %+v, want it failed", job)
+	}
+}
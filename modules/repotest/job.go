@@ -0,0 +1,315 @@
+package repotest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/google/uuid"
+)
+
+func RunJobRepositoryTests(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+
+		created := mustCreateJob(t, repos, jobs.KindExport)
+		if created.Status != jobs.StatusQueued || created.Attempt != 0 || created.StartedAt != nil {
+			t.Fatalf("CreateJob = %+v, want a queued job", created)
+		}
+
+		got, err := repos.Job.GetJob(ctx, created.JobID)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if got.Kind != jobs.KindExport || got.Actor != "tester" || got.RequestID != "req-1" {
+			t.Fatalf("GetJob = %+v", got)
+		}
+		if !bytes.Equal(compactJSON(t, got.Params), []byte(`{"format":"csv"}`)) {
+			t.Fatalf("Params = %s", got.Params)
+		}
+		if got.Result != nil || got.Error != nil || got.Artifact != nil {
+			t.Fatalf("GetJob of a new job has a result: %+v", got)
+		}
+
+		_, err = repos.Job.GetJob(ctx, uuid.New())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("ClaimJob", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+
+		none, err := repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil || none != nil {
+			t.Fatalf("ClaimJob with no jobs = %+v, %v", none, err)
+		}
+
+		first := mustCreateJob(t, repos, jobs.KindExport)
+		second := mustCreateJob(t, repos, jobs.KindImport)
+
+		claimed, err := repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimJob: %v", err)
+		}
+		if claimed.JobID != first.JobID || claimed.Status != jobs.StatusRunning || claimed.Attempt != 1 || claimed.StartedAt == nil {
+			t.Fatalf("ClaimJob = %+v, want the first job running", claimed)
+		}
+
+		claimed, err = repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil || claimed == nil || claimed.JobID != second.JobID {
+			t.Fatalf("second ClaimJob = %+v, %v, want the second job", claimed, err)
+		}
+
+		none, err = repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil || none != nil {
+			t.Fatalf("ClaimJob with every job held = %+v, %v", none, err)
+		}
+	})
+
+	t.Run("ClaimJobAfterLeaseExpired", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		job := mustCreateJob(t, repos, jobs.KindExport)
+
+		lost, err := repos.Job.ClaimJob(ctx, time.Millisecond)
+		if err != nil || lost == nil {
+			t.Fatalf("ClaimJob = %+v, %v", lost, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		claimed, err := repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil || claimed == nil {
+			t.Fatalf("ClaimJob after the lease expired = %+v, %v", claimed, err)
+		}
+		if claimed.JobID != job.JobID || claimed.Attempt != 2 {
+			t.Fatalf("ClaimJob = %+v, want attempt 2 of the job", claimed)
+		}
+		if !sameTime(*claimed.StartedAt, *lost.StartedAt) {
+			t.Fatalf("StartedAt = %v, want the first start %v", claimed.StartedAt, lost.StartedAt)
+		}
+
+		_, err = repos.Job.SaveProgress(ctx, job.JobID, lost.Attempt, 1, nil, time.Minute)
+		assertErrorIs(t, err, jobs.ErrJobLost)
+		lost.Status = jobs.StatusSucceeded
+		_, err = repos.Job.FinishJob(ctx, lost)
+		assertErrorIs(t, err, jobs.ErrJobLost)
+	})
+
+	t.Run("SaveProgressAndFinish", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		mustCreateJob(t, repos, jobs.KindExport)
+
+		claimed, err := repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil || claimed == nil {
+			t.Fatalf("ClaimJob = %+v, %v", claimed, err)
+		}
+
+		saved, err := repos.Job.SaveProgress(ctx, claimed.JobID, claimed.Attempt, 40, []byte(`{"rows":40}`), time.Minute)
+		if err != nil {
+			t.Fatalf("SaveProgress: %v", err)
+		}
+		if saved.Processed != 40 || !bytes.Equal(compactJSON(t, saved.Result), []byte(`{"rows":40}`)) {
+			t.Fatalf("SaveProgress = %+v", saved)
+		}
+
+		claimed.Status = jobs.StatusSucceeded
+		claimed.Processed = 50
+		claimed.Result = []byte(`{"rows":50}`)
+		claimed.Artifact = &jobs.Artifact{Name: "cars.csv", ContentType: "text/csv", Size: 1234}
+		finished, err := repos.Job.FinishJob(ctx, claimed)
+		if err != nil {
+			t.Fatalf("FinishJob: %v", err)
+		}
+
+		got, err := repos.Job.GetJob(ctx, claimed.JobID)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if got.Status != jobs.StatusSucceeded || got.Processed != 50 || got.FinishedAt == nil || got.LeaseUntil != nil {
+			t.Fatalf("GetJob after FinishJob = %+v", got)
+		}
+		if got.Artifact == nil || *got.Artifact != *claimed.Artifact {
+			t.Fatalf("Artifact = %+v, want %+v", got.Artifact, claimed.Artifact)
+		}
+		if !sameTime(*got.FinishedAt, *finished.FinishedAt) {
+			t.Fatalf("FinishedAt = %v, want %v", got.FinishedAt, finished.FinishedAt)
+		}
+
+		_, err = repos.Job.FinishJob(ctx, claimed)
+		assertErrorIs(t, err, jobs.ErrJobLost)
+	})
+
+	t.Run("FinishJobFailed", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		mustCreateJob(t, repos, jobs.KindImport)
+
+		claimed, err := repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil || claimed == nil {
+			t.Fatalf("ClaimJob = %+v, %v", claimed, err)
+		}
+
+		claimed.Status = jobs.StatusFailed
+		claimed.Error = &jobs.Failure{
+			Code:    apperrors.CodeValidation,
+			Message: "validation failed",
+			Errors:  []apperrors.FieldError{{Field: "engine", Message: "missing"}},
+		}
+		if _, err := repos.Job.FinishJob(ctx, claimed); err != nil {
+			t.Fatalf("FinishJob: %v", err)
+		}
+
+		got, err := repos.Job.GetJob(ctx, claimed.JobID)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if got.Status != jobs.StatusFailed || got.Error == nil || got.Error.Code != apperrors.CodeValidation ||
+			len(got.Error.Errors) != 1 || got.Result != nil || got.Artifact != nil {
+			t.Fatalf("GetJob after a failure = %+v", got)
+		}
+	})
+
+	t.Run("CancelJob", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+
+		running := mustCreateJob(t, repos, jobs.KindExport)
+		if _, err := repos.Job.ClaimJob(ctx, time.Minute); err != nil {
+			t.Fatalf("ClaimJob: %v", err)
+		}
+		queued := mustCreateJob(t, repos, jobs.KindExport)
+
+		canceled, err := repos.Job.CancelJob(ctx, queued.JobID)
+		if err != nil {
+			t.Fatalf("CancelJob(queued): %v", err)
+		}
+		if canceled.Status != jobs.StatusCanceled || !canceled.CancelRequested || canceled.FinishedAt == nil {
+			t.Fatalf("CancelJob(queued) = %+v, want it canceled", canceled)
+		}
+
+		stopping, err := repos.Job.CancelJob(ctx, running.JobID)
+		if err != nil {
+			t.Fatalf("CancelJob(running): %v", err)
+		}
+		if stopping.Status != jobs.StatusRunning || !stopping.CancelRequested || stopping.FinishedAt != nil {
+			t.Fatalf("CancelJob(running) = %+v, want it still running", stopping)
+		}
+
+		// The canceled job is never claimed.
+		none, err := repos.Job.ClaimJob(ctx, time.Minute)
+		if err != nil || none != nil {
+			t.Fatalf("ClaimJob = %+v, %v, want nothing to claim", none, err)
+		}
+
+		_, err = repos.Job.CancelJob(ctx, queued.JobID)
+		assertErrorIs(t, err, jobs.ErrJobFinished)
+		_, err = repos.Job.CancelJob(ctx, uuid.New())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("Files", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		job := mustCreateJob(t, repos, jobs.KindImport)
+
+		for seq, chunk := range []string{"name,year\n", "Civic,2020\n"} {
+			if err := repos.Job.WriteChunk(ctx, job.JobID, jobs.InputFile, seq, []byte(chunk)); err != nil {
+				t.Fatalf("WriteChunk(%d): %v", seq, err)
+			}
+		}
+		if err := repos.Job.WriteChunk(ctx, job.JobID, jobs.ArtifactFile, 0, []byte("other")); err != nil {
+			t.Fatalf("WriteChunk(artifact): %v", err)
+		}
+
+		chunk, err := repos.Job.ReadChunk(ctx, job.JobID, jobs.InputFile, 1)
+		if err != nil || string(chunk) != "Civic,2020\n" {
+			t.Fatalf("ReadChunk(1) = %q, %v", chunk, err)
+		}
+		_, err = repos.Job.ReadChunk(ctx, job.JobID, jobs.InputFile, 2)
+		if !errors.Is(err, io.EOF) {
+			t.Fatalf("ReadChunk past the end: %v, want io.EOF", err)
+		}
+
+		if err := repos.Job.DeleteFile(ctx, job.JobID, jobs.InputFile); err != nil {
+			t.Fatalf("DeleteFile: %v", err)
+		}
+		_, err = repos.Job.ReadChunk(ctx, job.JobID, jobs.InputFile, 0)
+		if !errors.Is(err, io.EOF) {
+			t.Fatalf("ReadChunk of a deleted file: %v, want io.EOF", err)
+		}
+		if chunk, err := repos.Job.ReadChunk(ctx, job.JobID, jobs.ArtifactFile, 0); err != nil || string(chunk) != "other" {
+			t.Fatalf("ReadChunk(artifact) = %q, %v, want it kept", chunk, err)
+		}
+	})
+
+	t.Run("PurgeJobs", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+
+		finished := mustCreateJob(t, repos, jobs.KindExport)
+		if _, err := repos.Job.CancelJob(ctx, finished.JobID); err != nil {
+			t.Fatalf("CancelJob: %v", err)
+		}
+		queued := mustCreateJob(t, repos, jobs.KindImport)
+		for _, job := range []*jobs.Job{finished, queued} {
+			if err := repos.Job.WriteChunk(ctx, job.JobID, jobs.InputFile, 0, []byte("data")); err != nil {
+				t.Fatalf("WriteChunk: %v", err)
+			}
+		}
+
+		purged, err := repos.Job.PurgeJobs(ctx, time.Now().Add(-time.Hour))
+		if err != nil || purged != 0 {
+			t.Fatalf("PurgeJobs before the retention = %d, %v, want 0", purged, err)
+		}
+
+		purged, err = repos.Job.PurgeJobs(ctx, time.Now().Add(time.Hour))
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeJobs = %d, %v, want 1", purged, err)
+		}
+
+		_, err = repos.Job.GetJob(ctx, finished.JobID)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+		_, err = repos.Job.ReadChunk(ctx, finished.JobID, jobs.InputFile, 0)
+		if !errors.Is(err, io.EOF) {
+			t.Fatalf("ReadChunk of a purged job: %v, want io.EOF", err)
+		}
+		if _, err := repos.Job.ReadChunk(ctx, queued.JobID, jobs.InputFile, 0); err != nil {
+			t.Fatalf("ReadChunk of a queued job: %v", err)
+		}
+	})
+}
+
+func mustCreateJob(t *testing.T, repos *Repositories, kind jobs.Kind) *jobs.Job {
+	t.Helper()
+
+	job, err := repos.Job.CreateJob(context.Background(), &jobs.Job{
+		JobID:     uuid.New(),
+		Kind:      kind,
+		Params:    []byte(`{"format": "csv"}`),
+		Actor:     "tester",
+		RequestID: "req-1",
+	})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	return job
+}
+
+// compactJSON drops the whitespace jsonb does not keep.
+func compactJSON(t *testing.T, raw []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		t.Fatalf("compact %s: %v", raw, err)
+	}
+	return buf.Bytes()
+}
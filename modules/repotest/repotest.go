@@ -0,0 +1,1724 @@
+// Package repotest is a contract test suite shared by every implementation of
+// the repositories, so the Postgres and in-memory backends keep the same
+// behavior.
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/etag"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	"github.com/codepnw/go-car-management/modules/engines"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/codepnw/go-car-management/modules/jobs/jobrepositories"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/codepnw/go-car-management/requestctx"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Repositories is one backend under test. Factory must return repositories
+// over an empty store each time it is called.
+type Repositories struct {
+	Car    carrepositories.ICarRepository
+	Engine engrepositories.IEngineRepository
+	Audit  auditrepositories.IAuditRepository
+	Job    jobrepositories.IJobRepository
+	Tx     database.Transactor
+	// Backfill, when set, makes the stored cars look written at legacyAt,
+	// before the audit log existed, and backfills their history the way the
+	// backend's migrations do. Backends without such records leave it nil.
+	Backfill func(t *testing.T, legacyAt time.Time)
+}
+
+type Factory func(t *testing.T) *Repositories
+
+// Postgres connects to TEST_DB_CONN_STR, applies the migrations and returns a
+// handle whose tables are emptied before every test. Each test binary works
+// in a schema of its own, so that packages tested in parallel do not empty
+// each other's tables. Tests are skipped when the variable is not set.
+func Postgres(t *testing.T) *sql.DB {
+	t.Helper()
+
+	connStr := os.Getenv("TEST_DB_CONN_STR")
+	if connStr == "" {
+		t.Skip("TEST_DB_CONN_STR not set, skipping Postgres tests")
+	}
+
+	schema := testSchema()
+	if err := createSchema(connStr, schema); err != nil {
+		t.Fatalf("create schema %s: %v", schema, err)
+	}
+
+	db, err := sql.Open("postgres", withSearchPath(connStr, schema))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	if _, err := db.Exec("TRUNCATE cars, engines, audit_log, jobs, job_files;"); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+
+	return db
+}
+
+// PostgresBackfill returns the Backfill of the Postgres repositories over db:
+// it empties the audit log, dates the cars to legacyAt and runs the migration
+// that backfilled the log.
+func PostgresBackfill(db *sql.DB) func(t *testing.T, legacyAt time.Time) {
+	return func(t *testing.T, legacyAt time.Time) {
+		t.Helper()
+
+		migrations, err := database.Migrations()
+		if err != nil {
+			t.Fatalf("load migrations: %v", err)
+		}
+		i := slices.IndexFunc(migrations, func(m *database.Migration) bool { return m.Name == "backfill_audit_log" })
+		if i < 0 {
+			t.Fatal("no backfill_audit_log migration")
+		}
+
+		if _, err := db.Exec("DELETE FROM audit_log;"); err != nil {
+			t.Fatalf("empty audit log: %v", err)
+		}
+		if _, err := db.Exec("UPDATE cars SET created_at = $1, updated_at = $1;", legacyAt); err != nil {
+			t.Fatalf("date cars: %v", err)
+		}
+		if _, err := db.Exec(migrations[i].Up); err != nil {
+			t.Fatalf("backfill audit log: %v", err)
+		}
+	}
+}
+
+// testSchema names the schema of the running test binary after its package,
+// such as repotest_carrepositories for carrepositories.test.
+func testSchema() string {
+	name := filepath.Base(os.Args[0])
+	name = strings.TrimSuffix(strings.TrimSuffix(name, filepath.Ext(name)), ".test")
+
+	schema := []rune("repotest_")
+	for _, r := range strings.ToLower(name) {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			r = '_'
+		}
+		schema = append(schema, r)
+	}
+	return string(schema)
+}
+
+func createSchema(connStr, schema string) error {
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("CREATE SCHEMA IF NOT EXISTS " + pq.QuoteIdentifier(schema) + ";")
+	return err
+}
+
+// withSearchPath adds schema as the search_path run-time parameter to a
+// connection string in either of the forms lib/pq accepts.
+func withSearchPath(connStr, schema string) string {
+	if strings.HasPrefix(connStr, "postgres://") || strings.HasPrefix(connStr, "postgresql://") {
+		u, err := url.Parse(connStr)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return connStr + " search_path=" + schema
+}
+
+func RunCarRepositoryTests(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGetWithEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		created, err := repos.Car.CreateCar(ctx, carRequest("Civic", "Honda", engine))
+		if err != nil {
+			t.Fatalf("CreateCar: %v", err)
+		}
+		if created.CarID == uuid.Nil {
+			t.Fatal("CreateCar returned an empty car_id")
+		}
+		if created.Engine == nil || created.Engine.EngineID != engine.EngineID {
+			t.Fatalf("CreateCar engine = %+v, want id %s", created.Engine, engine.EngineID)
+		}
+
+		got, err := repos.Car.GetCarById(ctx, created.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		assertCar(t, got, created)
+		if got.Engine == nil || *got.Engine != *engine {
+			t.Fatalf("GetCarById engine = %+v, want %+v", got.Engine, engine)
+		}
+	})
+
+	t.Run("CreateWithMissingEngine", func(t *testing.T) {
+		repos := newRepos(t)
+		missing := &engines.Engine{EngineID: uuid.New()}
+
+		_, err := repos.Car.CreateCar(context.Background(), carRequest("Civic", "Honda", missing))
+		assertErrorIs(t, err, apperrors.ErrValidation)
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		errAbort := errors.New("abort")
+
+		// createBoth creates an engine and a car using it in one transaction,
+		// checking that the car repository sees the uncommitted engine.
+		createBoth := func(ctx context.Context, name string) (*cars.Car, error) {
+			var car *cars.Car
+			err := repos.Tx.WithinTx(ctx, func(ctx context.Context) error {
+				engine, err := repos.Engine.CreateEngine(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+				if err != nil {
+					return err
+				}
+				if _, err := repos.Engine.GetEngineByID(ctx, engine.EngineID.String()); err != nil {
+					return err
+				}
+				if car, err = repos.Car.CreateCar(ctx, carRequest(name, "Honda", engine)); err != nil {
+					return err
+				}
+
+				switch name {
+				case "abort":
+					return errAbort
+				case "panic":
+					panic(errAbort)
+				}
+				return nil
+			})
+			return car, err
+		}
+
+		created, err := createBoth(ctx, "Civic")
+		if err != nil {
+			t.Fatalf("WithinTx: %v", err)
+		}
+		got, err := repos.Car.GetCarById(ctx, created.CarID.String())
+		if err != nil || got.Engine == nil || got.Engine.CarRange != 600 {
+			t.Fatalf("GetCarById after commit = %+v, %v", got, err)
+		}
+
+		aborted, err := createBoth(ctx, "abort")
+		assertErrorIs(t, err, errAbort)
+		_, err = repos.Car.GetCarById(ctx, aborted.CarID.String())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		func() {
+			defer func() {
+				if p := recover(); p != errAbort {
+					t.Fatalf("recovered %v, want the panic to propagate", p)
+				}
+			}()
+			createBoth(ctx, "panic")
+		}()
+
+		// Nested transactions join the outer one and roll back with it.
+		err = repos.Tx.WithinTx(ctx, func(ctx context.Context) error {
+			if _, err := createBoth(ctx, "Accord"); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		assertErrorIs(t, err, errAbort)
+
+		if page := listEnginePage(t, repos, engines.EngineFilter{}); page.Total != 1 {
+			t.Fatalf("engines after rolled back transactions = %d, want 1", page.Total)
+		}
+		if page := listCarPage(t, repos, cars.CarFilter{}); page.Total != 1 {
+			t.Fatalf("cars after rolled back transactions = %d, want 1", page.Total)
+		}
+	})
+
+	t.Run("VIN", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		req := carRequest("Civic", "Honda", engine)
+		req.VIN = "1HGCM82633A004352"
+		created := mustCreateCar(t, repos, req)
+		if created.VIN != req.VIN {
+			t.Fatalf("CreateCar vin = %q, want %q", created.VIN, req.VIN)
+		}
+
+		got, err := repos.Car.GetCarById(ctx, created.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		if got.VIN != req.VIN {
+			t.Fatalf("GetCarById vin = %q, want %q", got.VIN, req.VIN)
+		}
+
+		_, err = repos.Car.CreateCar(ctx, req)
+		assertErrorIs(t, err, apperrors.ErrConflict)
+
+		// Cars without a VIN never conflict.
+		mustCreateCar(t, repos, carRequest("Accord", "Honda", engine))
+		mustCreateCar(t, repos, carRequest("Accord", "Honda", engine))
+	})
+
+	t.Run("GetCarByIdNotFound", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.Car.GetCarById(context.Background(), uuid.NewString())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("InvalidCarID", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		_, err := repos.Car.GetCarById(ctx, "not-a-uuid")
+		assertErrorIs(t, err, apperrors.ErrInvalidID)
+
+		_, err = repos.Car.UpdateCar(ctx, "not-a-uuid", carRequest("Civic", "Honda", engine), etag.Any)
+		assertErrorIs(t, err, apperrors.ErrInvalidID)
+
+		_, err = repos.Car.DeleteCar(ctx, "not-a-uuid", etag.Any)
+		assertErrorIs(t, err, apperrors.ErrInvalidID)
+	})
+
+	t.Run("ListCars", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		v6 := mustCreateEngine(t, repos, 3000, 6, 700)
+		i4 := mustCreateEngine(t, repos, 1500, 4, 650)
+
+		newCar := func(name, brand, fuelType string, year uint16, price float64, engine *engines.Engine) *cars.Car {
+			req := carRequest(name, brand, engine)
+			req.FuelType = fuelType
+			req.Year = year
+			req.Price = price
+			return mustCreateCar(t, repos, req)
+		}
+
+		civic := newCar("Civic", "Honda", "Petrol", 2018, 18000, i4)
+		accord := newCar("Accord", "Honda", "Hybrid", 2021, 27000, i4)
+		supra := newCar("Supra", "Toyota", "Petrol", 2020, 52000, v6)
+		camry := newCar("Camry 100% Hybrid", "Toyota", "Hybrid", 2023, 31000, i4)
+		mustang := newCar("Mustang", "Ford", "Petrol", 2015, 35000, v6)
+
+		u16 := func(v uint16) *uint16 { return &v }
+		f64 := func(v float64) *float64 { return &v }
+
+		tests := []struct {
+			name      string
+			filter    cars.CarFilter
+			want      []*cars.Car
+			wantTotal int
+		}{
+			{"all by price", cars.CarFilter{Sort: "price"}, []*cars.Car{civic, accord, camry, mustang, supra}, 5},
+			{"brand case-insensitive, several values", cars.CarFilter{Brands: []string{"honda", "FORD"}, Sort: "year"}, []*cars.Car{mustang, civic, accord}, 3},
+			{"fuel type", cars.CarFilter{FuelTypes: []string{"Hybrid"}, Sort: "name"}, []*cars.Car{accord, camry}, 2},
+			{"name substring", cars.CarFilter{Name: "AMR", Sort: "name"}, []*cars.Car{camry}, 1},
+			{"name wildcard is literal", cars.CarFilter{Name: "100%", Sort: "name"}, []*cars.Car{camry}, 1},
+			{"year range", cars.CarFilter{YearMin: u16(2018), YearMax: u16(2021), Sort: "year", Desc: true}, []*cars.Car{accord, supra, civic}, 3},
+			{"price range", cars.CarFilter{PriceMin: f64(27000), PriceMax: f64(35000), Sort: "price"}, []*cars.Car{accord, camry, mustang}, 3},
+			{"engine attributes", cars.CarFilter{EngineCylinders: u16(6), EngineDisplacementMin: u16(2000), Sort: "year"}, []*cars.Car{mustang, supra}, 2},
+			{"engine range", cars.CarFilter{EngineCarRangeMax: u16(650), Brands: []string{"Toyota"}, Sort: "year"}, []*cars.Car{camry}, 1},
+			{"paged", cars.CarFilter{Sort: "price", Desc: true, Limit: 2, Offset: 1}, []*cars.Car{mustang, camry}, 5},
+			{"past the end", cars.CarFilter{Sort: "price", Limit: 2, Offset: 10}, []*cars.Car{}, 5},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				filter := tt.filter
+				if err := filter.Validate(); err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+
+				got, total, err := repos.Car.ListCars(ctx, &filter)
+				if err != nil {
+					t.Fatalf("ListCars: %v", err)
+				}
+				if total != tt.wantTotal {
+					t.Fatalf("ListCars total = %d, want %d", total, tt.wantTotal)
+				}
+				assertCarIDs(t, got, tt.want)
+				for _, car := range got {
+					if car.Engine == nil || car.Engine.CarRange == 0 {
+						t.Fatalf("ListCars did not join the engine: %+v", car.Engine)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("ListCarsByteOrder", func(t *testing.T) {
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		civic := mustCreateCar(t, repos, carRequest("civic", "Honda", engine))
+		zephyr := mustCreateCar(t, repos, carRequest("Zephyr", "Lincoln", engine))
+		accord := mustCreateCar(t, repos, carRequest("accord", "Honda", engine))
+
+		// Text sorts by bytes, so upper case comes before lower case
+		// whatever the collation of the database.
+		page := listCarPage(t, repos, cars.CarFilter{Sort: "name"})
+		assertCarIDs(t, page.Items, []*cars.Car{zephyr, accord, civic})
+
+		page = listCarPage(t, repos, cars.CarFilter{Sort: "name", Limit: 1})
+		page = listCarPage(t, repos, cars.CarFilter{Sort: "name", Limit: 2, Cursor: page.Next})
+		assertCarIDs(t, page.Items, []*cars.Car{accord, civic})
+	})
+
+	t.Run("ListCarsByEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		v6 := mustCreateEngine(t, repos, 3000, 6, 700)
+		i4 := mustCreateEngine(t, repos, 1500, 4, 650)
+
+		civic := mustCreateCar(t, repos, carRequest("Civic", "Honda", i4))
+		mustCreateCar(t, repos, carRequest("Supra", "Toyota", v6))
+		accord := mustCreateCar(t, repos, carRequest("Accord", "Honda", i4))
+		camry := mustCreateCar(t, repos, carRequest("Camry", "Toyota", i4))
+		if _, err := repos.Car.DeleteCar(ctx, camry.CarID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+
+		filter := cars.CarFilter{Brands: []string{"Honda"}, Sort: "name"}
+		if err := filter.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		got, total, err := repos.Car.ListCarsByEngine(ctx, i4.EngineID, &filter)
+		if err != nil {
+			t.Fatalf("ListCarsByEngine: %v", err)
+		}
+		if total != 2 {
+			t.Fatalf("ListCarsByEngine total = %d, want 2", total)
+		}
+		assertCarIDs(t, got, []*cars.Car{accord, civic})
+
+		filter = cars.CarFilter{Trashed: true}
+		if err := filter.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		got, _, err = repos.Car.ListCarsByEngine(ctx, i4.EngineID, &filter)
+		if err != nil {
+			t.Fatalf("ListCarsByEngine(trashed): %v", err)
+		}
+		assertCarIDs(t, got, []*cars.Car{camry})
+
+		got, total, err = repos.Car.ListCarsByEngine(ctx, uuid.New(), &filter)
+		if err != nil || total != 0 || len(got) != 0 {
+			t.Fatalf("ListCarsByEngine(unknown engine) = %d cars, total %d, error %v; want none", len(got), total, err)
+		}
+	})
+
+	t.Run("ExportCars", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		civic := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+		mustCreateCar(t, repos, carRequest("Supra", "Toyota", engine))
+		accord := mustCreateCar(t, repos, carRequest("Accord", "Honda", engine))
+		jazz := mustCreateCar(t, repos, carRequest("Jazz", "Honda", engine))
+		if _, err := repos.Car.DeleteCar(ctx, jazz.CarID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+
+		// Paging is ignored: a limit of one still exports every car.
+		filter := cars.CarFilter{Brands: []string{"honda"}, Sort: "name", Desc: true, Limit: 1}
+		if err := filter.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		var got []*cars.Car
+		err := repos.Car.ExportCars(ctx, &filter, func(car *cars.Car) error {
+			got = append(got, car)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExportCars: %v", err)
+		}
+		assertCarIDs(t, got, []*cars.Car{civic, accord})
+		if got[0].Engine == nil || got[0].Engine.CarRange != engine.CarRange {
+			t.Fatalf("ExportCars engine = %+v, want %+v", got[0].Engine, engine)
+		}
+
+		// An error from each stops the export.
+		stop := errors.New("stop")
+		calls := 0
+		err = repos.Car.ExportCars(ctx, &filter, func(*cars.Car) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Fatalf("ExportCars = %v after %d calls, want %v after 1", err, calls, stop)
+		}
+	})
+
+	t.Run("ListCarsCursor", func(t *testing.T) {
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		newCar := func(name string, price float64) *cars.Car {
+			req := carRequest(name, "Honda", engine)
+			req.Price = price
+			return mustCreateCar(t, repos, req)
+		}
+
+		a := newCar("A", 10000)
+		b1 := newCar("B1", 20000)
+		b2 := newCar("B2", 20000)
+		d := newCar("D", 30000)
+		e := newCar("E", 40000)
+		// Equal prices are ordered by car_id.
+		if pagination.Compare(b1.CarID, b2.CarID) > 0 {
+			b1, b2 = b2, b1
+		}
+
+		page1 := listCarPage(t, repos, cars.CarFilter{Sort: "price", Limit: 2})
+		assertCarIDs(t, page1.Items, []*cars.Car{a, b1})
+		if page1.Prev != nil || page1.Next == nil {
+			t.Fatalf("first page cursors = (%v, %v), want only next", page1.Prev, page1.Next)
+		}
+
+		// A row inserted before the cursor must not shift the next page.
+		cheap := newCar("Cheap", 5000)
+
+		page2 := listCarPage(t, repos, cars.CarFilter{Limit: 2, Cursor: page1.Next})
+		assertCarIDs(t, page2.Items, []*cars.Car{b2, d})
+		if page2.Total != 6 {
+			t.Fatalf("total = %d, want 6", page2.Total)
+		}
+
+		page3 := listCarPage(t, repos, cars.CarFilter{Limit: 2, Cursor: page2.Next})
+		assertCarIDs(t, page3.Items, []*cars.Car{e})
+		if page3.Next != nil || page3.Prev == nil {
+			t.Fatalf("last page cursors = (%v, %v), want only prev", page3.Prev, page3.Next)
+		}
+
+		back2 := listCarPage(t, repos, cars.CarFilter{Limit: 2, Cursor: page3.Prev})
+		assertCarIDs(t, back2.Items, []*cars.Car{b2, d})
+
+		back1 := listCarPage(t, repos, cars.CarFilter{Limit: 2, Cursor: back2.Prev})
+		assertCarIDs(t, back1.Items, []*cars.Car{a, b1})
+		if back1.Prev == nil || back1.Next == nil {
+			t.Fatalf("cursors = (%v, %v), want both", back1.Prev, back1.Next)
+		}
+
+		first := listCarPage(t, repos, cars.CarFilter{Limit: 2, Cursor: back1.Prev})
+		assertCarIDs(t, first.Items, []*cars.Car{cheap})
+		if first.Prev != nil {
+			t.Fatalf("prev cursor = %v on the first page", first.Prev)
+		}
+
+		desc := listCarPage(t, repos, cars.CarFilter{Sort: "price", Desc: true, Limit: 3})
+		assertCarIDs(t, desc.Items, []*cars.Car{e, d, b2})
+		desc = listCarPage(t, repos, cars.CarFilter{Limit: 3, Cursor: desc.Next})
+		assertCarIDs(t, desc.Items, []*cars.Car{b1, a, cheap})
+	})
+
+	t.Run("UpdateCar", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		other := mustCreateEngine(t, repos, 0, 0, 450)
+		created := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+
+		req := &cars.CarRequest{
+			Name:     "Civic Type R",
+			Year:     2024,
+			Brand:    "Honda",
+			FuelType: "Electric",
+			Engine:   other,
+			Price:    45000.5,
+		}
+
+		updated, err := repos.Car.UpdateCar(ctx, created.CarID.String(), req, created.Version)
+		if err != nil {
+			t.Fatalf("UpdateCar: %v", err)
+		}
+		if updated.Name != req.Name || updated.Year != req.Year || updated.FuelType != req.FuelType || updated.Price != req.Price {
+			t.Fatalf("UpdateCar = %+v, want fields from %+v", updated, req)
+		}
+		if updated.Engine == nil || updated.Engine.EngineID != other.EngineID {
+			t.Fatalf("UpdateCar engine = %+v, want id %s", updated.Engine, other.EngineID)
+		}
+		if updated.UpdatedAt.Before(created.UpdatedAt) {
+			t.Fatalf("UpdateCar updated_at %v is before %v", updated.UpdatedAt, created.UpdatedAt)
+		}
+
+		got, err := repos.Car.GetCarById(ctx, created.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		if got.Name != req.Name || got.Engine == nil || *got.Engine != *other {
+			t.Fatalf("GetCarById after update = %+v", got)
+		}
+
+		_, err = repos.Car.UpdateCar(ctx, uuid.NewString(), req, etag.Any)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		_, err = repos.Car.UpdateCar(ctx, created.CarID.String(), carRequest("Civic", "Honda", &engines.Engine{EngineID: uuid.New()}), etag.Any)
+		assertErrorIs(t, err, apperrors.ErrValidation)
+	})
+
+	t.Run("PatchCar", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		other := mustCreateEngine(t, repos, 2500, 6, 650)
+		req := carRequest("Civic", "Honda", engine)
+		req.VIN = "1HGCM82633A004352"
+		created := mustCreateCar(t, repos, req)
+
+		price, vin := 21000.0, ""
+		patched, err := repos.Car.PatchCar(ctx, created.CarID.String(), &cars.CarPatch{
+			Price:    &price,
+			VIN:      &vin,
+			EngineID: &other.EngineID,
+		}, created.Version)
+		if err != nil {
+			t.Fatalf("PatchCar: %v", err)
+		}
+		if patched.Price != price || patched.VIN != "" || patched.Name != "Civic" || patched.Year != created.Year {
+			t.Fatalf("PatchCar = %+v, want only price and vin changed", patched)
+		}
+		if patched.Engine == nil || patched.Engine.EngineID != other.EngineID {
+			t.Fatalf("PatchCar engine = %+v, want id %s", patched.Engine, other.EngineID)
+		}
+
+		got, err := repos.Car.GetCarById(ctx, created.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		assertCar(t, got, patched)
+
+		missing := uuid.New()
+		_, err = repos.Car.PatchCar(ctx, created.CarID.String(), &cars.CarPatch{EngineID: &missing}, etag.Any)
+		assertErrorIs(t, err, apperrors.ErrValidation)
+
+		_, err = repos.Car.PatchCar(ctx, uuid.NewString(), &cars.CarPatch{Price: &price}, etag.Any)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("DeleteCar", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		created := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+
+		deleted, err := repos.Car.DeleteCar(ctx, created.CarID.String(), created.Version)
+		if err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+		if deleted.DeletedAt == nil || deleted.Version != created.Version+1 {
+			t.Fatalf("DeleteCar = %+v, want deletedAt set and version %d", deleted, created.Version+1)
+		}
+
+		_, err = repos.Car.GetCarById(ctx, created.CarID.String())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+		_, err = repos.Car.DeleteCar(ctx, created.CarID.String(), etag.Any)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		assertCarIDs(t, listCarPage(t, repos, cars.CarFilter{}).Items, nil)
+		assertCarIDs(t, listCarPage(t, repos, cars.CarFilter{Trashed: true}).Items, []*cars.Car{created})
+	})
+
+	t.Run("RestoreCar", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		req := carRequest("Civic", "Honda", engine)
+		req.VIN = "1HGCM82633A004352"
+		created := mustCreateCar(t, repos, req)
+		id := created.CarID.String()
+
+		_, err := repos.Car.RestoreCar(ctx, id)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		deleted, err := repos.Car.DeleteCar(ctx, id, etag.Any)
+		if err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+
+		restored, err := repos.Car.RestoreCar(ctx, id)
+		if err != nil {
+			t.Fatalf("RestoreCar: %v", err)
+		}
+		if restored.DeletedAt != nil || restored.Version != deleted.Version+1 || restored.VIN != req.VIN {
+			t.Fatalf("RestoreCar = %+v, want a live car at version %d", restored, deleted.Version+1)
+		}
+		got, err := repos.Car.GetCarById(ctx, id)
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		assertCar(t, got, restored)
+
+		// A trashed car frees its VIN, so restoring it can collide.
+		if _, err := repos.Car.DeleteCar(ctx, id, etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+		mustCreateCar(t, repos, req)
+		_, err = repos.Car.RestoreCar(ctx, id)
+		assertErrorIs(t, err, apperrors.ErrConflict)
+
+		_, err = repos.Car.RestoreCar(ctx, uuid.NewString())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("RestoreCarWithTrashedEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		created := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+
+		if _, err := repos.Car.DeleteCar(ctx, created.CarID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+		if _, err := repos.Engine.DeleteEngine(ctx, engine.EngineID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteEngine: %v", err)
+		}
+
+		_, err := repos.Car.RestoreCar(ctx, created.CarID.String())
+		assertErrorIs(t, err, apperrors.ErrConflict)
+		_, err = repos.Car.CreateCar(ctx, carRequest("Accord", "Honda", engine))
+		assertErrorIs(t, err, cars.ErrEngineNotExists)
+	})
+
+	t.Run("PurgeCars", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		live := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+		trashed := mustCreateCar(t, repos, carRequest("Accord", "Honda", engine))
+
+		if _, err := repos.Car.DeleteCar(ctx, trashed.CarID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+
+		purged, err := repos.Car.PurgeCars(ctx, time.Now().Add(-time.Hour))
+		if err != nil || purged != 0 {
+			t.Fatalf("PurgeCars(an hour ago) = %d, %v, want 0", purged, err)
+		}
+
+		purged, err = repos.Car.PurgeCars(ctx, time.Now().Add(time.Second))
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeCars(now) = %d, %v, want 1", purged, err)
+		}
+		_, err = repos.Car.RestoreCar(ctx, trashed.CarID.String())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		assertCarIDs(t, listCarPage(t, repos, cars.CarFilter{}).Items, []*cars.Car{live})
+		assertCarIDs(t, listCarPage(t, repos, cars.CarFilter{Trashed: true}).Items, nil)
+	})
+
+	t.Run("DeleteCarNotFound", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.Car.DeleteCar(context.Background(), uuid.NewString(), etag.Any)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("CarHistory", func(t *testing.T) {
+		ctx := requestctx.WithActor(requestctx.WithRequestID(context.Background(), "req-1"), "alice")
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		other := mustCreateEngine(t, repos, 2500, 6, 700)
+
+		created, err := repos.Car.CreateCar(ctx, carRequest("Civic", "Honda", engine))
+		if err != nil {
+			t.Fatalf("CreateCar: %v", err)
+		}
+		id := created.CarID.String()
+
+		price := 19999.0
+		if _, err := repos.Car.PatchCar(ctx, id, &cars.CarPatch{Price: &price, EngineID: &other.EngineID}, etag.Any); err != nil {
+			t.Fatalf("PatchCar: %v", err)
+		}
+		// A failed write leaves no entry behind.
+		_, err = repos.Car.PatchCar(ctx, id, &cars.CarPatch{Price: &price}, created.Version)
+		assertErrorIs(t, err, apperrors.ErrPreconditionFailed)
+		if _, err := repos.Car.DeleteCar(context.Background(), id, etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+
+		history, err := repos.Audit.ListHistory(ctx, audit.EntityCar, created.CarID)
+		if err != nil {
+			t.Fatalf("ListHistory: %v", err)
+		}
+		assertActions(t, history, audit.ActionDelete, audit.ActionUpdate, audit.ActionCreate)
+
+		update := history[1]
+		if update.Actor != "alice" || update.RequestID != "req-1" || update.Version != 2 {
+			t.Fatalf("update entry = %+v, want actor alice, request req-1, version 2", update)
+		}
+		assertChangedFields(t, update, "engineId", "price")
+		if update.Changes[1].Before != created.Price || update.Changes[1].After != price {
+			t.Fatalf("price change = %+v, want %v -> %v", update.Changes[1], created.Price, price)
+		}
+
+		if history[0].Actor != audit.SystemActor {
+			t.Fatalf("delete actor = %q, want %q", history[0].Actor, audit.SystemActor)
+		}
+		assertChangedFields(t, history[0], "deletedAt")
+		assertChangedFields(t, history[2], "brand", "engineId", "fuelType", "name", "price", "year")
+
+		if list, err := repos.Audit.ListHistory(ctx, audit.EntityEngine, created.CarID); err != nil || len(list) != 0 {
+			t.Fatalf("ListHistory(engine, car id) = %v, %v, want no entries", list, err)
+		}
+	})
+
+	t.Run("BackfilledHistory", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		if repos.Backfill == nil {
+			t.Skip("the backend has no records older than the audit log")
+		}
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		car := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+
+		legacyAt := time.Now().Add(-time.Hour)
+		repos.Backfill(t, legacyAt)
+
+		carHistory, err := repos.Audit.ListHistory(ctx, audit.EntityCar, car.CarID)
+		if err != nil {
+			t.Fatalf("ListHistory(car): %v", err)
+		}
+		assertActions(t, carHistory, audit.ActionSnapshot)
+		engineHistory, err := repos.Audit.ListHistory(ctx, audit.EntityEngine, engine.EngineID)
+		if err != nil {
+			t.Fatalf("ListHistory(engine): %v", err)
+		}
+		assertActions(t, engineHistory, audit.ActionSnapshot)
+
+		// As of a time between the writing of the car and the backfill, the
+		// car and its engine both have a recorded state.
+		asOf := legacyAt.Add(time.Minute)
+		snap := audit.Replay(carHistory, asOf)
+		if snap == nil || snap.Fields["engineId"] != engine.EngineID.String() {
+			t.Fatalf("car as of %v = %+v, want engineId %s", asOf, snap, engine.EngineID)
+		}
+		snap = audit.Replay(engineHistory, asOf)
+		if snap == nil || snap.Fields["displacement"] != float64(1998) {
+			t.Fatalf("engine as of %v = %+v, want displacement 1998", asOf, snap)
+		}
+
+		if snap := audit.Replay(carHistory, legacyAt.Add(-time.Minute)); snap != nil {
+			t.Fatalf("car before it was written = %+v, want nil", snap)
+		}
+	})
+
+	t.Run("CarOptimisticLocking", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		created := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+		id := created.CarID.String()
+		if created.Version != 1 {
+			t.Fatalf("created version = %d, want 1", created.Version)
+		}
+
+		updated, err := repos.Car.UpdateCar(ctx, id, carRequest("Civic Si", "Honda", engine), created.Version)
+		if err != nil {
+			t.Fatalf("UpdateCar: %v", err)
+		}
+		if updated.Version != 2 {
+			t.Fatalf("updated version = %d, want 2", updated.Version)
+		}
+
+		// Every write made with the version read before the update is stale.
+		price := 1.0
+		_, err = repos.Car.UpdateCar(ctx, id, carRequest("Civic", "Honda", engine), created.Version)
+		assertErrorIs(t, err, apperrors.ErrPreconditionFailed)
+		_, err = repos.Car.PatchCar(ctx, id, &cars.CarPatch{Price: &price}, created.Version)
+		assertErrorIs(t, err, apperrors.ErrPreconditionFailed)
+		_, err = repos.Car.DeleteCar(ctx, id, created.Version)
+		assertErrorIs(t, err, apperrors.ErrPreconditionFailed)
+
+		got, err := repos.Car.GetCarById(ctx, id)
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		assertCar(t, got, updated)
+
+		patched, err := repos.Car.PatchCar(ctx, id, &cars.CarPatch{Price: &price}, etag.Any)
+		if err != nil {
+			t.Fatalf("PatchCar with any version: %v", err)
+		}
+		if patched.Version != 3 {
+			t.Fatalf("patched version = %d, want 3", patched.Version)
+		}
+
+		_, err = repos.Car.UpdateCar(ctx, uuid.NewString(), carRequest("Civic", "Honda", engine), 1)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("CreateCars", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		existing := carRequest("Civic", "Honda", engine)
+		existing.VIN = "1HGCM82633A004352"
+		mustCreateCar(t, repos, existing)
+
+		first := carRequest("Accord", "Honda", engine)
+		first.VIN = "1HGCM82633A004353"
+		again := carRequest("Jazz", "Honda", engine)
+		again.VIN = first.VIN
+		taken := carRequest("City", "Honda", engine)
+		taken.VIN = existing.VIN
+		missing := carRequest("HR-V", "Honda", &engines.Engine{EngineID: uuid.New()})
+
+		_, err := repos.Car.CreateCars(ctx, []*cars.CarRequest{first, again, taken, missing})
+		var itemErrs cars.ItemErrors
+		if !errors.As(err, &itemErrs) || len(itemErrs) != 3 {
+			t.Fatalf("CreateCars error = %v, want three item errors", err)
+		}
+		assertErrorIs(t, itemErrs[1], apperrors.ErrConflict)
+		assertErrorIs(t, itemErrs[2], apperrors.ErrConflict)
+		assertErrorIs(t, itemErrs[3], cars.ErrEngineNotExists)
+
+		page := listCarPage(t, repos, cars.CarFilter{})
+		if page.Total != 1 {
+			t.Fatalf("cars after a rejected batch = %d, want 1", page.Total)
+		}
+
+		created, err := repos.Car.CreateCars(ctx, []*cars.CarRequest{first, carRequest("Jazz", "Honda", engine)})
+		if err != nil {
+			t.Fatalf("CreateCars: %v", err)
+		}
+		if len(created) != 2 || created[0].Name != "Accord" || created[1].Name != "Jazz" || created[0].VIN != first.VIN {
+			t.Fatalf("CreateCars = %+v, want Accord and Jazz in order", created)
+		}
+		for _, car := range created {
+			got, err := repos.Car.GetCarById(ctx, car.CarID.String())
+			if err != nil {
+				t.Fatalf("GetCarById: %v", err)
+			}
+			assertCar(t, got, car)
+
+			history, err := repos.Audit.ListHistory(ctx, audit.EntityCar, car.CarID)
+			if err != nil {
+				t.Fatalf("ListHistory: %v", err)
+			}
+			assertActions(t, history, audit.ActionCreate)
+		}
+	})
+
+	t.Run("UpdateAndDeleteCars", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		civic := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+		jazz := mustCreateCar(t, repos, carRequest("Jazz", "Honda", engine))
+
+		update := func(car *cars.Car, version int64, name string) *cars.CarUpdate {
+			return &cars.CarUpdate{CarID: car.CarID, Version: version, CarRequest: *carRequest(name, "Honda", engine)}
+		}
+
+		_, err := repos.Car.UpdateCars(ctx, []*cars.CarUpdate{
+			update(civic, civic.Version, "Civic Type R"),
+			update(jazz, jazz.Version+1, "Fit"),
+			update(&cars.Car{CarID: uuid.New()}, 1, "Ghost"),
+		})
+		var itemErrs cars.ItemErrors
+		if !errors.As(err, &itemErrs) || len(itemErrs) != 2 {
+			t.Fatalf("UpdateCars error = %v, want two item errors", err)
+		}
+		assertErrorIs(t, itemErrs[1], apperrors.ErrPreconditionFailed)
+		assertErrorIs(t, itemErrs[2], apperrors.ErrNotFound)
+
+		updated, err := repos.Car.UpdateCars(ctx, []*cars.CarUpdate{
+			update(jazz, jazz.Version, "Fit"),
+			update(civic, civic.Version, "Civic Type R"),
+		})
+		if err != nil {
+			t.Fatalf("UpdateCars: %v", err)
+		}
+		if updated[0].CarID != jazz.CarID || updated[0].Name != "Fit" || updated[0].Version != 2 ||
+			updated[1].CarID != civic.CarID || updated[1].Name != "Civic Type R" {
+			t.Fatalf("UpdateCars = %+v, %+v", updated[0], updated[1])
+		}
+		got, err := repos.Car.GetCarById(ctx, jazz.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		assertCar(t, got, updated[0])
+
+		_, err = repos.Car.DeleteCars(ctx, []*cars.CarRef{{CarID: civic.CarID, Version: 1}})
+		if !errors.As(err, &itemErrs) {
+			t.Fatalf("DeleteCars with a stale version error = %v, want item errors", err)
+		}
+		assertErrorIs(t, itemErrs[0], apperrors.ErrPreconditionFailed)
+
+		deleted, err := repos.Car.DeleteCars(ctx, []*cars.CarRef{{CarID: civic.CarID, Version: 2}, {CarID: jazz.CarID, Version: etag.Any}})
+		if err != nil {
+			t.Fatalf("DeleteCars: %v", err)
+		}
+		if len(deleted) != 2 || deleted[0].DeletedAt == nil || deleted[1].CarID != jazz.CarID {
+			t.Fatalf("DeleteCars = %+v", deleted)
+		}
+
+		history, err := repos.Audit.ListHistory(ctx, audit.EntityCar, jazz.CarID)
+		if err != nil {
+			t.Fatalf("ListHistory: %v", err)
+		}
+		assertActions(t, history, audit.ActionDelete, audit.ActionUpdate, audit.ActionCreate)
+		if page := listCarPage(t, repos, cars.CarFilter{}); page.Total != 0 {
+			t.Fatalf("live cars after DeleteCars = %d, want 0", page.Total)
+		}
+	})
+
+	runReplaceEngineTests(t, newRepos)
+}
+
+func RunEngineRepositoryTests(t *testing.T, newRepos Factory) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		created := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		got, err := repos.Engine.GetEngineByID(ctx, created.EngineID.String())
+		if err != nil {
+			t.Fatalf("GetEngineByID: %v", err)
+		}
+		if *got != *created {
+			t.Fatalf("GetEngineByID = %+v, want %+v", got, created)
+		}
+	})
+
+	t.Run("ListEngines", func(t *testing.T) {
+		repos := newRepos(t)
+		i3 := mustCreateEngine(t, repos, 1000, 3, 500)
+		i4 := mustCreateEngine(t, repos, 2000, 4, 600)
+		v6 := mustCreateEngine(t, repos, 3000, 6, 700)
+		ev := mustCreateEngine(t, repos, 0, 0, 450)
+
+		mustCreateCar(t, repos, carRequest("Civic", "Honda", i4))
+		mustCreateCar(t, repos, carRequest("Accord", "Honda", i4))
+		mustCreateCar(t, repos, carRequest("Supra", "Toyota", v6))
+
+		u16 := func(v uint16) *uint16 { return &v }
+
+		tests := []struct {
+			name      string
+			filter    engines.EngineFilter
+			want      []*engines.Engine
+			wantTotal int
+		}{
+			{"default order", engines.EngineFilter{}, []*engines.Engine{ev, i3, i4, v6}, 4},
+			{"displacement range", engines.EngineFilter{DisplacementMin: u16(1000), DisplacementMax: u16(2000)}, []*engines.Engine{i3, i4}, 2},
+			{"cylinders", engines.EngineFilter{NoOfCylinders: u16(6)}, []*engines.Engine{v6}, 1},
+			{"car range", engines.EngineFilter{CarRangeMin: u16(500), CarRangeMax: u16(650), Sort: "carRange", Desc: true}, []*engines.Engine{i4, i3}, 2},
+			{"by usage", engines.EngineFilter{Sort: "usageCount", Desc: true, Limit: 2}, []*engines.Engine{i4, v6}, 4},
+			{"paged", engines.EngineFilter{Sort: "carRange", Limit: 2, Offset: 1}, []*engines.Engine{i3, i4}, 4},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				page := listEnginePage(t, repos, tt.filter)
+				if page.Total != tt.wantTotal {
+					t.Fatalf("ListEngines total = %d, want %d", page.Total, tt.wantTotal)
+				}
+				assertEngineIDs(t, page.Items, tt.want)
+			})
+		}
+
+		page := listEnginePage(t, repos, engines.EngineFilter{Sort: "usageCount", Desc: true})
+		wantUsage := map[uuid.UUID]int{i4.EngineID: 2, v6.EngineID: 1, i3.EngineID: 0, ev.EngineID: 0}
+		for _, engine := range page.Items {
+			if engine.UsageCount != wantUsage[engine.EngineID] {
+				t.Fatalf("engine %s usage = %d, want %d", engine.EngineID, engine.UsageCount, wantUsage[engine.EngineID])
+			}
+		}
+	})
+
+	t.Run("ListEnginesCursor", func(t *testing.T) {
+		repos := newRepos(t)
+		small := mustCreateEngine(t, repos, 1000, 3, 500)
+		mid := mustCreateEngine(t, repos, 2000, 4, 600)
+		large := mustCreateEngine(t, repos, 3000, 6, 700)
+
+		page1 := listEnginePage(t, repos, engines.EngineFilter{Sort: "carRange", Desc: true, Limit: 2})
+		assertEngineIDs(t, page1.Items, []*engines.Engine{large, mid})
+
+		page2 := listEnginePage(t, repos, engines.EngineFilter{Limit: 2, Cursor: page1.Next})
+		assertEngineIDs(t, page2.Items, []*engines.Engine{small})
+		if page2.Next != nil || page2.Total != 3 {
+			t.Fatalf("last page next = %v, total = %d", page2.Next, page2.Total)
+		}
+
+		back := listEnginePage(t, repos, engines.EngineFilter{Limit: 2, Cursor: page2.Prev})
+		assertEngineIDs(t, back.Items, []*engines.Engine{large, mid})
+	})
+
+	t.Run("GetEngineByIDNotFound", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.Engine.GetEngineByID(context.Background(), uuid.NewString())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		_, err = repos.Engine.GetEngineByID(context.Background(), "not-a-uuid")
+		assertErrorIs(t, err, apperrors.ErrInvalidID)
+	})
+
+	t.Run("UpdateEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		created := mustCreateEngine(t, repos, 1998, 4, 600)
+		req := &engines.EngineRequest{Displacement: 2500, NoOfCylinders: 6, CarRange: 650}
+
+		updated, err := repos.Engine.UpdateEngine(ctx, created.EngineID.String(), req, created.Version)
+		if err != nil {
+			t.Fatalf("UpdateEngine: %v", err)
+		}
+		want := engines.Engine{EngineID: created.EngineID, Displacement: 2500, NoOfCylinders: 6, CarRange: 650, Version: 2}
+		if *updated != want {
+			t.Fatalf("UpdateEngine = %+v, want %+v", updated, want)
+		}
+
+		got, err := repos.Engine.GetEngineByID(ctx, created.EngineID.String())
+		if err != nil {
+			t.Fatalf("GetEngineByID: %v", err)
+		}
+		if *got != want {
+			t.Fatalf("GetEngineByID after update = %+v, want %+v", got, want)
+		}
+
+		_, err = repos.Engine.UpdateEngine(ctx, uuid.NewString(), req, etag.Any)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		_, err = repos.Engine.UpdateEngine(ctx, "not-a-uuid", req, etag.Any)
+		assertErrorIs(t, err, apperrors.ErrInvalidID)
+	})
+
+	t.Run("PatchEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		created := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		carRange := uint16(640)
+		patched, err := repos.Engine.PatchEngine(ctx, created.EngineID.String(), &engines.EnginePatch{CarRange: &carRange}, created.Version)
+		if err != nil {
+			t.Fatalf("PatchEngine: %v", err)
+		}
+		want := engines.Engine{EngineID: created.EngineID, Displacement: 1998, NoOfCylinders: 4, CarRange: 640, Version: 2}
+		if *patched != want {
+			t.Fatalf("PatchEngine = %+v, want %+v", patched, want)
+		}
+
+		got, err := repos.Engine.GetEngineByID(ctx, created.EngineID.String())
+		if err != nil {
+			t.Fatalf("GetEngineByID: %v", err)
+		}
+		if *got != want {
+			t.Fatalf("GetEngineByID after patch = %+v, want %+v", got, want)
+		}
+
+		_, err = repos.Engine.PatchEngine(ctx, uuid.NewString(), &engines.EnginePatch{CarRange: &carRange}, etag.Any)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("DeleteEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		created := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		deleted, err := repos.Engine.DeleteEngine(ctx, created.EngineID.String(), created.Version)
+		if err != nil {
+			t.Fatalf("DeleteEngine: %v", err)
+		}
+		if deleted.DeletedAt == nil || deleted.Version != created.Version+1 || deleted.CarRange != created.CarRange {
+			t.Fatalf("DeleteEngine = %+v, want deletedAt set and version %d", deleted, created.Version+1)
+		}
+
+		_, err = repos.Engine.GetEngineByID(ctx, created.EngineID.String())
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		assertEngineIDs(t, listEnginePage(t, repos, engines.EngineFilter{}).Items, nil)
+		assertEngineIDs(t, listEnginePage(t, repos, engines.EngineFilter{Trashed: true}).Items, []*engines.Engine{created})
+	})
+
+	t.Run("RestoreEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		created := mustCreateEngine(t, repos, 1998, 4, 600)
+		id := created.EngineID.String()
+
+		_, err := repos.Engine.RestoreEngine(ctx, id)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+
+		deleted, err := repos.Engine.DeleteEngine(ctx, id, etag.Any)
+		if err != nil {
+			t.Fatalf("DeleteEngine: %v", err)
+		}
+
+		restored, err := repos.Engine.RestoreEngine(ctx, id)
+		if err != nil {
+			t.Fatalf("RestoreEngine: %v", err)
+		}
+		want := *created
+		want.Version = deleted.Version + 1
+		if *restored != want {
+			t.Fatalf("RestoreEngine = %+v, want %+v", restored, want)
+		}
+
+		got, err := repos.Engine.GetEngineByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetEngineByID: %v", err)
+		}
+		if *got != want {
+			t.Fatalf("GetEngineByID after restore = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("PurgeEngines", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		unused := mustCreateEngine(t, repos, 1998, 4, 600)
+		used := mustCreateEngine(t, repos, 2500, 6, 700)
+		car := mustCreateCar(t, repos, carRequest("Civic", "Honda", used))
+
+		if _, err := repos.Car.DeleteCar(ctx, car.CarID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+		for _, engine := range []*engines.Engine{unused, used} {
+			if _, err := repos.Engine.DeleteEngine(ctx, engine.EngineID.String(), etag.Any); err != nil {
+				t.Fatalf("DeleteEngine: %v", err)
+			}
+		}
+
+		// The engine of a trashed car stays until the car is purged.
+		purged, err := repos.Engine.PurgeEngines(ctx, time.Now().Add(time.Second))
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeEngines = %d, %v, want 1", purged, err)
+		}
+		assertEngineIDs(t, listEnginePage(t, repos, engines.EngineFilter{Trashed: true}).Items, []*engines.Engine{used})
+
+		if _, err := repos.Car.PurgeCars(ctx, time.Now().Add(time.Second)); err != nil {
+			t.Fatalf("PurgeCars: %v", err)
+		}
+		purged, err = repos.Engine.PurgeEngines(ctx, time.Now().Add(time.Second))
+		if err != nil || purged != 1 {
+			t.Fatalf("PurgeEngines after purging cars = %d, %v, want 1", purged, err)
+		}
+	})
+
+	t.Run("DeleteEngineNotFound", func(t *testing.T) {
+		repos := newRepos(t)
+
+		_, err := repos.Engine.DeleteEngine(context.Background(), uuid.NewString(), etag.Any)
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("EngineHistory", func(t *testing.T) {
+		ctx := requestctx.WithActor(context.Background(), "bob")
+		repos := newRepos(t)
+
+		created, err := repos.Engine.CreateEngine(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+		if err != nil {
+			t.Fatalf("CreateEngine: %v", err)
+		}
+		id := created.EngineID.String()
+
+		req := &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 650}
+		if _, err := repos.Engine.UpdateEngine(ctx, id, req, etag.Any); err != nil {
+			t.Fatalf("UpdateEngine: %v", err)
+		}
+		if _, err := repos.Engine.DeleteEngine(ctx, id, etag.Any); err != nil {
+			t.Fatalf("DeleteEngine: %v", err)
+		}
+		if _, err := repos.Engine.RestoreEngine(ctx, id); err != nil {
+			t.Fatalf("RestoreEngine: %v", err)
+		}
+
+		history, err := repos.Audit.ListHistory(ctx, audit.EntityEngine, created.EngineID)
+		if err != nil {
+			t.Fatalf("ListHistory: %v", err)
+		}
+		assertActions(t, history, audit.ActionRestore, audit.ActionDelete, audit.ActionUpdate, audit.ActionCreate)
+
+		update := history[2]
+		assertChangedFields(t, update, "carRange")
+		if update.Actor != "bob" || update.Changes[0].Before != 600.0 || update.Changes[0].After != 650.0 {
+			t.Fatalf("update entry = %+v, want bob changing carRange 600 -> 650", update)
+		}
+		assertChangedFields(t, history[0], "deletedAt")
+		if history[0].Changes[0].After != nil {
+			t.Fatalf("restore entry = %+v, want deletedAt cleared", history[0])
+		}
+	})
+
+	t.Run("DeleteEngineDuringCarCreate", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+
+		err := duringCarCreate(t, repos, carRequest("Civic", "Honda", engine), func() error {
+			_, err := repos.Engine.DeleteEngine(ctx, engine.EngineID.String(), etag.Any)
+			return err
+		})
+		assertErrorIs(t, err, apperrors.ErrConflict)
+
+		if _, err := repos.Engine.GetEngineByID(ctx, engine.EngineID.String()); err != nil {
+			t.Fatalf("GetEngineByID after the conflict: %v", err)
+		}
+	})
+
+	t.Run("EngineOptimisticLocking", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		created := mustCreateEngine(t, repos, 1998, 4, 600)
+		id := created.EngineID.String()
+
+		carRange := uint16(650)
+		patched, err := repos.Engine.PatchEngine(ctx, id, &engines.EnginePatch{CarRange: &carRange}, created.Version)
+		if err != nil {
+			t.Fatalf("PatchEngine: %v", err)
+		}
+		if patched.Version != created.Version+1 {
+			t.Fatalf("patched version = %d, want %d", patched.Version, created.Version+1)
+		}
+
+		req := &engines.EngineRequest{Displacement: 2000, NoOfCylinders: 4, CarRange: 700}
+		_, err = repos.Engine.UpdateEngine(ctx, id, req, created.Version)
+		assertErrorIs(t, err, apperrors.ErrPreconditionFailed)
+		_, err = repos.Engine.PatchEngine(ctx, id, &engines.EnginePatch{CarRange: &carRange}, created.Version)
+		assertErrorIs(t, err, apperrors.ErrPreconditionFailed)
+		_, err = repos.Engine.DeleteEngine(ctx, id, created.Version)
+		assertErrorIs(t, err, apperrors.ErrPreconditionFailed)
+
+		got, err := repos.Engine.GetEngineByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetEngineByID: %v", err)
+		}
+		if *got != *patched {
+			t.Fatalf("engine after stale writes = %+v, want %+v", got, patched)
+		}
+
+		if _, err := repos.Engine.DeleteEngine(ctx, id, patched.Version); err != nil {
+			t.Fatalf("DeleteEngine: %v", err)
+		}
+	})
+
+	t.Run("DeleteEngineInUse", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		car := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+
+		_, err := repos.Engine.DeleteEngine(ctx, engine.EngineID.String(), etag.Any)
+		assertErrorIs(t, err, apperrors.ErrConflict)
+		var appErr *apperrors.Error
+		if !errors.As(err, &appErr) {
+			t.Fatalf("DeleteEngine error = %v, want an *apperrors.Error", err)
+		}
+		if ids, _ := appErr.Extensions["carIds"].([]uuid.UUID); !slices.Equal(ids, []uuid.UUID{car.CarID}) || appErr.Extensions["carCount"] != 1 {
+			t.Fatalf("DeleteEngine conflict extensions = %v, want carIds [%s] and carCount 1", appErr.Extensions, car.CarID)
+		}
+
+		got, err := repos.Engine.GetEngineByID(ctx, engine.EngineID.String())
+		if err != nil {
+			t.Fatalf("GetEngineByID: %v", err)
+		}
+		if *got != *engine {
+			t.Fatalf("engine after failed delete = %+v, want %+v", got, engine)
+		}
+
+		// Trashed cars no longer count as users of the engine.
+		if _, err := repos.Car.DeleteCar(ctx, car.CarID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+		page := listEnginePage(t, repos, engines.EngineFilter{})
+		if len(page.Items) != 1 || page.Items[0].UsageCount != 0 {
+			t.Fatalf("ListEngines after trashing the car = %+v, want usage count 0", page.Items)
+		}
+		if _, err := repos.Engine.DeleteEngine(ctx, engine.EngineID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteEngine after trashing the car: %v", err)
+		}
+	})
+
+	t.Run("FindEngineBySpec", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		first := mustCreateEngine(t, repos, 1998, 4, 600)
+		second := mustCreateEngine(t, repos, 1998, 4, 600)
+		mustCreateEngine(t, repos, 1998, 4, 700)
+		want := first
+		if second.EngineID.String() < first.EngineID.String() {
+			want = second
+		}
+
+		got, err := repos.Engine.FindEngineBySpec(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+		if err != nil {
+			t.Fatalf("FindEngineBySpec: %v", err)
+		}
+		if got.EngineID != want.EngineID {
+			t.Fatalf("FindEngineBySpec = %s, want the lowest ID %s", got.EngineID, want.EngineID)
+		}
+
+		// Trashed engines are not matched.
+		if _, err := repos.Engine.DeleteEngine(ctx, want.EngineID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteEngine: %v", err)
+		}
+		got, err = repos.Engine.FindEngineBySpec(ctx, &engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600})
+		if err != nil {
+			t.Fatalf("FindEngineBySpec after delete: %v", err)
+		}
+		if got.EngineID == want.EngineID {
+			t.Fatalf("FindEngineBySpec after delete = %s, want the other engine", got.EngineID)
+		}
+
+		_, err = repos.Engine.FindEngineBySpec(ctx, &engines.EngineRequest{Displacement: 2487, NoOfCylinders: 4, CarRange: 600})
+		assertErrorIs(t, err, apperrors.ErrNotFound)
+	})
+}
+
+func runReplaceEngineTests(t *testing.T, newRepos Factory) {
+	t.Run("ReplaceEngine", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		from := mustCreateEngine(t, repos, 1998, 4, 600)
+		to := mustCreateEngine(t, repos, 2487, 4, 700)
+		civic := mustCreateCar(t, repos, carRequest("Civic", "Honda", from))
+		accord := mustCreateCar(t, repos, carRequest("Accord", "Honda", from))
+		trashed := mustCreateCar(t, repos, carRequest("Jazz", "Honda", from))
+		if _, err := repos.Car.DeleteCar(ctx, trashed.CarID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteCar: %v", err)
+		}
+
+		moved, err := repos.Car.ReplaceEngine(ctx, from.EngineID, &to.EngineID)
+		if err != nil {
+			t.Fatalf("ReplaceEngine: %v", err)
+		}
+		want := []*cars.Car{civic, accord}
+		slices.SortFunc(want, func(a, b *cars.Car) int { return slices.Compare(a.CarID[:], b.CarID[:]) })
+		assertCarIDs(t, moved, want)
+
+		for _, car := range moved {
+			if car.Engine == nil || car.Engine.EngineID != to.EngineID || car.Version != 2 {
+				t.Fatalf("moved car = %+v, want engine %s at version 2", car, to.EngineID)
+			}
+			history, err := repos.Audit.ListHistory(ctx, audit.EntityCar, car.CarID)
+			if err != nil {
+				t.Fatalf("ListHistory: %v", err)
+			}
+			assertActions(t, history, audit.ActionUpdate, audit.ActionCreate)
+			assertChangedFields(t, history[0], "engineId")
+		}
+
+		// Trashed cars keep their engine.
+		if _, err := repos.Car.RestoreCar(ctx, trashed.CarID.String()); err != nil {
+			t.Fatalf("RestoreCar: %v", err)
+		}
+		got, err := repos.Car.GetCarById(ctx, trashed.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		if got.Engine == nil || got.Engine.EngineID != from.EngineID {
+			t.Fatalf("trashed car engine = %+v, want %s", got.Engine, from.EngineID)
+		}
+	})
+
+	t.Run("ReplaceEngineDetach", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		car := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+
+		moved, err := repos.Car.ReplaceEngine(ctx, engine.EngineID, nil)
+		if err != nil {
+			t.Fatalf("ReplaceEngine: %v", err)
+		}
+		assertCarIDs(t, moved, []*cars.Car{car})
+
+		got, err := repos.Car.GetCarById(ctx, car.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		if got.Engine != nil {
+			t.Fatalf("detached car engine = %+v, want none", got.Engine)
+		}
+		if _, err := repos.Engine.DeleteEngine(ctx, engine.EngineID.String(), etag.Any); err != nil {
+			t.Fatalf("DeleteEngine after detaching: %v", err)
+		}
+	})
+
+	t.Run("ReplaceEngineMissing", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		engine := mustCreateEngine(t, repos, 1998, 4, 600)
+		car := mustCreateCar(t, repos, carRequest("Civic", "Honda", engine))
+
+		missing := uuid.New()
+		_, err := repos.Car.ReplaceEngine(ctx, engine.EngineID, &missing)
+		assertErrorIs(t, err, cars.ErrEngineNotExists)
+
+		got, err := repos.Car.GetCarById(ctx, car.CarID.String())
+		if err != nil {
+			t.Fatalf("GetCarById: %v", err)
+		}
+		if got.Engine == nil || got.Engine.EngineID != engine.EngineID || got.Version != car.Version {
+			t.Fatalf("car after failed replace = %+v, want it unchanged", got)
+		}
+	})
+
+	t.Run("ReplaceEngineDuringCarCreate", func(t *testing.T) {
+		ctx := context.Background()
+		repos := newRepos(t)
+		from := mustCreateEngine(t, repos, 1998, 4, 600)
+		to := mustCreateEngine(t, repos, 2500, 4, 650)
+		civic := mustCreateCar(t, repos, carRequest("Civic", "Honda", from))
+
+		var moved []*cars.Car
+		err := duringCarCreate(t, repos, carRequest("Accord", "Honda", from), func() error {
+			var err error
+			moved, err = repos.Car.ReplaceEngine(ctx, from.EngineID, &to.EngineID)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("ReplaceEngine: %v", err)
+		}
+		if len(moved) != 2 || !slices.ContainsFunc(moved, func(car *cars.Car) bool { return car.CarID == civic.CarID }) {
+			t.Fatalf("moved = %+v, want civic and the car created meanwhile", moved)
+		}
+		for _, car := range moved {
+			history, err := repos.Audit.ListHistory(ctx, audit.EntityCar, car.CarID)
+			if err != nil {
+				t.Fatalf("ListHistory: %v", err)
+			}
+			assertActions(t, history, audit.ActionUpdate, audit.ActionCreate)
+			if history[0].Changes[0].Before != from.EngineID.String() {
+				t.Fatalf("update entry of %s = %+v, want engineId from %s", car.CarID, history[0].Changes, from.EngineID)
+			}
+		}
+	})
+}
+
+// duringCarCreate runs write while a transaction that has created a car from
+// req is still open, then commits the transaction and returns the error of
+// write. write must wait for the transaction to end, as it does when the
+// engine of the car is locked.
+func duringCarCreate(t *testing.T, repos *Repositories, req *cars.CarRequest, write func() error) error {
+	t.Helper()
+
+	created := make(chan error, 1)
+	release := make(chan struct{})
+	committed := make(chan error, 1)
+	go func() {
+		committed <- repos.Tx.WithinTx(context.Background(), func(ctx context.Context) error {
+			_, err := repos.Car.CreateCar(ctx, req)
+			created <- err
+			if err != nil {
+				return err
+			}
+			<-release
+			return nil
+		})
+	}()
+	if err := <-created; err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+
+	written := make(chan error, 1)
+	go func() { written <- write() }()
+
+	select {
+	case err := <-written:
+		close(release)
+		t.Fatalf("write returned %v while the car was being created", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-committed; err != nil {
+		t.Fatalf("commit CreateCar: %v", err)
+	}
+	return <-written
+}
+
+func carRequest(name, brand string, engine *engines.Engine) *cars.CarRequest {
+	return &cars.CarRequest{
+		Name:     name,
+		Year:     2020,
+		Brand:    brand,
+		FuelType: "Petrol",
+		Engine:   &engines.Engine{EngineID: engine.EngineID},
+		Price:    25999.99,
+	}
+}
+
+func mustCreateEngine(t *testing.T, repos *Repositories, displacement, cylinders, carRange uint16) *engines.Engine {
+	t.Helper()
+
+	engine, err := repos.Engine.CreateEngine(context.Background(), &engines.EngineRequest{
+		Displacement:  displacement,
+		NoOfCylinders: cylinders,
+		CarRange:      carRange,
+	})
+	if err != nil {
+		t.Fatalf("CreateEngine: %v", err)
+	}
+	return engine
+}
+
+func mustCreateCar(t *testing.T, repos *Repositories, req *cars.CarRequest) *cars.Car {
+	t.Helper()
+
+	car, err := repos.Car.CreateCar(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateCar: %v", err)
+	}
+	return car
+}
+
+// assertCar compares the car columns. Timestamps are compared to the
+// microsecond, the precision Postgres stores.
+func assertCar(t *testing.T, got, want *cars.Car) {
+	t.Helper()
+
+	if got.CarID != want.CarID || got.Name != want.Name || got.Year != want.Year ||
+		got.Brand != want.Brand || got.FuelType != want.FuelType || got.Price != want.Price ||
+		got.Version != want.Version {
+		t.Fatalf("car = %+v, want %+v", got, want)
+	}
+	if !sameTime(got.CreatedAt, want.CreatedAt) || !sameTime(got.UpdatedAt, want.UpdatedAt) {
+		t.Fatalf("car timestamps = (%v, %v), want (%v, %v)", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+}
+
+func assertCarIDs(t *testing.T, got, want []*cars.Car) {
+	t.Helper()
+
+	names := func(list []*cars.Car) []string {
+		out := make([]string, len(list))
+		for i, car := range list {
+			out[i] = car.Name
+		}
+		return out
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("cars = %v, want %v", names(got), names(want))
+	}
+	for i := range got {
+		if got[i].CarID != want[i].CarID {
+			t.Fatalf("cars = %v, want %v", names(got), names(want))
+		}
+	}
+}
+
+// listCarPage fetches one page the way the car service does: one row more
+// than the limit, then trimmed by pagination.NewPage.
+func listCarPage(t *testing.T, repos *Repositories, filter cars.CarFilter) *pagination.Page[*cars.Car] {
+	t.Helper()
+
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	query := filter
+	query.Limit++
+	rows, total, err := repos.Car.ListCars(context.Background(), &query)
+	if err != nil {
+		t.Fatalf("ListCars: %v", err)
+	}
+
+	return pagination.NewPage(rows, total, filter.Limit, filter.Offset, filter.Sort, filter.Desc, filter.Cursor,
+		func(car *cars.Car) (any, uuid.UUID) { return car.SortValue(filter.Sort), car.CarID })
+}
+
+func listEnginePage(t *testing.T, repos *Repositories, filter engines.EngineFilter) *pagination.Page[*engines.EngineUsage] {
+	t.Helper()
+
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	query := filter
+	query.Limit++
+	rows, total, err := repos.Engine.ListEngines(context.Background(), &query)
+	if err != nil {
+		t.Fatalf("ListEngines: %v", err)
+	}
+
+	return pagination.NewPage(rows, total, filter.Limit, filter.Offset, filter.Sort, filter.Desc, filter.Cursor,
+		func(engine *engines.EngineUsage) (any, uuid.UUID) {
+			return engine.SortValue(filter.Sort), engine.EngineID
+		})
+}
+
+func assertEngineIDs(t *testing.T, got []*engines.EngineUsage, want []*engines.Engine) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("engines = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i].EngineID != want[i].EngineID {
+			t.Fatalf("engines = %v, want %v", got, want)
+		}
+	}
+}
+
+func assertActions(t *testing.T, history []*audit.Entry, want ...audit.Action) {
+	t.Helper()
+
+	got := make([]audit.Action, len(history))
+	for i, e := range history {
+		got[i] = e.Action
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("history actions = %v, want %v", got, want)
+	}
+}
+
+func assertChangedFields(t *testing.T, e *audit.Entry, want ...string) {
+	t.Helper()
+
+	got := make([]string, len(e.Changes))
+	for i, c := range e.Changes {
+		got[i] = c.Field
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("%s entry changed %v, want %v", e.Action, got, want)
+	}
+}
+
+func sameTime(a, b time.Time) bool {
+	return a.Truncate(time.Microsecond).Equal(b.Truncate(time.Microsecond))
+}
+
+func assertErrorIs(t *testing.T, err, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Fatalf("error = %v, want %v", err, target)
+	}
+}
@@ -0,0 +1,202 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	uuidType       = reflect.TypeFor[uuid.UUID]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Name sets the component name of the type of v, which defaults to the name
+// of the Go type. It must be called before the type is first used.
+func (d *Document) Name(v any, name string) {
+	t := reflect.TypeOf(v)
+	if prev, ok := d.names[t]; ok && prev != name {
+		panic(fmt.Sprintf("openapi: %s is already named %s", t, prev))
+	}
+	d.names[t] = name
+}
+
+// Schema returns the schema of the JSON form of v. Named struct types are
+// added to the components once and referenced.
+//
+// A struct with validate tags is read as a request: a field is required
+// when its validate tag says so. Any other struct is read as a response,
+// whose fields are required unless they are omitempty.
+func (d *Document) Schema(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return d.schemaOf(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		s := &Schema{Type: "integer", Minimum: ptr(0.0)}
+		if t.Bits() < 64 {
+			s.Maximum = ptr(float64(uint64(1)<<t.Bits() - 1))
+		}
+		return s
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return ArrayOf(d.schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.component(t)
+	}
+	panic(fmt.Sprintf("openapi: no schema for %s", t))
+}
+
+func (d *Document) component(t reflect.Type) *Schema {
+	name, ok := d.names[t]
+	if !ok {
+		name = t.Name()
+		for other, n := range d.names {
+			if n == name && other != t {
+				panic(fmt.Sprintf("openapi: %s and %s are both named %s", t, other, name))
+			}
+		}
+		d.names[t] = name
+	}
+
+	if _, ok := d.Components.Schemas[name]; !ok {
+		// The placeholder ends recursion through self-referencing types.
+		d.Components.Schemas[name] = &Schema{}
+		*d.Components.Schemas[name] = *d.structSchema(t)
+	}
+	return Ref(name)
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	request := hasValidateTags(t)
+	d.addFields(s, t, request)
+	return s
+}
+
+// addFields adds the fields of t to s, with the fields of embedded structs
+// inline as encoding/json renders them.
+func (d *Document) addFields(s *Schema, t reflect.Type, request bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			d.addFields(s, f.Type, request)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		omitempty := strings.Contains(","+opts+",", ",omitempty,")
+		rules := strings.Split(f.Tag.Get("validate"), ",")
+
+		prop := d.schemaOf(f.Type)
+		if prop.Ref == "" {
+			applyRules(prop, rules)
+		}
+		if f.Type.Kind() == reflect.Pointer && !omitempty && !request {
+			prop = Nullable(prop)
+		}
+		s.Properties[name] = prop
+
+		required := !omitempty
+		if request {
+			required = hasRule(rules, "required")
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// applyRules turns the validate rules that bound a value into schema
+// constraints. Custom rules are described by the document itself.
+func applyRules(s *Schema, rules []string) {
+	for _, rule := range rules {
+		tag, param, _ := strings.Cut(rule, "=")
+		switch tag {
+		case "gte", "min":
+			if n, err := strconv.ParseFloat(param, 64); err == nil {
+				s.Minimum = &n
+			}
+		case "lte", "max":
+			if n, err := strconv.ParseFloat(param, 64); err == nil {
+				s.Maximum = &n
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, v)
+			}
+		}
+	}
+}
+
+func hasValidateTags(t reflect.Type) bool {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Tag.Get("validate") != "" {
+			return true
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && hasValidateTags(f.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		if tag, _, _ := strings.Cut(rule, "="); tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,55 @@
+package openapi
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// swaggerUIVersion pins the Swagger UI release the docs page loads.
+const swaggerUIVersion = "5.17.14"
+
+var uiTemplate = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+<script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-standalone-preset.js" crossorigin></script>
+<script>
+window.onload = () => {
+  window.ui = SwaggerUIBundle({
+    urls: {{.Specs}},
+    dom_id: "#swagger-ui",
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout",
+  });
+};
+</script>
+</body>
+</html>
+`))
+
+// Spec is a document the Swagger UI page offers.
+type Spec struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// UIPage renders a Swagger UI page showing specs, the first one by default.
+func UIPage(title string, specs ...Spec) []byte {
+	var buf bytes.Buffer
+	err := uiTemplate.Execute(&buf, map[string]any{
+		"Title":   title,
+		"Version": swaggerUIVersion,
+		"Specs":   specs,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
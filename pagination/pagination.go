@@ -0,0 +1,213 @@
+// Package pagination implements keyset (cursor) paging. A cursor records the
+// sort key and ID of the row a page ended on; the next query resumes strictly
+// after it, so rows inserted meanwhile never shift or repeat a page.
+package pagination
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/google/uuid"
+)
+
+var errInvalidCursor = apperrors.BadRequest(nil, "invalid cursor")
+
+type Cursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d,omitempty"`
+	Value json.RawMessage `json:"v"`
+	ID    uuid.UUID       `json:"i"`
+	// Before asks for the rows preceding the boundary row instead of the
+	// ones following it.
+	Before bool `json:"b,omitempty"`
+}
+
+func newCursor(sort string, desc bool, value any, id uuid.UUID, before bool) *Cursor {
+	raw, _ := json.Marshal(value)
+	return &Cursor{Sort: sort, Desc: desc, Value: raw, ID: id, Before: before}
+}
+
+// DecodeValue decodes the stored sort key into a value of the same type as
+// zero.
+func (c *Cursor) DecodeValue(zero any) (any, error) {
+	v := reflect.New(reflect.TypeOf(zero))
+	if err := json.Unmarshal(c.Value, v.Interface()); err != nil {
+		return nil, errInvalidCursor
+	}
+	return v.Elem().Interface(), nil
+}
+
+// Reversed reports whether the rows must be fetched in the opposite of the
+// listing order: a Before cursor walks backwards from its boundary.
+func (c *Cursor) Reversed() bool {
+	return c != nil && c.Before
+}
+
+// Signer turns cursors into opaque tokens. Tokens carry an HMAC so clients
+// cannot forge or edit them.
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// NewRandomSigner uses a fresh key, so its tokens only stay valid for the
+// life of the process.
+func NewRandomSigner() *Signer {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return NewSigner(key)
+}
+
+func (s *Signer) Encode(c *Cursor) string {
+	if c == nil {
+		return ""
+	}
+
+	payload, _ := json.Marshal(c)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(s.sign(payload))
+}
+
+// Decode verifies and parses a token from Encode. An empty token yields a
+// nil cursor.
+func (s *Signer) Decode(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	enc := base64.RawURLEncoding
+
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, s.sign(payload)) {
+		return nil, errInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(payload, &c); err != nil || c.Sort == "" {
+		return nil, errInvalidCursor
+	}
+	return &c, nil
+}
+
+func (s *Signer) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Page is one page of a listing together with the cursors around it.
+type Page[T any] struct {
+	Items []T
+	Total int
+	Next  *Cursor
+	Prev  *Cursor
+}
+
+// Key extracts the sort value and ID of an item for building cursors.
+type Key[T any] func(item T) (value any, id uuid.UUID)
+
+// NewPage trims rows fetched with limit+1 to limit and works out the next
+// and previous cursors. rows must already be in listing order, also when they
+// were fetched backwards for a Before cursor.
+func NewPage[T any](rows []T, total, limit, offset int, sort string, desc bool, cursor *Cursor, key Key[T]) *Page[T] {
+	more := len(rows) > limit
+	if more {
+		if cursor.Reversed() {
+			rows = rows[1:]
+		} else {
+			rows = rows[:limit]
+		}
+	}
+
+	page := &Page[T]{Items: rows, Total: total}
+	if len(rows) == 0 {
+		return page
+	}
+
+	firstValue, firstID := key(rows[0])
+	lastValue, lastID := key(rows[len(rows)-1])
+
+	hasNext := more
+	hasPrev := offset > 0 || cursor != nil
+	if cursor.Reversed() {
+		hasNext, hasPrev = true, more
+	}
+
+	if hasNext {
+		page.Next = newCursor(sort, desc, lastValue, lastID, false)
+	}
+	if hasPrev {
+		page.Prev = newCursor(sort, desc, firstValue, firstID, true)
+	}
+	return page
+}
+
+// Compare orders two sort values of the same type the way Postgres orders
+// the matching columns. Strings compare by bytes, so text sort columns in
+// Postgres must use the "C" collation.
+func Compare(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case uint16:
+		return cmp.Compare(a, b.(uint16))
+	case int:
+		return cmp.Compare(a, b.(int))
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case uuid.UUID:
+		bb := b.(uuid.UUID)
+		return bytes.Compare(a[:], bb[:])
+	}
+	panic("pagination: unsupported sort value type")
+}
+
+// After reports whether the row (value, id) lies past the cursor boundary in
+// the direction the cursor walks.
+func (c *Cursor) After(value, boundary any, id uuid.UUID) bool {
+	n := Compare(value, boundary)
+	if n == 0 {
+		n = Compare(id, c.ID)
+	}
+	if c.Desc != c.Before {
+		n = -n
+	}
+	return n > 0
+}
+
+// Meta builds the "meta" object of a listing response. nextCursor and
+// prevCursor are left out when there is no page in that direction.
+func Meta[T any](s *Signer, page *Page[T], limit, offset int) map[string]any {
+	meta := map[string]any{"total": page.Total, "limit": limit, "offset": offset}
+	if page.Next != nil {
+		meta["nextCursor"] = s.Encode(page.Next)
+	}
+	if page.Prev != nil {
+		meta["prevCursor"] = s.Encode(page.Prev)
+	}
+	return meta
+}
@@ -0,0 +1,109 @@
+package pagination
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/google/uuid"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	s := NewSigner([]byte("secret"))
+	want := newCursor("price", true, 52000.5, uuid.New(), true)
+
+	got, err := s.Decode(s.Encode(want))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got.Sort != want.Sort || got.Desc != want.Desc || got.ID != want.ID || got.Before != want.Before {
+		t.Fatalf("Decode = %+v, want %+v", got, want)
+	}
+
+	value, err := got.DecodeValue(float64(0))
+	if err != nil || value != 52000.5 {
+		t.Fatalf("DecodeValue = %v, %v", value, err)
+	}
+
+	if c, err := s.Decode(""); c != nil || err != nil {
+		t.Fatalf("Decode(\"\") = %v, %v, want nil cursor", c, err)
+	}
+}
+
+func TestSignerRejectsTamperedCursors(t *testing.T) {
+	s := NewSigner([]byte("secret"))
+	token := s.Encode(newCursor("name", false, "Civic", uuid.New(), false))
+	payload, sig, _ := strings.Cut(token, ".")
+
+	forged := NewSigner([]byte("other")).Encode(newCursor("name", false, "Civic", uuid.New(), false))
+	_, forgedSig, _ := strings.Cut(forged, ".")
+
+	tests := map[string]string{
+		"garbage":        "not-a-cursor",
+		"edited payload": "x" + payload[1:] + "." + sig,
+		"other key":      forged,
+		"swapped sig":    payload + "." + forgedSig,
+		"missing sig":    payload,
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.Decode(token)
+			if !errors.Is(err, apperrors.ErrBadRequest) {
+				t.Fatalf("Decode error = %v, want bad request", err)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	key := func(n int) (any, uuid.UUID) { return n, uuid.Nil }
+	after := &Cursor{Sort: "n"}
+	before := &Cursor{Sort: "n", Before: true}
+
+	tests := []struct {
+		name     string
+		rows     []int
+		offset   int
+		cursor   *Cursor
+		want     []int
+		next     bool
+		prev     bool
+		nextFrom int
+		prevFrom int
+	}{
+		{"first page with more", []int{1, 2, 3}, 0, nil, []int{1, 2}, true, false, 2, 0},
+		{"single page", []int{1, 2}, 0, nil, []int{1, 2}, false, false, 0, 0},
+		{"offset page", []int{3, 4}, 2, nil, []int{3, 4}, false, true, 0, 3},
+		{"after cursor", []int{3, 4, 5}, 0, after, []int{3, 4}, true, true, 4, 3},
+		{"before cursor with more", []int{1, 2, 3}, 0, before, []int{2, 3}, true, true, 3, 2},
+		{"before cursor at start", []int{1, 2}, 0, before, []int{1, 2}, true, false, 2, 0},
+		{"empty", nil, 0, after, nil, false, false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(tt.rows, 10, 2, tt.offset, "n", false, tt.cursor, key)
+
+			if len(page.Items) != len(tt.want) {
+				t.Fatalf("items = %v, want %v", page.Items, tt.want)
+			}
+			for i := range page.Items {
+				if page.Items[i] != tt.want[i] {
+					t.Fatalf("items = %v, want %v", page.Items, tt.want)
+				}
+			}
+
+			if (page.Next != nil) != tt.next || (page.Prev != nil) != tt.prev {
+				t.Fatalf("cursors = (next %v, prev %v), want (%v, %v)", page.Next, page.Prev, tt.next, tt.prev)
+			}
+			if tt.next && (string(page.Next.Value) != strconv.Itoa(tt.nextFrom) || page.Next.Before) {
+				t.Fatalf("next = %+v, want after %d", page.Next, tt.nextFrom)
+			}
+			if tt.prev && (string(page.Prev.Value) != strconv.Itoa(tt.prevFrom) || !page.Prev.Before) {
+				t.Fatalf("prev = %+v, want before %d", page.Prev, tt.prevFrom)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+)
+
+type operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	path  []string
+	from  []string
+	value any
+}
+
+// NewJSONPatch parses a JSON Patch (RFC 6902) document. Malformed operations
+// are rejected before anything is applied.
+func NewJSONPatch(body []byte) (*Patch, error) {
+	var ops []*operation
+	if err := decode(body, &ops); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if err := op.parse(); err != nil {
+			return nil, apperrors.BadRequest(err, "operation %d: %v", i, err)
+		}
+	}
+
+	return &Patch{apply: func(doc any) (any, error) {
+		var err error
+		for _, op := range ops {
+			if doc, err = op.apply(doc); err != nil {
+				return nil, err
+			}
+		}
+		return doc, nil
+	}}, nil
+}
+
+func (op *operation) parse() error {
+	var err error
+	if op.path, err = parsePointer(op.Path); err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("%s requires a value", op.Op)
+		}
+		return json.Unmarshal(op.Value, &op.value)
+	case "move", "copy":
+		if op.from, err = parsePointer(op.From); err != nil {
+			return err
+		}
+		if op.Op == "move" && len(op.path) > len(op.from) && slices.Equal(op.path[:len(op.from)], op.from) {
+			return errors.New("cannot move a value into one of its children")
+		}
+		return nil
+	case "remove":
+		return nil
+	}
+	return fmt.Errorf("unknown op %q", op.Op)
+}
+
+func (op *operation) apply(doc any) (any, error) {
+	switch op.Op {
+	case "add":
+		return add(doc, op.path, deepCopy(op.value), op.Path)
+	case "remove":
+		return remove(doc, op.path, op.Path)
+	case "replace":
+		doc, err := remove(doc, op.path, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, deepCopy(op.value), op.Path)
+	case "move":
+		value, err := get(doc, op.from, op.From)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = remove(doc, op.from, op.From); err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, value, op.Path)
+	case "copy":
+		value, err := get(doc, op.from, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.path, deepCopy(value), op.Path)
+	case "test":
+		value, err := get(doc, op.path, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, op.value) {
+			return nil, apperrors.Conflict("patch test failed at %q", op.Path)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", p)
+	}
+
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func pathError(pointer, msg string) error {
+	field := strings.ReplaceAll(strings.TrimPrefix(pointer, "/"), "/", ".")
+	return apperrors.Validation(nil, apperrors.FieldError{Field: field, Message: msg})
+}
+
+func get(doc any, path []string, pointer string) (any, error) {
+	node := doc
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, pathError(pointer, "path "+pointer+" does not exist")
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, pathError(pointer, err.Error())
+			}
+			node = n[i]
+		default:
+			return nil, pathError(pointer, "path "+pointer+" does not exist")
+		}
+	}
+	return node, nil
+}
+
+// update walks to the container holding the last token of path, replaces it
+// with the result of fn and rebuilds the containers back up to the root, since
+// inserting into an array can reallocate it.
+func update(node any, path []string, pointer string, fn func(container any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+
+	child, err := get(node, path[:1], pointer)
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, path[1:], pointer, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		n[path[0]] = child
+	case []any:
+		i, _ := arrayIndex(path[0], len(n)-1)
+		n[i] = child
+	}
+	return node, nil
+}
+
+func add(doc any, path []string, value any, pointer string) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, pointer, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[token] = value
+			return c, nil
+		case []any:
+			if token == "-" {
+				return append(c, value), nil
+			}
+			i, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, pathError(pointer, err.Error())
+			}
+			return slices.Insert(c, i, value), nil
+		}
+		return nil, pathError(pointer, "path "+pointer+" does not exist")
+	})
+}
+
+func remove(doc any, path []string, pointer string) (any, error) {
+	if len(path) == 0 {
+		return nil, pathError(pointer, "cannot remove the whole document")
+	}
+
+	return update(doc, path, pointer, func(container any, token string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			if _, ok := c[token]; !ok {
+				return nil, pathError(pointer, "path "+pointer+" does not exist")
+			}
+			delete(c, token)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(token, len(c)-1)
+			if err != nil {
+				return nil, pathError(pointer, err.Error())
+			}
+			return slices.Delete(c, i, i+1), nil
+		}
+		return nil, pathError(pointer, "path "+pointer+" does not exist")
+	})
+}
+
+// arrayIndex parses an array index token no greater than last.
+func arrayIndex(token string, last int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > last {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return i, nil
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[key] = deepCopy(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = deepCopy(value)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,35 @@
+package patch
+
+// NewMergePatch parses a JSON Merge Patch (RFC 7396) document.
+func NewMergePatch(body []byte) (*Patch, error) {
+	var mp any
+	if err := decode(body, &mp); err != nil {
+		return nil, err
+	}
+
+	return &Patch{apply: func(doc any) (any, error) {
+		return mergePatch(doc, mp), nil
+	}}, nil
+}
+
+// mergePatch is the MergePatch function of RFC 7396, section 2.
+func mergePatch(target, patch any) any {
+	obj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+
+	for key, value := range obj {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergePatch(t[key], value)
+	}
+	return t
+}
@@ -0,0 +1,174 @@
+// Package patch applies partial updates sent to PATCH routes, as JSON Merge
+// Patch (RFC 7396) or JSON Patch (RFC 6902). Patches are applied to the JSON
+// form of a request struct, so the result is validated and written like any
+// other request.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// Patch is a parsed PATCH body.
+type Patch struct {
+	apply func(doc any) (any, error)
+}
+
+// Read parses the body of r according to its Content-Type. Plain
+// application/json is treated as a merge patch.
+func Read(r *http.Request) (*Patch, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = r.Header.Get("Content-Type")
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apperrors.BadRequest(err, "the request body could not be read")
+	}
+
+	switch mediaType {
+	case MergePatchContentType, "application/json":
+		return NewMergePatch(body)
+	case JSONPatchContentType:
+		return NewJSONPatch(body)
+	}
+	return nil, apperrors.UnsupportedMediaType(mediaType, MergePatchContentType, JSONPatchContentType)
+}
+
+// Translate returns p as a patch of another form of the same document, such
+// as the representation of a resource in another version of the API. into
+// converts the document to the form p was written for, and back converts the
+// patched document to the original form.
+func (p *Patch) Translate(into, back func(doc any) (any, error)) *Patch {
+	return &Patch{apply: func(doc any) (any, error) {
+		doc, err := into(doc)
+		if err != nil {
+			return nil, err
+		}
+		patched, err := p.apply(doc)
+		if err != nil {
+			return nil, err
+		}
+		return back(patched)
+	}}
+}
+
+// ApplyTo patches v, which must be a pointer to a struct, in place and
+// returns the JSON names of the top-level fields whose value changed.
+func (p *Patch) ApplyTo(v any) ([]string, error) {
+	// The patch may modify doc in place, so original is decoded separately.
+	doc, err := toDocument(v)
+	if err != nil {
+		return nil, err
+	}
+	original, err := toDocument(v)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := p.apply(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := patched.(map[string]any)
+	if !ok {
+		return nil, apperrors.BadRequest(nil, "the patched document must be an object")
+	}
+
+	known := jsonFields(reflect.TypeOf(v).Elem())
+	var fields []apperrors.FieldError
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		if !slices.Contains(known, key) {
+			fields = append(fields, apperrors.FieldError{Field: key, Message: key + " is not a known field"})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.Validation(nil, fields...)
+	}
+
+	var changed []string
+	for _, key := range known {
+		if !reflect.DeepEqual(original[key], obj[key]) {
+			changed = append(changed, key)
+		}
+	}
+
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	target := reflect.ValueOf(v).Elem()
+	target.SetZero()
+	if err := json.Unmarshal(raw, v); err != nil {
+		if field, ok := apperrors.DecodeFieldError(err); ok {
+			return nil, apperrors.Validation(err, field)
+		}
+		return nil, apperrors.InvalidJSON(err)
+	}
+
+	return changed, nil
+}
+
+// toDocument converts v to its generic JSON form.
+func toDocument(v any) (map[string]any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func decode(body []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if err := dec.Decode(v); err != nil {
+		return apperrors.InvalidJSON(err)
+	}
+	if dec.More() {
+		return apperrors.BadRequest(nil, "unexpected data after the patch document")
+	}
+	return nil
+}
+
+// jsonFields returns the JSON names of the exported fields of struct type t,
+// in declaration order.
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
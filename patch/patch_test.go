@@ -0,0 +1,219 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codepnw/go-car-management/apperrors"
+)
+
+func applyJSON(t *testing.T, p *Patch, doc string) (string, error) {
+	t.Helper()
+
+	var v any
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("bad test document: %v", err)
+	}
+
+	out, err := p.apply(v)
+	if err != nil {
+		return "", err
+	}
+	raw, _ := json.Marshal(out)
+	return string(raw), nil
+}
+
+func assertJSONEqual(t *testing.T, got, want string) {
+	t.Helper()
+
+	var g, w any
+	json.Unmarshal([]byte(got), &g)
+	json.Unmarshal([]byte(want), &w)
+	if !reflect.DeepEqual(g, w) {
+		t.Fatalf("result = %s, want %s", got, want)
+	}
+}
+
+// Examples from RFC 7396, appendix A.
+func TestMergePatch(t *testing.T) {
+	tests := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		p, err := NewMergePatch([]byte(tt.patch))
+		if err != nil {
+			t.Fatalf("NewMergePatch(%s): %v", tt.patch, err)
+		}
+		got, err := applyJSON(t, p, tt.doc)
+		if err != nil {
+			t.Fatalf("apply %s to %s: %v", tt.patch, tt.doc, err)
+		}
+		assertJSONEqual(t, got, tt.want)
+	}
+}
+
+// Examples from RFC 6902, appendix A.
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, want string
+		wantErr                error
+	}{
+		{"add object member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"remove object member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{"test success", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"test failure", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``, apperrors.ErrConflict},
+		{"add nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{"add to nonexistent target", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``, apperrors.ErrValidation},
+		{"escaped pointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`, nil},
+		{"append to array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, nil},
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`, nil},
+		{"remove missing", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, ``, apperrors.ErrValidation},
+		{"index out of range", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":3}]`, ``, apperrors.ErrValidation},
+		{"later op fails", `{"a":1}`, `[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":1}]`, ``, apperrors.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewJSONPatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("NewJSONPatch: %v", err)
+			}
+
+			got, err := applyJSON(t, p, tt.doc)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestJSONPatchRejectsMalformedOperations(t *testing.T) {
+	tests := map[string]string{
+		"not an array":  `{"op":"add"}`,
+		"unknown op":    `[{"op":"frobnicate","path":"/a"}]`,
+		"missing value": `[{"op":"add","path":"/a"}]`,
+		"bad pointer":   `[{"op":"remove","path":"a"}]`,
+		"move to child": `[{"op":"move","from":"/a","path":"/a/b"}]`,
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewJSONPatch([]byte(body)); !errors.Is(err, apperrors.ErrBadRequest) {
+				t.Fatalf("error = %v, want bad request", err)
+			}
+		})
+	}
+}
+
+type request struct {
+	Name  string `json:"name"`
+	Year  uint16 `json:"year"`
+	Notes string `json:"notes,omitempty"`
+}
+
+func TestApplyTo(t *testing.T) {
+	req := &request{Name: "Civic", Year: 2020, Notes: "spare key"}
+
+	p, _ := NewMergePatch([]byte(`{"year":2021,"name":"Civic","notes":null}`))
+	changed, err := p.ApplyTo(req)
+	if err != nil {
+		t.Fatalf("ApplyTo: %v", err)
+	}
+	if *req != (request{Name: "Civic", Year: 2021}) {
+		t.Fatalf("patched = %+v", req)
+	}
+	if !reflect.DeepEqual(changed, []string{"year", "notes"}) {
+		t.Fatalf("changed = %v, want [year notes]", changed)
+	}
+
+	p, _ = NewMergePatch([]byte(`{"colour":"red"}`))
+	if _, err := p.ApplyTo(req); !errors.Is(err, apperrors.ErrValidation) {
+		t.Fatalf("unknown field error = %v, want validation", err)
+	}
+
+	p, _ = NewJSONPatch([]byte(`[{"op":"replace","path":"/year","value":"soon"}]`))
+	_, err = p.ApplyTo(req)
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation || appErr.Fields[0].Field != "year" {
+		t.Fatalf("type error = %v, want validation on year", err)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	// The patch is written for a form that names the field "title".
+	rename := func(from, to string) func(doc any) (any, error) {
+		return func(doc any) (any, error) {
+			obj := doc.(map[string]any)
+			if v, ok := obj[from]; ok {
+				delete(obj, from)
+				obj[to] = v
+			}
+			return obj, nil
+		}
+	}
+
+	p, _ := NewJSONPatch([]byte(`[{"op":"replace","path":"/title","value":"Jazz"}]`))
+	req := &request{Name: "Civic", Year: 2020}
+	changed, err := p.Translate(rename("name", "title"), rename("title", "name")).ApplyTo(req)
+	if err != nil {
+		t.Fatalf("ApplyTo: %v", err)
+	}
+	if *req != (request{Name: "Jazz", Year: 2020}) || !reflect.DeepEqual(changed, []string{"name"}) {
+		t.Fatalf("patched = %+v, changed = %v", req, changed)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		wantErr     error
+	}{
+		{"application/merge-patch+json", `{"a":1}`, nil},
+		{"application/merge-patch+json; charset=utf-8", `{"a":1}`, nil},
+		{"application/json", `{"a":1}`, nil},
+		{"application/json-patch+json", `[{"op":"remove","path":"/a"}]`, nil},
+		{"text/plain", `{"a":1}`, apperrors.ErrUnsupportedMediaType},
+		{"", `{"a":1}`, apperrors.ErrUnsupportedMediaType},
+		{"application/merge-patch+json", `{"a":`, apperrors.ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		r, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", tt.contentType)
+
+		_, err := Read(r)
+		if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+			t.Fatalf("Read(%q) error = %v, want %v", tt.contentType, err, tt.wantErr)
+		}
+	}
+}
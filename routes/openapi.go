@@ -0,0 +1,951 @@
+package routes
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/middlewares"
+	"github.com/codepnw/go-car-management/modules/audit"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carexport"
+	"github.com/codepnw/go-car-management/modules/cars/carimport"
+	"github.com/codepnw/go-car-management/modules/cars/carv2"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/codepnw/go-car-management/modules/engines/enginev2"
+	"github.com/codepnw/go-car-management/modules/jobs"
+	"github.com/codepnw/go-car-management/openapi"
+	"github.com/codepnw/go-car-management/patch"
+)
+
+const (
+	specPath = "/openapi.json"
+	docsPath = "/docs"
+)
+
+const apiDescription = `Manages an inventory of cars and the engines they use.
+
+Successful responses wrap their payload in a "data" member; listings add a
+"meta" member with paging information. Errors are sent as RFC 7807 problem
+documents (application/problem+json) whose "code" is stable for clients to
+match on.
+
+Writes to an existing car or engine require an If-Match header holding the
+ETag of the version being changed, or "*" to skip the check.`
+
+// OpenAPI describes every route NewRoutes mounts under version.
+func OpenAPI(version string) *openapi.Document {
+	s := &spec{
+		Document: openapi.New(openapi.Info{
+			Title:       "Car management API",
+			Version:     strings.TrimPrefix(version, "/"),
+			Description: apiDescription,
+		}),
+		version: version,
+	}
+	s.Tags = []*openapi.Tag{
+		{Name: "cars", Description: "Cars, their trash and their history."},
+		{Name: "engines", Description: "Engines, which cars reference."},
+		{Name: "jobs", Description: "Exports and imports running in the background."},
+		{Name: "docs", Description: "The OpenAPI documents of the API."},
+	}
+
+	s.components()
+	s.carPaths()
+	s.enginePaths()
+	s.jobPaths()
+	s.docsPaths()
+
+	switch version {
+	case V1:
+		s.Info.Description += "\n\n" + v1Deprecation
+		for path, item := range s.Paths {
+			if strings.HasPrefix(path, version+"/") {
+				for _, op := range *item {
+					op.Deprecated = true
+				}
+			}
+		}
+	case V2:
+		s.Info.Description += "\n\n" + v2Changes
+	}
+	return s.Document
+}
+
+var v1Deprecation = fmt.Sprintf(`Version 1 is deprecated in favor of version 2, and is served until %s.
+Its responses carry the Deprecation and Sunset headers when a newer version is served beside it.`, v1Sunset.Format(time.DateOnly))
+
+// v1ListCars records how the listing changed GET /v1/cars/, which used to
+// look cars up by brand.
+const v1ListCars = `This listing replaced the lookup by brand of earlier releases: brand now
+matches ignoring case and may be repeated, isEngine is ignored as engines
+are always included, and the cars are paged, 20 at a time by default.`
+
+const v2Changes = `Compared with version 1, cars and engines are identified by "id", a car
+nests its engine under "engine", prices are money objects, and nullable
+fields are sent as null rather than left out. NDJSON exports and the
+history and diff of cars use the same form; CSV and XLSX files keep the
+same columns in every version.`
+
+// spec builds the document with the shapes the handlers share.
+type spec struct {
+	*openapi.Document
+	version string
+}
+
+func (s *spec) components() {
+	s.Name(audit.Entry{}, "AuditEntry")
+	s.Name(audit.Change{}, "AuditChange")
+	s.Name(jobs.Failure{}, "JobFailure")
+	s.Name(jobs.Artifact{}, "JobArtifact")
+	s.Name(carimport.Report{}, "ImportReport")
+	s.Name(carimport.RowError{}, "ImportRowError")
+	s.Name(middlewares.Problem{}, "Problem")
+
+	schemas := s.Components.Schemas
+
+	switch s.version {
+	case V1:
+		s.v1Schemas()
+	case V2:
+		s.v2Schemas()
+	}
+
+	schemas["Engine"].Description = "An engine as the API returns it."
+	schemas["EngineUsage"].Description = "An engine in a listing, with the number of live cars using it."
+
+	engineRequest := schemas["EngineRequest"]
+	engineRequest.Description = "The fields of an engine a client writes. An engine without cylinders is electric."
+	engineRequest.Properties["displacement"] = openapi.Describe(engineRequest.Properties["displacement"],
+		"The displacement in cc; greater than 0 when the engine has cylinders.")
+	engineRequest.Properties["carRange"] = openapi.Describe(engineRequest.Properties["carRange"], "The range in km.")
+
+	schemas["BulkItemResult"] = &openapi.Schema{
+		Type:        "object",
+		Description: "The outcome of one item of a bulk request: the written car, or the problem the item would have had as a single request.",
+		Properties: map[string]*openapi.Schema{
+			"index":  {Type: "integer", Description: "The position of the item in the request."},
+			"status": {Type: "integer", Description: "The status the item would have had as a single request."},
+			"data":   openapi.Ref("Car"),
+			"error":  openapi.Ref("Problem"),
+		},
+		Required: []string{"index", "status"},
+	}
+	schemas["BulkResponse"] = openapi.Object(map[string]*openapi.Schema{
+		"data": openapi.ArrayOf(openapi.Ref("BulkItemResult")),
+		"meta": openapi.Object(map[string]*openapi.Schema{
+			"succeeded": {Type: "integer"},
+			"failed":    {Type: "integer"},
+		}),
+	})
+
+	schemas["ListMeta"] = &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"total":      {Type: "integer", Description: "The number of records matching the filters."},
+			"limit":      {Type: "integer"},
+			"offset":     {Type: "integer"},
+			"nextCursor": {Type: "string", Description: "The cursor of the next page, left out on the last page."},
+			"prevCursor": {Type: "string", Description: "The cursor of the previous page, left out on the first page."},
+		},
+		Required: []string{"total", "limit", "offset"},
+	}
+
+	s.Schema(audit.Entry{})
+	schemas["AuditChange"].Properties["before"] = &openapi.Schema{Description: "The value before the change, null when the field was not set."}
+	schemas["AuditChange"].Properties["after"] = &openapi.Schema{Description: "The value after the change, null when the field was cleared."}
+
+	s.Schema(jobs.Job{})
+	job := schemas["Job"]
+	job.Description = "A background job. Poll it until its status is succeeded, failed or canceled."
+	job.Properties["status"].Enum = []any{jobs.StatusQueued, jobs.StatusRunning, jobs.StatusSucceeded, jobs.StatusFailed, jobs.StatusCanceled}
+	job.Properties["kind"].Enum = []any{jobs.KindExport, jobs.KindImport}
+	job.Properties["result"] = &openapi.Schema{
+		Description: `What the job reported last. An export reports {"rows": n}. An import reports a checkpoint, {"rows": n, "report": ImportReport}, while it runs and its ImportReport once it succeeded.`,
+	}
+
+	s.Schema(carimport.Report{})
+	s.Schema(middlewares.Problem{})
+	problem := schemas["Problem"]
+	problem.Description = "An RFC 7807 problem document. Some problems carry extra members, such as the IDs of the records a conflict is about."
+	problem.Properties["code"].Enum = []any{
+		apperrors.CodeNotFound, apperrors.CodeConflict, apperrors.CodeValidation, apperrors.CodeInvalidID,
+		apperrors.CodeBadRequest, apperrors.CodeInternal, apperrors.CodeUnsupportedMediaType,
+		apperrors.CodePreconditionFailed, apperrors.CodePreconditionRequired, apperrors.CodeFailedDependency,
+	}
+	problem.Properties["errors"] = openapi.Describe(problem.Properties["errors"], "The invalid fields, for validation problems.")
+
+	schemas["MergePatch"] = &openapi.Schema{
+		Type:        "object",
+		Description: "A JSON Merge Patch (RFC 7396) of the request fields of the resource.",
+	}
+	schemas["JSONPatch"] = openapi.ArrayOf(&openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"op":    {Type: "string", Enum: []any{"add", "remove", "replace", "move", "copy", "test"}},
+			"path":  {Type: "string", Description: "A JSON Pointer into the request fields of the resource."},
+			"from":  {Type: "string"},
+			"value": {},
+		},
+		Required: []string{"op", "path"},
+	})
+	schemas["JSONPatch"].Description = "A JSON Patch (RFC 6902) of the request fields of the resource."
+
+	s.Components.Headers["ETag"] = &openapi.Header{
+		Description: "The version of the resource, to send back in If-Match.",
+		Schema:      &openapi.Schema{Type: "string", Example: `"3"`},
+	}
+	s.Components.Headers["X-Request-ID"] = &openapi.Header{
+		Description: "The correlation ID of the request, generated unless the client sent a valid one.",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	s.Components.Parameters["IfMatch"] = &openapi.Parameter{
+		Name:        "If-Match",
+		In:          "header",
+		Required:    true,
+		Description: `The ETag of the version being changed, or "*" to skip the check.`,
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	s.Components.Parameters["Actor"] = &openapi.Parameter{
+		Name:        middlewares.ActorHeader,
+		In:          "header",
+		Description: "Who makes the request, as recorded in the history of the records it changes.",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+	s.Components.Parameters["RequestID"] = &openapi.Parameter{
+		Name:        middlewares.RequestIDHeader,
+		In:          "header",
+		Description: "A correlation ID for the request, echoed in the response and in problem documents.",
+		Schema:      &openapi.Schema{Type: "string"},
+	}
+
+	for status, description := range problemResponses {
+		s.Components.Responses[problemName(status)] = &openapi.Response{
+			Description: description,
+			Content:     map[string]*openapi.MediaType{middlewares.ProblemContentType: {Schema: openapi.Ref("Problem")}},
+		}
+	}
+}
+
+func (s *spec) v1Schemas() {
+	schemas := s.Components.Schemas
+
+	s.Schema(cars.Car{})
+	car := schemas["Car"]
+	car.Description = "A car as the API returns it."
+	car.Properties["engineId"] = openapi.Describe(openapi.Nullable(openapi.Ref("Engine")),
+		"The engine of the car. Despite its name this member holds the whole engine, not only its ID. It is null when the engine was detached from the car.")
+	car.Properties["vin"] = openapi.Describe(car.Properties["vin"], "The vehicle identification number, left out when the car has none.")
+	car.Properties["version"] = openapi.Describe(car.Properties["version"], "The version of the car, which its ETag carries.")
+	car.Properties["deletedAt"] = openapi.Describe(car.Properties["deletedAt"], "When the car was moved to the trash; only set on trashed cars.")
+
+	s.Schema(engines.EngineUsage{})
+	s.carRequestSchema("engineId")
+	s.bulkSchemas("carId", cars.BulkCreateRequest{}, cars.BulkUpdateRequest{}, cars.BulkDeleteRequest{})
+}
+
+func (s *spec) v2Schemas() {
+	schemas := s.Components.Schemas
+
+	s.Schema(carv2.Money{})
+	money := schemas["Money"]
+	money.Description = "An amount of money."
+	money.Properties["amount"].Pattern = `^[0-9]+(\.[0-9]+)?$`
+	money.Properties["amount"] = openapi.Describe(money.Properties["amount"], "A decimal number, as a string so that it is not rounded.")
+	money.Properties["amount"].Example = "19999.99"
+	money.Properties["currency"].Enum = []any{carv2.Currency}
+
+	s.Schema(carv2.Car{})
+	car := schemas["Car"]
+	car.Description = "A car as the API returns it."
+	car.Properties["engine"] = openapi.Describe(car.Properties["engine"], "The engine of the car, null when the engine was detached from the car.")
+	car.Properties["vin"] = openapi.Describe(car.Properties["vin"], "The vehicle identification number, null when the car has none.")
+	car.Properties["version"] = openapi.Describe(car.Properties["version"], "The version of the car, which its ETag carries.")
+	car.Properties["deletedAt"] = openapi.Describe(car.Properties["deletedAt"], "When the car was moved to the trash, null unless the car is in the trash.")
+
+	schemas["Engine"].Properties["deletedAt"] = openapi.Describe(schemas["Engine"].Properties["deletedAt"],
+		"When the engine was moved to the trash, null unless the engine is in the trash.")
+	s.Schema(enginev2.EngineUsage{})
+
+	// A carv2.CarRequest is validated as the cars.CarRequest it converts
+	// to, so its schema is the one of cars.CarRequest with the parts that
+	// differ replaced. The engine of cars.CarRequest is one of them.
+	s.Name(carv2.CarRequest{}, "CarRequest")
+	s.Name(cars.CarRequest{}, "CarRequest")
+	s.Name(engines.Engine{}, "Engine")
+	carRequest := s.carRequestSchema("id")
+	carRequest.Properties["price"] = openapi.Describe(openapi.Ref("Money"), "The price, in "+carv2.Currency+".")
+
+	s.bulkSchemas("id", carv2.BulkCreateRequest{}, carv2.BulkUpdateRequest{}, carv2.BulkDeleteRequest{})
+}
+
+// carRequestSchema adds the CarRequest schema, where a stored engine is
+// referenced by engineID.
+func (s *spec) carRequestSchema(engineID string) *openapi.Schema {
+	schemas := s.Components.Schemas
+
+	schemas["EngineReference"] = &openapi.Schema{
+		Type:        "object",
+		Description: "A stored engine, referenced by its ID.",
+		Properties:  map[string]*openapi.Schema{engineID: {Type: "string", Format: "uuid"}},
+		Required:    []string{engineID},
+	}
+
+	s.Schema(cars.CarRequest{})
+	carRequest := schemas["CarRequest"]
+	carRequest.Description = "The fields of a car a client writes."
+	carRequest.Required = append(carRequest.Required, "fuelType")
+	carRequest.Properties["year"] = openapi.Describe(carRequest.Properties["year"], "The model year, no later than next year.")
+	carRequest.Properties["fuelType"] = openapi.Describe(carRequest.Properties["fuelType"],
+		"Electric cars need an engine without displacement or cylinders, Petrol and Diesel cars an engine with both, Hybrid cars an engine with a displacement.")
+	carRequest.Properties["engine"] = &openapi.Schema{
+		Description: "The engine of the car: a stored engine by its ID, or the specs of an engine. Specs reuse a stored engine with the same specs, or create one with the car.",
+		OneOf:       []*openapi.Schema{openapi.Ref("EngineReference"), s.Schema(engines.EngineRequest{})},
+	}
+	vin := carRequest.Properties["vin"]
+	vin.Pattern = "^[A-HJ-NPR-Z0-9]{17}$"
+	vin.Description = "A 17-character VIN without the letters I, O or Q, unique among live cars."
+	return carRequest
+}
+
+// bulkSchemas adds the schemas of the bulk request types, whose items
+// identify cars by carID. An updated item takes the fields of CarRequest.
+func (s *spec) bulkSchemas(carID string, create, update, remove any) {
+	schemas := s.Components.Schemas
+
+	for _, v := range []any{create, update, remove} {
+		s.Schema(v)
+	}
+	for _, name := range []string{"BulkCreateRequest", "BulkUpdateRequest", "BulkDeleteRequest"} {
+		items := schemas[name].Properties["items"]
+		items.MinItems, items.MaxItems = ptr(1), ptr(cars.MaxBulkItems)
+		schemas[name].Required = []string{"items"}
+	}
+
+	carRequest := schemas["CarRequest"]
+	ref := map[string]*openapi.Schema{
+		carID:     {Type: "string", Format: "uuid"},
+		"version": {Type: "integer", Format: "int64", Description: "The version the car was read at, as its ETag carries it."},
+	}
+	schemas["CarRef"] = &openapi.Schema{
+		Type:        "object",
+		Description: "A car to delete, with the version it was read at.",
+		Properties:  ref,
+		Required:    []string{carID, "version"},
+	}
+	carUpdate := &openapi.Schema{
+		Type:        "object",
+		Description: "A car to replace: the fields of CarRequest, with the car ID and the version it was read at.",
+		Properties:  maps.Clone(carRequest.Properties),
+		Required:    append([]string{carID, "version"}, carRequest.Required...),
+	}
+	maps.Copy(carUpdate.Properties, ref)
+	schemas["CarUpdate"] = carUpdate
+}
+
+// problemResponses are the error responses, by status.
+var problemResponses = map[int]string{
+	http.StatusBadRequest:           "The ID in the path or the body is malformed (codes invalid_id, bad_request).",
+	http.StatusNotFound:             "The resource does not exist (code not_found).",
+	http.StatusConflict:             "The request conflicts with stored data (code conflict).",
+	http.StatusPreconditionFailed:   "If-Match does not match the current version (code precondition_failed).",
+	http.StatusUnsupportedMediaType: "The body has an unsupported Content-Type (code unsupported_media_type).",
+	http.StatusUnprocessableEntity:  "The query or the body is invalid; errors lists the fields (code validation_failed).",
+	http.StatusPreconditionRequired: "If-Match is missing (code precondition_required).",
+	http.StatusInternalServerError:  "An unexpected error; quote the correlation ID when reporting it (code internal_error).",
+}
+
+func problemName(status int) string {
+	return strings.ReplaceAll(http.StatusText(status), " ", "")
+}
+
+func (s *spec) carPaths() {
+	base := s.version + "/cars"
+	tags := []string{"cars"}
+
+	listCars := &openapi.Operation{
+		OperationID: "listCars",
+		Summary:     "List cars",
+		Tags:        tags,
+		Parameters:  append(carCriteriaParams(), pageParams(cars.SortFields, cars.DefaultListLimit, cars.MaxListLimit)...),
+		Responses:   s.responses(http.StatusOK, list(openapi.Ref("Car")), http.StatusUnprocessableEntity),
+	}
+	if s.version == V1 {
+		listCars.Description = v1ListCars
+	}
+	s.Add(http.MethodGet, base+"/", listCars)
+	s.Add(http.MethodPost, base+"/", &openapi.Operation{
+		OperationID: "createCar",
+		Summary:     "Create a car",
+		Tags:        tags,
+		RequestBody: jsonBody(openapi.Ref("CarRequest")),
+		Responses: withETag(s.responses(http.StatusCreated, data(openapi.Ref("Car")),
+			http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity), http.StatusCreated),
+	})
+	s.Add(http.MethodGet, base+"/{id}", &openapi.Operation{
+		OperationID: "getCar",
+		Summary:     "Get a car",
+		Description: "With asOf the car is rebuilt from its history as it was then, and sent without an ETag.",
+		Tags:        tags,
+		Parameters: []*openapi.Parameter{idParam("car"), {
+			Name:        "asOf",
+			In:          "query",
+			Description: "An RFC 3339 time to read the car as of.",
+			Schema:      &openapi.Schema{Type: "string", Format: "date-time"},
+		}},
+		Responses: withETag(s.responses(http.StatusOK, data(openapi.Ref("Car")),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity), http.StatusOK),
+	})
+	s.Add(http.MethodPut, base+"/{id}", &openapi.Operation{
+		OperationID: "updateCar",
+		Summary:     "Replace a car",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("car"), ifMatch()},
+		RequestBody: jsonBody(openapi.Ref("CarRequest")),
+		Responses:   withETag(s.responses(http.StatusOK, data(openapi.Ref("Car")), writeProblems...), http.StatusOK),
+	})
+	s.Add(http.MethodPatch, base+"/{id}", &openapi.Operation{
+		OperationID: "patchCar",
+		Summary:     "Patch a car",
+		Description: "The patch applies to the CarRequest form of the car, with the engine referenced by its ID.",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("car"), ifMatch()},
+		RequestBody: patchBody(),
+		Responses: withETag(s.responses(http.StatusOK, data(openapi.Ref("Car")),
+			append(writeProblems, http.StatusUnsupportedMediaType)...), http.StatusOK),
+	})
+	s.Add(http.MethodDelete, base+"/{id}", &openapi.Operation{
+		OperationID: "deleteCar",
+		Summary:     "Move a car to the trash",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("car"), ifMatch()},
+		Responses:   s.responses(http.StatusNoContent, nil, http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed, http.StatusPreconditionRequired),
+	})
+
+	bulkDescription := "In atomic mode, the default, every item is written or none is; in partial mode the valid items are written. " +
+		"The response is 207 Multi-Status when any item failed."
+	for _, op := range []struct {
+		method, id, summary, body string
+		status                    int
+	}{
+		{http.MethodPost, "createCars", "Create cars in bulk", "BulkCreateRequest", http.StatusCreated},
+		{http.MethodPut, "updateCars", "Replace cars in bulk", "BulkUpdateRequest", http.StatusOK},
+		{http.MethodDelete, "deleteCars", "Move cars to the trash in bulk", "BulkDeleteRequest", http.StatusOK},
+	} {
+		responses := s.responses(op.status, openapi.Ref("BulkResponse"), http.StatusBadRequest, http.StatusUnprocessableEntity)
+		responses["207"] = &openapi.Response{
+			Description: "Some items failed.",
+			Content:     jsonContent(openapi.Ref("BulkResponse")),
+		}
+		s.Add(op.method, base+"/bulk", &openapi.Operation{
+			OperationID: op.id,
+			Summary:     op.summary,
+			Description: bulkDescription,
+			Tags:        tags,
+			Parameters: []*openapi.Parameter{{
+				Name:   "mode",
+				In:     "query",
+				Schema: &openapi.Schema{Type: "string", Enum: []any{cars.BulkAtomic, cars.BulkPartial}, Default: cars.BulkAtomic},
+			}},
+			RequestBody: jsonBody(openapi.Ref(op.body)),
+			Responses:   responses,
+		})
+	}
+
+	s.Add(http.MethodPost, base+"/import", &openapi.Operation{
+		OperationID: "importCars",
+		Summary:     "Import cars from CSV",
+		Description: "Every row stands on its own: rows that fail are listed in the report and the others are imported.",
+		Tags:        tags,
+		Parameters:  importParams(),
+		RequestBody: importBody(),
+		Responses: s.responses(http.StatusOK, data(openapi.Ref("ImportReport")),
+			http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity),
+	})
+	s.Add(http.MethodGet, base+"/export", &openapi.Operation{
+		OperationID: "exportCars",
+		Summary:     "Export cars",
+		Description: "Streams every car matching the filters as a file download.",
+		Tags:        tags,
+		Parameters:  append(carCriteriaParams(), formatParam()),
+		Responses: s.responses(http.StatusOK, nil, http.StatusUnprocessableEntity).
+			with(http.StatusOK, exportResponse()),
+	})
+
+	s.Add(http.MethodGet, base+"/trash", &openapi.Operation{
+		OperationID: "listTrashedCars",
+		Summary:     "List the cars in the trash",
+		Tags:        tags,
+		Parameters:  append(carCriteriaParams(), pageParams(cars.SortFields, cars.DefaultListLimit, cars.MaxListLimit)...),
+		Responses:   s.responses(http.StatusOK, list(openapi.Ref("Car")), http.StatusUnprocessableEntity),
+	})
+	s.Add(http.MethodDelete, base+"/trash", &openapi.Operation{
+		OperationID: "purgeCars",
+		Summary:     "Purge expired cars from the trash",
+		Description: "Permanently deletes the cars that have been in the trash longer than the retention period.",
+		Tags:        tags,
+		Responses:   s.responses(http.StatusOK, data(purged())),
+	})
+	s.Add(http.MethodPost, base+"/{id}/restore", &openapi.Operation{
+		OperationID: "restoreCar",
+		Summary:     "Restore a car from the trash",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("car")},
+		Responses: withETag(s.responses(http.StatusOK, data(openapi.Ref("Car")),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict), http.StatusOK),
+	})
+	s.Add(http.MethodGet, base+"/{id}/history", &openapi.Operation{
+		OperationID: "listCarHistory",
+		Summary:     "List the changes to a car",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("car")},
+		Responses:   s.responses(http.StatusOK, data(openapi.ArrayOf(openapi.Ref("AuditEntry"))), http.StatusBadRequest),
+	})
+	s.Add(http.MethodGet, base+"/{id}/diff", &openapi.Operation{
+		OperationID: "diffCar",
+		Summary:     "Compare a car at two points in time",
+		Description: "Lists the fields of the car, and of its engine under \"engine.\", that differ between from and to.",
+		Tags:        tags,
+		Parameters: []*openapi.Parameter{
+			idParam("car"),
+			{Name: "from", In: "query", Required: true, Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+			{Name: "to", In: "query", Required: true, Schema: &openapi.Schema{Type: "string", Format: "date-time"}},
+		},
+		Responses: s.responses(http.StatusOK, data(openapi.Object(map[string]*openapi.Schema{
+			"from":    {Type: "string", Format: "date-time"},
+			"to":      {Type: "string", Format: "date-time"},
+			"changes": openapi.ArrayOf(openapi.Ref("AuditChange")),
+		})), http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity),
+	})
+
+	s.Add(http.MethodGet, s.version+"/engines/{id}/cars", &openapi.Operation{
+		OperationID: "listEngineCars",
+		Summary:     "List the cars using an engine",
+		Tags:        tags,
+		Parameters: append(append([]*openapi.Parameter{idParam("engine")}, carCriteriaParams()...),
+			pageParams(cars.SortFields, cars.DefaultListLimit, cars.MaxListLimit)...),
+		Responses: s.responses(http.StatusOK, list(openapi.Ref("Car")),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity),
+	})
+}
+
+func (s *spec) enginePaths() {
+	base := s.version + "/engines"
+	tags := []string{"engines"}
+
+	filters := []*openapi.Parameter{
+		uint16Param("displacementMin", "The smallest displacement."),
+		uint16Param("displacementMax", "The largest displacement."),
+		uint16Param("noOfCylinders", "The number of cylinders."),
+		uint16Param("carRangeMin", "The shortest range."),
+		uint16Param("carRangeMax", "The longest range."),
+	}
+	listParams := append(filters, pageParams(engines.SortFields, engines.DefaultListLimit, engines.MaxListLimit)...)
+
+	s.Add(http.MethodGet, base+"/", &openapi.Operation{
+		OperationID: "listEngines",
+		Summary:     "List engines",
+		Tags:        tags,
+		Parameters:  listParams,
+		Responses:   s.responses(http.StatusOK, list(openapi.Ref("EngineUsage")), http.StatusUnprocessableEntity),
+	})
+	s.Add(http.MethodPost, base+"/", &openapi.Operation{
+		OperationID: "createEngine",
+		Summary:     "Create an engine",
+		Tags:        tags,
+		RequestBody: jsonBody(openapi.Ref("EngineRequest")),
+		Responses: withETag(s.responses(http.StatusCreated, data(openapi.Ref("Engine")),
+			http.StatusBadRequest, http.StatusUnprocessableEntity), http.StatusCreated),
+	})
+	s.Add(http.MethodGet, base+"/{id}", &openapi.Operation{
+		OperationID: "getEngine",
+		Summary:     "Get an engine",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("engine")},
+		Responses: withETag(s.responses(http.StatusOK, data(openapi.Ref("Engine")),
+			http.StatusBadRequest, http.StatusNotFound), http.StatusOK),
+	})
+	s.Add(http.MethodPut, base+"/{id}", &openapi.Operation{
+		OperationID: "updateEngine",
+		Summary:     "Replace an engine",
+		Description: "An engine used by cars cannot change in a way that no longer suits their fuel type.",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("engine"), ifMatch()},
+		RequestBody: jsonBody(openapi.Ref("EngineRequest")),
+		Responses:   withETag(s.responses(http.StatusOK, data(openapi.Ref("Engine")), writeProblems...), http.StatusOK),
+	})
+	s.Add(http.MethodPatch, base+"/{id}", &openapi.Operation{
+		OperationID: "patchEngine",
+		Summary:     "Patch an engine",
+		Description: "The patch applies to the EngineRequest form of the engine.",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("engine"), ifMatch()},
+		RequestBody: patchBody(),
+		Responses: withETag(s.responses(http.StatusOK, data(openapi.Ref("Engine")),
+			append(writeProblems, http.StatusUnsupportedMediaType)...), http.StatusOK),
+	})
+	s.Add(http.MethodDelete, base+"/{id}", &openapi.Operation{
+		OperationID: "deleteEngine",
+		Summary:     "Move an engine to the trash",
+		Description: "Without cascade or reassignTo the delete fails with 409 while live cars use the engine; the problem lists their IDs.",
+		Tags:        tags,
+		Parameters: []*openapi.Parameter{
+			idParam("engine"),
+			ifMatch(),
+			{
+				Name:        "cascade",
+				In:          "query",
+				Description: "detach clears the engine of the cars using it.",
+				Schema:      &openapi.Schema{Type: "string", Enum: []any{engines.CascadeDetach}},
+			},
+			{
+				Name:        "reassignTo",
+				In:          "query",
+				Description: "Moves the cars using the engine to this engine instead; not combinable with cascade.",
+				Schema:      &openapi.Schema{Type: "string", Format: "uuid"},
+			},
+		},
+		Responses: s.responses(http.StatusNoContent, nil, writeProblems...),
+	})
+
+	s.Add(http.MethodGet, base+"/trash", &openapi.Operation{
+		OperationID: "listTrashedEngines",
+		Summary:     "List the engines in the trash",
+		Tags:        tags,
+		Parameters:  listParams,
+		Responses:   s.responses(http.StatusOK, list(openapi.Ref("EngineUsage")), http.StatusUnprocessableEntity),
+	})
+	s.Add(http.MethodDelete, base+"/trash", &openapi.Operation{
+		OperationID: "purgeEngines",
+		Summary:     "Purge expired engines from the trash",
+		Description: "Permanently deletes the engines that have been in the trash longer than the retention period and that no car references.",
+		Tags:        tags,
+		Responses:   s.responses(http.StatusOK, data(purged())),
+	})
+	s.Add(http.MethodPost, base+"/{id}/restore", &openapi.Operation{
+		OperationID: "restoreEngine",
+		Summary:     "Restore an engine from the trash",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("engine")},
+		Responses: withETag(s.responses(http.StatusOK, data(openapi.Ref("Engine")),
+			http.StatusBadRequest, http.StatusNotFound), http.StatusOK),
+	})
+	s.Add(http.MethodGet, base+"/{id}/history", &openapi.Operation{
+		OperationID: "listEngineHistory",
+		Summary:     "List the changes to an engine",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("engine")},
+		Responses:   s.responses(http.StatusOK, data(openapi.ArrayOf(openapi.Ref("AuditEntry"))), http.StatusBadRequest),
+	})
+}
+
+func (s *spec) jobPaths() {
+	base := s.version + "/jobs"
+	tags := []string{"jobs"}
+
+	accepted := func(responses responses) responses {
+		responses["202"].Headers = map[string]*openapi.Header{
+			"Location": {Description: "Where to poll the job.", Schema: &openapi.Schema{Type: "string"}},
+		}
+		return responses
+	}
+
+	s.Add(http.MethodPost, base+"/exports", &openapi.Operation{
+		OperationID: "submitExport",
+		Summary:     "Export cars in the background",
+		Description: "Takes the query of GET /cars/export. The file is the artifact of the job once it succeeded.",
+		Tags:        tags,
+		Parameters:  append(carCriteriaParams(), formatParam()),
+		Responses:   accepted(s.responses(http.StatusAccepted, data(openapi.Ref("Job")), http.StatusUnprocessableEntity)),
+	})
+	s.Add(http.MethodPost, base+"/imports", &openapi.Operation{
+		OperationID: "submitImport",
+		Summary:     "Import cars in the background",
+		Description: "Takes the request of POST /cars/import. The file is stored with the job before the response is sent; the report is the result of the job.",
+		Tags:        tags,
+		Parameters:  importParams(),
+		RequestBody: importBody(),
+		Responses: accepted(s.responses(http.StatusAccepted, data(openapi.Ref("Job")),
+			http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)),
+	})
+	s.Add(http.MethodGet, base+"/{id}", &openapi.Operation{
+		OperationID: "getJob",
+		Summary:     "Get a job",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("job")},
+		Responses:   s.responses(http.StatusOK, data(openapi.Ref("Job")), http.StatusBadRequest, http.StatusNotFound),
+	})
+	s.Add(http.MethodPost, base+"/{id}/cancel", &openapi.Operation{
+		OperationID: "cancelJob",
+		Summary:     "Cancel a job",
+		Description: "A queued job is canceled right away; a running one stops shortly after.",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("job")},
+		Responses: s.responses(http.StatusAccepted, data(openapi.Ref("Job")),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict),
+	})
+	s.Add(http.MethodGet, base+"/{id}/artifact", &openapi.Operation{
+		OperationID: "getJobArtifact",
+		Summary:     "Download the file a job produced",
+		Tags:        tags,
+		Parameters:  []*openapi.Parameter{idParam("job")},
+		Responses: s.responses(http.StatusOK, nil, http.StatusBadRequest, http.StatusNotFound).
+			with(http.StatusOK, exportResponse()),
+	})
+}
+
+func (s *spec) docsPaths() {
+	tags := []string{"docs"}
+	document := responses{"200": {
+		Description: "An OpenAPI document.",
+		Content:     jsonContent(&openapi.Schema{Type: "object"}),
+	}}
+
+	s.Add(http.MethodGet, s.version+specPath, &openapi.Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "Get this OpenAPI document",
+		Tags:        tags,
+		Responses:   document,
+	})
+	s.Add(http.MethodGet, specPath, &openapi.Operation{
+		OperationID: "getLatestOpenAPI",
+		Summary:     "Get the OpenAPI document of the latest version",
+		Tags:        tags,
+		Responses:   document,
+	})
+	s.Add(http.MethodGet, docsPath, &openapi.Operation{
+		OperationID: "getDocs",
+		Summary:     "Browse the OpenAPI documents with Swagger UI",
+		Tags:        tags,
+		Responses: responses{"200": {
+			Description: "An HTML page.",
+			Content:     map[string]*openapi.MediaType{"text/html": {Schema: &openapi.Schema{Type: "string"}}},
+		}},
+	})
+}
+
+type responses map[string]*openapi.Response
+
+// responses builds the responses of an operation succeeding with status and
+// body, nil for none, and failing with problems. Every operation may fail
+// with a 500.
+func (s *spec) responses(status int, body *openapi.Schema, problems ...int) responses {
+	r := responses{strconv.Itoa(status): {Description: http.StatusText(status)}}
+	if body != nil {
+		r[strconv.Itoa(status)].Content = jsonContent(body)
+	}
+	for _, p := range append(problems, http.StatusInternalServerError) {
+		if _, ok := problemResponses[p]; !ok {
+			panic(fmt.Sprintf("routes: no problem response for %d", p))
+		}
+		r[strconv.Itoa(p)] = &openapi.Response{Ref: "#/components/responses/" + problemName(p)}
+	}
+	return r
+}
+
+// with replaces the response with status.
+func (r responses) with(status int, response *openapi.Response) responses {
+	r[strconv.Itoa(status)] = response
+	return r
+}
+
+// writeProblems are the failures of a write to an existing resource.
+var writeProblems = []int{
+	http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed,
+	http.StatusUnprocessableEntity, http.StatusPreconditionRequired,
+}
+
+func withETag(r responses, status int) responses {
+	r[strconv.Itoa(status)].Headers = map[string]*openapi.Header{"ETag": {Ref: "#/components/headers/ETag"}}
+	return r
+}
+
+func jsonContent(schema *openapi.Schema) map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{"application/json": {Schema: schema}}
+}
+
+func jsonBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: jsonContent(schema)}
+}
+
+func patchBody() *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required:    true,
+		Description: "application/json is read as a merge patch.",
+		Content: map[string]*openapi.MediaType{
+			patch.MergePatchContentType: {Schema: openapi.Ref("MergePatch")},
+			"application/json":          {Schema: openapi.Ref("MergePatch")},
+			patch.JSONPatchContentType:  {Schema: openapi.Ref("JSONPatch")},
+		},
+	}
+}
+
+func importBody() *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required:    true,
+		Description: "The CSV file, as the body or as the file part of a form. Its first row is the header, matched to car fields by the mapping.",
+		Content: map[string]*openapi.MediaType{
+			"text/csv": {Schema: &openapi.Schema{Type: "string"}},
+			"multipart/form-data": {Schema: &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"file": {Type: "string", Format: "binary"}},
+				Required:   []string{"file"},
+			}},
+		},
+	}
+}
+
+func importParams() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		{
+			Name:        "dryRun",
+			In:          "query",
+			Description: "Validates every row and reports what would be imported without writing anything.",
+			Schema:      &openapi.Schema{Type: "boolean", Default: false},
+		},
+		{
+			Name: "mapping",
+			In:   "query",
+			Description: "Overrides the header of the column of some fields, as field=Header pairs separated by commas. " +
+				"By default each field is read from the column named after it: " + strings.Join(carimport.Fields, ", ") + ".",
+			Schema: &openapi.Schema{Type: "string", Example: "name=Model,price=Price (EUR)"},
+		},
+		{
+			Name:        "delimiter",
+			In:          "query",
+			Description: `The column delimiter: a single character, or "tab".`,
+			Schema:      &openapi.Schema{Type: "string", Default: ","},
+		},
+	}
+}
+
+func formatParam() *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:   "format",
+		In:     "query",
+		Schema: &openapi.Schema{Type: "string", Enum: []any{carexport.CSV, carexport.NDJSON, carexport.XLSX}, Default: carexport.CSV},
+	}
+}
+
+func exportResponse() *openapi.Response {
+	file := &openapi.Schema{Type: "string", Format: "binary"}
+	return &openapi.Response{
+		Description: "The file, as an attachment. CSV and XLSX columns are the fields of a version 1 car with the engine flattened; NDJSON lines are Car objects.",
+		Headers: map[string]*openapi.Header{
+			"Content-Disposition": {Schema: &openapi.Schema{Type: "string", Example: `attachment; filename=cars-20240501-093000.csv`}},
+		},
+		Content: map[string]*openapi.MediaType{
+			carexport.CSV.ContentType():    {Schema: file},
+			carexport.NDJSON.ContentType(): {Schema: file},
+			carexport.XLSX.ContentType():   {Schema: file},
+		},
+	}
+}
+
+func data(schema *openapi.Schema) *openapi.Schema {
+	return openapi.Object(map[string]*openapi.Schema{"data": schema})
+}
+
+func list(items *openapi.Schema) *openapi.Schema {
+	return openapi.Object(map[string]*openapi.Schema{
+		"data": openapi.ArrayOf(items),
+		"meta": openapi.Ref("ListMeta"),
+	})
+}
+
+func purged() *openapi.Schema {
+	return openapi.Object(map[string]*openapi.Schema{"purged": {Type: "integer", Description: "The number of records deleted."}})
+}
+
+func idParam(resource string) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        "id",
+		In:          "path",
+		Required:    true,
+		Description: "The ID of the " + resource + ".",
+		Schema:      &openapi.Schema{Type: "string", Format: "uuid"},
+	}
+}
+
+func ifMatch() *openapi.Parameter {
+	return &openapi.Parameter{Ref: "#/components/parameters/IfMatch"}
+}
+
+func uint16Param(name, description string) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        name,
+		In:          "query",
+		Description: description,
+		Schema:      &openapi.Schema{Type: "integer", Minimum: ptr(0.0), Maximum: ptr(65535.0)},
+	}
+}
+
+// carCriteriaParams are the query parameters that select cars, which
+// listings and exports share.
+func carCriteriaParams() []*openapi.Parameter {
+	explode := false
+	return []*openapi.Parameter{
+		{
+			Name:        "brand",
+			In:          "query",
+			Description: "Brands to include; repeat the parameter or separate them with commas.",
+			Schema:      openapi.ArrayOf(&openapi.Schema{Type: "string"}),
+			Explode:     &explode,
+		},
+		{
+			Name:        "fuelType",
+			In:          "query",
+			Description: "Fuel types to include; repeat the parameter or separate them with commas.",
+			Schema:      openapi.ArrayOf(&openapi.Schema{Type: "string", Enum: []any{"Petrol", "Diesel", "Electric", "Hybrid"}}),
+			Explode:     &explode,
+		},
+		{Name: "name", In: "query", Description: "Matches names containing this text, ignoring case.", Schema: &openapi.Schema{Type: "string"}},
+		uint16Param("yearMin", "The earliest model year."),
+		uint16Param("yearMax", "The latest model year."),
+		{Name: "priceMin", In: "query", Description: "The lowest price.", Schema: &openapi.Schema{Type: "number"}},
+		{Name: "priceMax", In: "query", Description: "The highest price.", Schema: &openapi.Schema{Type: "number"}},
+		uint16Param("engineDisplacementMin", "The smallest engine displacement."),
+		uint16Param("engineDisplacementMax", "The largest engine displacement."),
+		uint16Param("engineCylinders", "The number of engine cylinders."),
+		uint16Param("engineCarRangeMin", "The shortest engine range."),
+		uint16Param("engineCarRangeMax", "The longest engine range."),
+		{
+			Name:        "sort",
+			In:          "query",
+			Description: `The field to order by, descending with a leading "-", as in -price.`,
+			Schema:      &openapi.Schema{Type: "string", Default: "createdAt"},
+		},
+	}
+}
+
+// pageParams are the paging parameters of a listing sorted by one of
+// sortFields.
+func pageParams(sortFields []string, defaultLimit, maxLimit int) []*openapi.Parameter {
+	return []*openapi.Parameter{
+		{
+			Name:   "limit",
+			In:     "query",
+			Schema: &openapi.Schema{Type: "integer", Minimum: ptr(0.0), Maximum: ptr(float64(maxLimit)), Default: defaultLimit},
+		},
+		{
+			Name:        "offset",
+			In:          "query",
+			Description: "Skips this many records; not combinable with cursor.",
+			Schema:      &openapi.Schema{Type: "integer", Minimum: ptr(0.0)},
+		},
+		{
+			Name:        "cursor",
+			In:          "query",
+			Description: "The nextCursor or prevCursor of a page, which carries its own sort order. Sort fields: " + strings.Join(sortFields, ", ") + ".",
+			Schema:      &openapi.Schema{Type: "string"},
+		},
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
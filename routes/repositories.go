@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"database/sql"
+
+	"github.com/codepnw/go-car-management/database"
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/modules/audit/auditrepositories"
+	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
+	"github.com/codepnw/go-car-management/modules/jobs/jobrepositories"
+)
+
+type Repositories struct {
+	Car    carrepositories.ICarRepository
+	Engine engrepositories.IEngineRepository
+	Audit  auditrepositories.IAuditRepository
+	Job    jobrepositories.IJobRepository
+	// Tx lets services run calls to several repositories in one transaction.
+	Tx database.Transactor
+}
+
+func NewPostgresRepositories(db *sql.DB) *Repositories {
+	return &Repositories{
+		Car:    carrepositories.NewCarRepository(db),
+		Engine: engrepositories.NewEngineRepository(db),
+		Audit:  auditrepositories.NewAuditRepository(db),
+		Job:    jobrepositories.NewJobRepository(db),
+		Tx:     database.NewTxManager(db),
+	}
+}
+
+func NewMemoryRepositories(db *memdb.DB) *Repositories {
+	return &Repositories{
+		Car:    carrepositories.NewCarMemoryRepository(db),
+		Engine: engrepositories.NewEngineMemoryRepository(db),
+		Audit:  auditrepositories.NewAuditMemoryRepository(db),
+		Job:    jobrepositories.NewJobMemoryRepository(db),
+		Tx:     db,
+	}
+}
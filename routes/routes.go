@@ -1,49 +1,202 @@
 package routes
 
 import (
-	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
-	"github.com/codepnw/go-car-management/modules/cars/carrepositories"
+	"github.com/codepnw/go-car-management/middlewares"
+	"github.com/codepnw/go-car-management/modules/audit"
+	audithandlers "github.com/codepnw/go-car-management/modules/audit/handlers"
+	auditservices "github.com/codepnw/go-car-management/modules/audit/services"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/cars/carimport"
+	"github.com/codepnw/go-car-management/modules/cars/carv2"
 	carhandlers "github.com/codepnw/go-car-management/modules/cars/handlers"
 	carservices "github.com/codepnw/go-car-management/modules/cars/services"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/codepnw/go-car-management/modules/engines/enginev2"
 	enghandlers "github.com/codepnw/go-car-management/modules/engines/handlers"
-	engrepositories "github.com/codepnw/go-car-management/modules/engines/repositories"
 	engservices "github.com/codepnw/go-car-management/modules/engines/services"
+	jobhandlers "github.com/codepnw/go-car-management/modules/jobs/handlers"
+	jobservices "github.com/codepnw/go-car-management/modules/jobs/services"
+	"github.com/codepnw/go-car-management/openapi"
+	"github.com/codepnw/go-car-management/pagination"
 	"github.com/gin-gonic/gin"
 )
 
-func NewRoutes(db *sql.DB, r *gin.Engine, version string) {
-	carRoutes(db, r, version)
-	engineRoutes(db, r, version)
+// Config holds what the routes need besides storage.
+type Config struct {
+	// Cursors signs the keyset pagination cursors of list endpoints.
+	Cursors *pagination.Signer
+	// TrashRetention is how long deleted records stay restorable before they
+	// may be purged.
+	TrashRetention time.Duration
 }
 
-func carRoutes(db *sql.DB, r *gin.Engine, version string) {
-	g := r.Group(version + "/cars")
+// The API versions NewRoutes can mount, by path prefix.
+const (
+	V1 = "/v1"
+	V2 = "/v2"
+)
+
+var (
+	// v1Deprecated is when v2 shipped, and v1Sunset when v1 stops being
+	// served.
+	v1Deprecated = time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	v1Sunset     = time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// apiVersion is what differs between the versions of the API.
+type apiVersion struct {
+	cars    cars.Representation
+	engines engines.Representation
+}
+
+func versionOf(version string) apiVersion {
+	switch version {
+	case V1:
+		return apiVersion{cars: cars.V1, engines: engines.V1}
+	case V2:
+		return apiVersion{cars: carv2.Representation, engines: enginev2.Representation}
+	}
+	panic(fmt.Sprintf("routes: unknown API version %q", version))
+}
+
+// versionMiddlewares returns the middlewares that run before every route of
+// version, when latest is the newest version mounted. v1 is only marked
+// deprecated when a successor is mounted to point to.
+func versionMiddlewares(version, latest string) []gin.HandlerFunc {
+	if version == V1 && latest != V1 {
+		return []gin.HandlerFunc{middlewares.Deprecation(v1Deprecated, v1Sunset, latest+specPath)}
+	}
+	return nil
+}
+
+// CarRepresentation returns the form of cars in version, the v1 form when
+// version is empty.
+func CarRepresentation(version string) cars.Representation {
+	if version == "" {
+		return cars.V1
+	}
+	return versionOf(version).cars
+}
+
+// NewRoutes mounts versions side by side, each under its own path prefix,
+// oldest first. The versions share storage and services; they differ in the
+// form of their bodies.
+func NewRoutes(repos *Repositories, cfg *Config, r *gin.Engine, versions ...string) {
+	latest := versions[len(versions)-1]
+	for _, version := range versions {
+		api := versionOf(version)
+		g := r.Group(version, versionMiddlewares(version, latest)...)
 
-	repo := carrepositories.NewCarRepository(db)
-	service := carservices.NewCarService(repo)
-	handler := carhandlers.NewCarHandler(service)
+		carRoutes(repos, cfg, g, api)
+		engineRoutes(repos, cfg, g, api)
+		jobRoutes(repos, g, version)
+	}
+	docsRoutes(r, versions)
+}
+
+func carRoutes(repos *Repositories, cfg *Config, api *gin.RouterGroup, version apiVersion) {
+	g := api.Group("/cars")
+
+	service := carservices.NewCarService(repos.Car, repos.Engine, repos.Audit, repos.Tx)
+	handler := carhandlers.NewCarHandler(service, cfg.Cursors, cfg.TrashRetention, version.cars)
+	history := audithandlers.NewAuditHandler(auditservices.NewAuditService(repos.Audit), audit.EntityCar, version.cars.Changes)
+	imports := carhandlers.NewImportHandler(carimport.NewImporter(service, repos.Engine))
 
 	idParam := "/:id"
 
 	g.GET(idParam, handler.GetCarByID)
-	g.GET("/", handler.GetCarByBrand)
+	g.GET("/", handler.ListCars)
 	g.POST("/", handler.CreateCar)
-	g.PATCH(idParam, handler.UpdateCar)
+	g.PUT(idParam, handler.UpdateCar)
+	g.PATCH(idParam, handler.PatchCar)
 	g.DELETE(idParam, handler.DeleteCar)
+
+	g.POST("/bulk", handler.CreateCars)
+	g.PUT("/bulk", handler.UpdateCars)
+	g.DELETE("/bulk", handler.DeleteCars)
+	g.POST("/import", imports.ImportCars)
+	g.GET("/export", handler.ExportCars)
+
+	g.GET("/trash", handler.ListTrashedCars)
+	g.DELETE("/trash", handler.PurgeCars)
+	g.POST(idParam+"/restore", handler.RestoreCar)
+	g.GET(idParam+"/history", history.ListHistory)
+	g.GET(idParam+"/diff", handler.DiffCar)
+
+	// The cars of an engine are served by the car handler, nested under the
+	// engine they belong to.
+	api.GET("/engines"+idParam+"/cars", handler.ListEngineCars)
 }
 
-func engineRoutes(db *sql.DB, r *gin.Engine, version string) {
-	g := r.Group(version + "/engines")
+func engineRoutes(repos *Repositories, cfg *Config, api *gin.RouterGroup, version apiVersion) {
+	g := api.Group("/engines")
 
-	repo := engrepositories.NewEngineRepository(db)
-	service := engservices.NewEngineService(repo)
-	handler := enghandlers.NewEngineHandler(service)
+	service := engservices.NewEngineService(repos.Engine, repos.Car, repos.Tx)
+	handler := enghandlers.NewEngineHandler(service, cfg.Cursors, cfg.TrashRetention, version.engines)
+	history := audithandlers.NewAuditHandler(auditservices.NewAuditService(repos.Audit), audit.EntityEngine, nil)
 
 	idParam := "/:id"
 
 	g.GET(idParam, handler.GetEngineByID)
+	g.GET("/", handler.ListEngines)
 	g.POST("/", handler.CreateEngine)
-	g.PATCH(idParam, handler.UpdateEngine)
+	g.PUT(idParam, handler.UpdateEngine)
+	g.PATCH(idParam, handler.PatchEngine)
 	g.DELETE(idParam, handler.DeleteEngine)
-}
\ No newline at end of file
+
+	g.GET("/trash", handler.ListTrashedEngines)
+	g.DELETE("/trash", handler.PurgeEngines)
+	g.POST(idParam+"/restore", handler.RestoreEngine)
+	g.GET(idParam+"/history", history.ListHistory)
+}
+
+func jobRoutes(repos *Repositories, api *gin.RouterGroup, version string) {
+	g := api.Group("/jobs")
+
+	service := jobservices.NewJobService(repos.Job)
+	handler := jobhandlers.NewJobHandler(service)
+	submit := carhandlers.NewJobHandler(service, version)
+
+	idParam := "/:id"
+
+	g.POST("/exports", submit.SubmitExport)
+	g.POST("/imports", submit.SubmitImport)
+
+	g.GET(idParam, handler.GetJob)
+	g.POST(idParam+"/cancel", handler.CancelJob)
+	g.GET(idParam+"/artifact", handler.GetArtifact)
+}
+
+// docsRoutes serves the OpenAPI document of each version under its prefix,
+// the latest one at the root too, and a Swagger UI page to browse them.
+func docsRoutes(r *gin.Engine, versions []string) {
+	var specs []openapi.Spec
+	latest := versions[len(versions)-1]
+	for _, version := range versions {
+		spec, err := json.Marshal(OpenAPI(version))
+		if err != nil {
+			panic(err)
+		}
+		serve := func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/json", spec)
+		}
+
+		// The version group applies the middlewares of the version.
+		r.Group(version, versionMiddlewares(version, latest)...).GET(specPath, serve)
+		if version == latest {
+			r.GET(specPath, serve)
+		}
+		specs = append([]openapi.Spec{{Name: strings.TrimPrefix(version, "/"), URL: version + specPath}}, specs...)
+	}
+	page := openapi.UIPage("Car management API", specs...)
+
+	r.GET(docsPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+}
@@ -0,0 +1,259 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/codepnw/go-car-management/database/memdb"
+	"github.com/codepnw/go-car-management/middlewares"
+	"github.com/codepnw/go-car-management/modules/cars/carv2"
+	"github.com/codepnw/go-car-management/openapi"
+	"github.com/codepnw/go-car-management/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+var testVersions = []string{V1, V2}
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	NewRoutes(NewMemoryRepositories(memdb.New()), &Config{Cursors: pagination.NewRandomSigner()}, r, testVersions...)
+	return r
+}
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// TestOpenAPICoversRoutes checks that the document of each version
+// describes exactly its routes and the routes shared by every version.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	r := newTestRouter()
+
+	for _, version := range testVersions {
+		doc := OpenAPI(version)
+
+		routed := make(map[string]bool)
+		for _, route := range r.Routes() {
+			path := ginParam.ReplaceAllString(route.Path, "{$1}")
+			if versioned(path) && !strings.HasPrefix(path, version+"/") {
+				continue
+			}
+			routed[route.Method+" "+path] = true
+			if doc.Operation(route.Method, path) == nil {
+				t.Errorf("%s %s is routed but missing from the %s OpenAPI document", route.Method, path, version)
+			}
+		}
+
+		ids := make(map[string]string)
+		for path, item := range doc.Paths {
+			for method, op := range *item {
+				key := strings.ToUpper(method) + " " + path
+				if !routed[key] {
+					t.Errorf("%s is in the %s OpenAPI document but not routed", key, version)
+				}
+				if prev, ok := ids[op.OperationID]; ok {
+					t.Errorf("%s and %s share the operation ID %q", prev, key, op.OperationID)
+				}
+				ids[op.OperationID] = key
+			}
+		}
+	}
+}
+
+func versioned(path string) bool {
+	for _, version := range testVersions {
+		if strings.HasPrefix(path, version+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOpenAPIRefsResolve(t *testing.T) {
+	for _, version := range testVersions {
+		t.Run(version, func(t *testing.T) {
+			assertRefsResolve(t, OpenAPI(version))
+		})
+	}
+}
+
+func assertRefsResolve(t *testing.T, spec *openapi.Document) {
+	t.Helper()
+
+	body, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for _, ref := range regexp.MustCompile(`"\$ref":"([^"]+)"`).FindAllStringSubmatch(string(body), -1) {
+		var node any = doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref[1], "#/"), "/") {
+			m, _ := node.(map[string]any)
+			node = m[part]
+		}
+		if node == nil {
+			t.Errorf("%s does not resolve", ref[1])
+		}
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	r := newTestRouter()
+
+	for _, path := range []string{V1 + specPath, V2 + specPath, specPath} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var doc struct {
+			OpenAPI string         `json:"openapi"`
+			Info    openapi.Info   `json:"info"`
+			Paths   map[string]any `json:"paths"`
+		}
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &doc) != nil || doc.OpenAPI == "" {
+			t.Fatalf("GET %s = %d %.200s", path, w.Code, w.Body)
+		}
+		if path == specPath && doc.Info.Version != "v2" {
+			t.Fatalf("GET %s serves %s, want the latest version", path, doc.Info.Version)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, docsPath, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), V1+specPath) || !strings.Contains(w.Body.String(), V2+specPath) {
+		t.Fatalf("GET %s = %d %.200s", docsPath, w.Code, w.Body)
+	}
+}
+
+func serve(t *testing.T, r *gin.Engine, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestVersionsSideBySide(t *testing.T) {
+	r := newTestRouter()
+
+	w := serve(t, r, http.MethodPost, V2+"/cars/", `{"name":"Model 3","year":2024,"brand":"Tesla","fuelType":"Electric",
+		"engine":{"carRange":500},"price":{"amount":"39990.50","currency":"USD"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("v2 create = %d %s", w.Code, w.Body)
+	}
+	var created struct {
+		Data struct {
+			ID     string `json:"id"`
+			Engine struct {
+				ID       string `json:"id"`
+				CarRange int    `json:"carRange"`
+			} `json:"engine"`
+			Price carv2.Money `json:"price"`
+			VIN   *string     `json:"vin"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	car := created.Data
+	if car.ID == "" || car.Engine.ID == "" || car.Engine.CarRange != 500 || car.Price != (carv2.Money{Amount: "39990.5", Currency: "USD"}) || car.VIN != nil {
+		t.Fatalf("v2 car = %s", w.Body)
+	}
+	if w.Header().Get("Deprecation") != "" {
+		t.Fatalf("v2 response carries Deprecation")
+	}
+
+	// The same car in v1, with its quirks.
+	w = serve(t, r, http.MethodGet, V1+"/cars/"+car.ID, "")
+	var v1 struct {
+		Data map[string]any `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &v1)
+	engine, _ := v1.Data["engineId"].(map[string]any)
+	if w.Code != http.StatusOK || v1.Data["carId"] != car.ID || v1.Data["price"] != 39990.5 || engine["engineId"] != car.Engine.ID {
+		t.Fatalf("v1 car = %d %s", w.Code, w.Body)
+	}
+	if w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") == "" || w.Header().Get("Link") != `</v2/openapi.json>; rel="successor-version"` {
+		t.Fatalf("v1 response headers = %v, want Deprecation, Sunset and a link to v2", w.Header())
+	}
+
+	// A v2 patch is written against the v2 form.
+	w = serve(t, r, http.MethodPatch, V2+"/cars/"+car.ID, `{"price":{"amount":"37500"}}`, "If-Match", "*")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"price":{"amount":"37500","currency":"USD"}`) {
+		t.Fatalf("v2 patch = %d %s", w.Code, w.Body)
+	}
+	w = serve(t, r, http.MethodPatch, V2+"/cars/"+car.ID, `{"price":{"currency":"EUR"}}`, "If-Match", "*")
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "price.currency") {
+		t.Fatalf("v2 patch with another currency = %d %s", w.Code, w.Body)
+	}
+	w = serve(t, r, http.MethodPatch, V2+"/cars/"+car.ID, `{"price":37500}`, "If-Match", "*")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("v2 patch with a bare price = %d %s", w.Code, w.Body)
+	}
+
+	// A patch that changes nothing keeps the version.
+	w = serve(t, r, http.MethodPatch, V2+"/cars/"+car.ID, `{"name":"Model 3"}`, "If-Match", "*")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("v2 no-op patch = %d %s, ETag %s", w.Code, w.Body, w.Header().Get("ETag"))
+	}
+
+	w = serve(t, r, http.MethodGet, V2+"/engines/"+car.Engine.ID, "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"id":"`+car.Engine.ID+`"`) {
+		t.Fatalf("v2 engine = %d %s", w.Code, w.Body)
+	}
+
+	// NDJSON exports and the history use the form of the version too.
+	w = serve(t, r, http.MethodGet, V2+"/cars/export?format=ndjson", "")
+	var line map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &line); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("v2 export = %d %s", w.Code, w.Body)
+	}
+	price, _ := line["price"].(map[string]any)
+	if _, ok := line["engineId"]; ok || line["id"] != car.ID || price["amount"] != "37500" {
+		t.Fatalf("v2 export line = %s", w.Body)
+	}
+	w = serve(t, r, http.MethodGet, V1+"/cars/export?format=ndjson", "")
+	if !strings.Contains(w.Body.String(), `"carId":"`+car.ID+`"`) || !strings.Contains(w.Body.String(), `"price":37500`) {
+		t.Fatalf("v1 export = %s", w.Body)
+	}
+
+	w = serve(t, r, http.MethodGet, V2+"/cars/"+car.ID+"/history", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `{"field":"price","before":{"amount":"39990.5","currency":"USD"},"after":{"amount":"37500","currency":"USD"}}`) ||
+		!strings.Contains(w.Body.String(), `"field":"engine.id"`) {
+		t.Fatalf("v2 history = %d %s", w.Code, w.Body)
+	}
+	w = serve(t, r, http.MethodGet, V1+"/cars/"+car.ID+"/history", "")
+	if !strings.Contains(w.Body.String(), `{"field":"price","before":39990.5,"after":37500}`) {
+		t.Fatalf("v1 history = %s", w.Body)
+	}
+}
+
+// TestV1Alone checks that v1 is not marked deprecated when no successor is
+// mounted beside it.
+func TestV1Alone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middlewares.ErrorHandler())
+	NewRoutes(NewMemoryRepositories(memdb.New()), &Config{Cursors: pagination.NewRandomSigner()}, r, V1)
+
+	for _, path := range []string{V1 + "/cars/", V1 + specPath} {
+		w := serve(t, r, http.MethodGet, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d %s", path, w.Code, w.Body)
+		}
+		for _, header := range []string{"Deprecation", "Sunset", "Link"} {
+			if got := w.Header().Get(header); got != "" {
+				t.Errorf("GET %s: %s = %q, want none", path, header, got)
+			}
+		}
+	}
+}
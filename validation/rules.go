@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type rule struct {
+	tag     string
+	fn      validator.Func
+	message string
+}
+
+var rules = []rule{
+	{"modelyear", modelYear, "{0} cannot be later than next year"},
+	{"vin", vin, "{0} must be a 17-character VIN without the letters I, O or Q"},
+	{"fuelengine", fuelEngine, "{0} does not match the engine: electric cars need an engine without displacement or cylinders, other fuel types need a combustion engine"},
+	{"combustion", combustion, "{0} must be greater than 0 for a combustion engine"},
+}
+
+var vinPattern = regexp.MustCompile(`^[A-HJ-NPR-Z0-9]{17}$`)
+
+// modelYear rejects model years after next year; manufacturers release the
+// next model year early, but not further ahead.
+func modelYear(fl validator.FieldLevel) bool {
+	year, ok := number(fl.Field())
+	return ok && year <= float64(time.Now().Year()+1)
+}
+
+func vin(fl validator.FieldLevel) bool {
+	return vinPattern.MatchString(fl.Field().String())
+}
+
+// fuelEngine checks a fuel type against the engine in the sibling field named
+// by the tag parameter, e.g. `validate:"fuelengine=Engine"`. The engine must
+// carry its Displacement and NoOfCylinders; a missing engine is left to
+// `required`.
+func fuelEngine(fl validator.FieldLevel) bool {
+	engine := fl.Parent().FieldByName(fl.Param())
+	if !engine.IsValid() {
+		return false
+	}
+	if engine.Kind() == reflect.Pointer {
+		if engine.IsNil() {
+			return true
+		}
+		engine = engine.Elem()
+	}
+
+	displacement, ok := number(engine.FieldByName("Displacement"))
+	if !ok {
+		return false
+	}
+	cylinders, ok := number(engine.FieldByName("NoOfCylinders"))
+	if !ok {
+		return false
+	}
+
+	switch fl.Field().String() {
+	case "Electric":
+		return displacement == 0 && cylinders == 0
+	case "Petrol", "Diesel":
+		return displacement > 0 && cylinders > 0
+	case "Hybrid":
+		return displacement > 0
+	}
+	return true
+}
+
+// combustion requires a positive displacement when the sibling cylinder
+// field named by the tag parameter is set, e.g. `validate:"combustion=NoOfCylinders"`.
+// Engines without cylinders are electric and may have no displacement.
+func combustion(fl validator.FieldLevel) bool {
+	cylinders := fl.Parent().FieldByName(fl.Param())
+	if !cylinders.IsValid() || cylinders.IsZero() {
+		return true
+	}
+	return !fl.Field().IsZero()
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
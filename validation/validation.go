@@ -0,0 +1,154 @@
+// Package validation is the shared request validator. It wraps
+// go-playground/validator with the domain rules in rules.go and turns
+// failures into apperrors validation errors with one readable message per
+// field.
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+)
+
+type Validator struct {
+	validate *validator.Validate
+	trans    ut.Translator
+}
+
+var std = New()
+
+// New returns a validator with the built-in English messages and every
+// custom rule in rules.go registered.
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON name, which is what clients sent.
+	validate.RegisterTagNameFunc(jsonName)
+
+	locale := en.New()
+	trans, _ := ut.New(locale, locale).GetTranslator("en")
+	if err := entranslations.RegisterDefaultTranslations(validate, trans); err != nil {
+		panic(err)
+	}
+
+	v := &Validator{validate: validate, trans: trans}
+	for _, r := range rules {
+		if err := v.RegisterRule(r.tag, r.fn, r.message); err != nil {
+			panic(err)
+		}
+	}
+
+	return v
+}
+
+// RegisterRule adds a field rule usable as a struct tag. message is the text
+// shown to clients; {0} is replaced by the field name and {1} by the tag
+// parameter. Rules must be registered before the validator is used.
+func (v *Validator) RegisterRule(tag string, fn validator.Func, message string) error {
+	if err := v.validate.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+
+	return v.validate.RegisterTranslation(tag, v.trans,
+		func(trans ut.Translator) error {
+			return trans.Add(tag, message, true)
+		},
+		func(trans ut.Translator, fe validator.FieldError) string {
+			msg, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return msg
+		},
+	)
+}
+
+// Struct validates s and returns an *apperrors.Error listing every failing
+// field, or nil.
+func (v *Validator) Struct(s any) error {
+	return v.translate(v.validate.Struct(s))
+}
+
+// StructPartial validates only the listed top-level fields of s, given by
+// their JSON names.
+func (v *Validator) StructPartial(s any, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	t := reflect.TypeOf(s)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	names := make([]string, 0, len(fields))
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if slices.Contains(fields, jsonName(f)) {
+			names = append(names, f.Name)
+		}
+	}
+
+	return v.translate(v.validate.StructPartial(s, names...))
+}
+
+func (v *Validator) translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]apperrors.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, apperrors.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Message: fe.Translate(v.trans),
+		})
+	}
+
+	return apperrors.Validation(err, fields...)
+}
+
+func jsonName(f reflect.StructField) string {
+	name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// fieldPath drops the root struct name: "CarRequest.engine.engineId"
+// becomes "engine.engineId".
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func Struct(s any) error {
+	return std.Struct(s)
+}
+
+func StructPartial(s any, fields ...string) error {
+	return std.StructPartial(s, fields...)
+}
+
+// RegisterRule adds a rule to the shared validator used by Struct.
+func RegisterRule(tag string, fn validator.Func, message string) error {
+	return std.RegisterRule(tag, fn, message)
+}
@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/codepnw/go-car-management/apperrors"
+	"github.com/codepnw/go-car-management/modules/cars"
+	"github.com/codepnw/go-car-management/modules/engines"
+	"github.com/google/uuid"
+)
+
+func validCar() *cars.CarRequest {
+	return &cars.CarRequest{
+		Name:     "Civic",
+		Year:     2020,
+		Brand:    "Honda",
+		FuelType: "Petrol",
+		Engine:   &engines.Engine{EngineID: uuid.New(), Displacement: 1998, NoOfCylinders: 4, CarRange: 600},
+		Price:    25000,
+		VIN:      "1HGCM82633A004352",
+	}
+}
+
+func TestStruct(t *testing.T) {
+	nextYear := uint16(time.Now().Year() + 1)
+
+	tests := []struct {
+		name      string
+		mutate    func(req *cars.CarRequest)
+		wantField string
+	}{
+		{"valid", func(req *cars.CarRequest) {}, ""},
+		{"next model year", func(req *cars.CarRequest) { req.Year = nextYear }, ""},
+		{"model year too far ahead", func(req *cars.CarRequest) { req.Year = nextYear + 1 }, "year"},
+		{"missing name", func(req *cars.CarRequest) { req.Name = "" }, "name"},
+		{"vin with letter O", func(req *cars.CarRequest) { req.VIN = "1HGCM82633AO04352" }, "vin"},
+		{"short vin", func(req *cars.CarRequest) { req.VIN = "1HGCM8263" }, "vin"},
+		{"no vin", func(req *cars.CarRequest) { req.VIN = "" }, ""},
+		{"electric with combustion engine", func(req *cars.CarRequest) { req.FuelType = "Electric" }, "fuelType"},
+		{"petrol with electric engine", func(req *cars.CarRequest) {
+			req.Engine = &engines.Engine{EngineID: uuid.New(), CarRange: 400}
+		}, "fuelType"},
+		{"electric with electric engine", func(req *cars.CarRequest) {
+			req.FuelType = "Electric"
+			req.Engine = &engines.Engine{EngineID: uuid.New(), CarRange: 400}
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validCar()
+			tt.mutate(req)
+
+			err := Struct(req)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Struct: %v", err)
+				}
+				return
+			}
+
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidation {
+				t.Fatalf("Struct error = %v, want a validation error", err)
+			}
+			if len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.wantField {
+				t.Fatalf("fields = %+v, want one error on %q", appErr.Fields, tt.wantField)
+			}
+			if appErr.Fields[0].Message == "" {
+				t.Fatal("field error has no message")
+			}
+		})
+	}
+}
+
+func TestStructEngineDisplacement(t *testing.T) {
+	for _, tt := range []struct {
+		req   engines.EngineRequest
+		valid bool
+	}{
+		{engines.EngineRequest{Displacement: 1998, NoOfCylinders: 4, CarRange: 600}, true},
+		{engines.EngineRequest{Displacement: 0, NoOfCylinders: 0, CarRange: 400}, true},
+		{engines.EngineRequest{Displacement: 0, NoOfCylinders: 4, CarRange: 600}, false},
+	} {
+		t.Run(strconv.Itoa(int(tt.req.NoOfCylinders)), func(t *testing.T) {
+			err := Struct(&tt.req)
+			if (err == nil) != tt.valid {
+				t.Fatalf("Struct(%+v) = %v, want valid=%v", tt.req, err, tt.valid)
+			}
+			var appErr *apperrors.Error
+			if err != nil && (!errors.As(err, &appErr) || appErr.Fields[0].Message != "displacement must be greater than 0 for a combustion engine") {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStructPartial(t *testing.T) {
+	req := validCar()
+	req.Name = ""
+	req.Year = 1800
+
+	if err := StructPartial(req, "price", "fuelType"); err != nil {
+		t.Fatalf("StructPartial on valid fields = %v", err)
+	}
+
+	err := StructPartial(req, "year")
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) || len(appErr.Fields) != 1 || appErr.Fields[0].Field != "year" {
+		t.Fatalf("StructPartial(year) = %v, want only a year error", err)
+	}
+
+	// Cross-field rules still see the rest of the struct.
+	req.Engine.Displacement, req.Engine.NoOfCylinders = 0, 0
+	if err := StructPartial(req, "fuelType"); !errors.Is(err, apperrors.ErrValidation) {
+		t.Fatalf("StructPartial(fuelType) with an electric engine = %v, want validation error", err)
+	}
+}